@@ -20,18 +20,34 @@ func main() {
 	pOutputFolder := flag.String("output", "./", "Output folder")
 	pNumWorkers := flag.Int("workers", 3, "Number of goroutines to run in parallel")
 	pPatternsFile := flag.String("patterns", "patterns.txt", "File with hex patterns")
+	pPrune := flag.Bool("prune", false, "Leave out dead, captured and dominated cells when expanding nodes")
+	pSymmetry := flag.String("symmetry", "none", "Handling of symmetric states in samples: none, dedupe (write canonical states once) or augment (write all symmetric variants)")
+	pFormat := flag.String("format", "csv", "Format of sample files: csv or jsonl (self-describing, with boards, visit counts and search IDs)")
+	pPlanes := flag.Bool("planes", false, "Include a feature for each cell and player in samples")
 	flag.Parse()
 	boardSize, secondsToRun, thresholdN, numWorkers, patternsFile := *pBoardSize, *pSecondsToRun, *pThresholdN, *pNumWorkers, *pPatternsFile
 	writeJSON, indentJSON, outputFolder := *pWriteJSON, *pIndentJSON, *pOutputFolder
+	prune, planes := *pPrune, *pPlanes
+	symmetry, err := mcts.GetSampleSymmetryModeFromString(*pSymmetry)
+	if err != nil {
+		panic(err)
+	}
+	format, err := mcts.GetSampleFormatFromString(*pFormat)
+	if err != nil {
+		panic(err)
+	}
 
-	fmt.Printf("Using boardSize = %d, secondsToRun = %d, numWorkers = %d, patternsFile = %s, writeJSON = %t, indentJSON = %t, outputFolder = %s, thresholdN = %d\n",
-		boardSize, secondsToRun, numWorkers, patternsFile, writeJSON, indentJSON, outputFolder, thresholdN)
+	fmt.Printf("Using boardSize = %d, secondsToRun = %d, numWorkers = %d, patternsFile = %s, writeJSON = %t, indentJSON = %t, outputFolder = %s, thresholdN = %d, prune = %t, symmetry = %s, format = %s, planes = %t\n",
+		boardSize, secondsToRun, numWorkers, patternsFile, writeJSON, indentJSON, outputFolder, thresholdN, prune, symmetry, format, planes)
 
 	// Init the algorithm
 	initState := hex.NewState(byte(boardSize), hex.Red)
 	explorationFactor := math.Sqrt(2)
 	minBeforeExpand := uint(10)
 	mc := mcts.InitMCTS(*initState, explorationFactor, minBeforeExpand)
+	mc.SetPruning(prune)
+	mc.SetSampleSymmetry(symmetry)
+	mc.SetCellPlanes(planes)
 	var root *mcts.MCTS
 	if writeJSON {
 		root = mc
@@ -39,7 +55,7 @@ func main() {
 
 	// Run the algorithm
 	mcts.RunMCTSinParallel(numWorkers, boardSize, thresholdN, time.Duration(secondsToRun)*time.Second,
-		outputFolder, patternsFile, mc, false)
+		outputFolder, patternsFile, mc, format, false)
 
 	if writeJSON {
 		// Write JSON
@@ -1,33 +1,117 @@
 package mcts
 
 import (
-	"os"
+	"fmt"
+	"sync"
 
+	"github.com/RdecKa/0xAI/common/game/hex"
 	"github.com/RdecKa/0xAI/common/tree"
 )
 
+// ------------------------------
+// |     SampleSymmetryMode     |
+// ------------------------------
+
+// SampleSymmetryMode determines how symmetric states are handled when samples
+// are written (see hex.Symmetry)
+type SampleSymmetryMode byte
+
+// enum for sample symmetry modes
+const (
+	SymmetryNone    SampleSymmetryMode = iota // Each state is written as it is
+	SymmetryDedupe                            // Canonical forms of states are written, each only once
+	SymmetryAugment                           // All symmetric variants of each state are written
+)
+
+func (m SampleSymmetryMode) String() string {
+	switch m {
+	case SymmetryDedupe:
+		return "dedupe"
+	case SymmetryAugment:
+		return "augment"
+	default:
+		return "none"
+	}
+}
+
+// GetSampleSymmetryModeFromString returns the SampleSymmetryMode with a given
+// name (see SampleSymmetryMode.String)
+func GetSampleSymmetryModeFromString(s string) (SampleSymmetryMode, error) {
+	for _, m := range []SampleSymmetryMode{SymmetryNone, SymmetryDedupe, SymmetryAugment} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return SymmetryNone, fmt.Errorf("Unknown sample symmetry mode '%s'", s)
+}
+
+// sampleFilter holds the symmetry mode and keys of canonical states that have
+// already been written. It is shared by all searches that continue from the
+// same search, so that states are not duplicated across workers.
+type sampleFilter struct {
+	mode SampleSymmetryMode
+	mu   sync.Mutex
+	seen map[uint64]bool
+}
+
+// newSampleFilter returns a new sampleFilter with the given mode
+func newSampleFilter(mode SampleSymmetryMode) *sampleFilter {
+	return &sampleFilter{mode: mode, seen: make(map[uint64]bool)}
+}
+
+// getStatesToWrite returns the states that are written as samples for State
+// s. Each of them also produces a sample of its color-swapped transpose (see
+// hex.State.GenSample).
+func (sf *sampleFilter) getStatesToWrite(s hex.State) []hex.State {
+	if sf == nil {
+		return []hex.State{s}
+	}
+	switch sf.mode {
+	case SymmetryDedupe:
+		c, _ := s.Canonical()
+		sf.mu.Lock()
+		defer sf.mu.Unlock()
+		if sf.seen[c.GetMapKey()] {
+			return nil
+		}
+		sf.seen[c.GetMapKey()] = true
+		return []hex.State{c}
+	case SymmetryAugment:
+		if r := s.Transform(hex.Rotation180); !s.Same(&r) {
+			return []hex.State{s, r}
+		}
+	}
+	return []hex.State{s}
+}
+
 // GenSamples traverses the MCTS tree and writes samples (nodes that have been
-// visited at least thresholdN times) to an outputFile. It returns possible
+// visited at least thresholdN times) with SampleWriter sw. It returns possible
 // candidates for later MCTS
-func (mcts *MCTS) GenSamples(outputFile *os.File, thresholdN uint, gridChan chan []uint32,
-	patChan chan []int, resultChan chan [2][]int) ([]*tree.Node, error) {
+func (mcts *MCTS) GenSamples(sw SampleWriter, thresholdN uint) ([]*tree.Node, error) {
 
 	// Write samples to a file
 	root := mcts.mcTree.GetRoot()
-	expandCandidates := genSamples(root, outputFile, thresholdN, gridChan, patChan, resultChan)
-	return expandCandidates, nil
+	return genSamples(root, sw, thresholdN, mcts.samples)
 }
 
 // genSamples traverses the MCTS tree starting from Node node and writes samples
-// to a File file. It returns possible candidates for later MCTS
-func genSamples(node *tree.Node, outputFile *os.File, thresholdN uint, gridChan chan []uint32,
-	patChan chan []int, resultChan chan [2][]int) []*tree.Node {
+// with SampleWriter sw. Symmetric states are handled by sampleFilter sf (nil
+// means that each state is written as it is). It returns possible candidates
+// for later MCTS
+func genSamples(node *tree.Node, sw SampleWriter, thresholdN uint, sf *sampleFilter) ([]*tree.Node, error) {
 	mnv := node.GetValue().(*mctsNodeValue)
 	expandCandidates := make([]*tree.Node, 0, 20)
 	if mnv.n >= thresholdN {
-		outputFile.WriteString(mnv.state.GenSample(mnv.q, gridChan, patChan, resultChan))
+		for _, state := range sf.getStatesToWrite(mnv.state.(hex.State)) {
+			if err := sw.WriteSample(state, mnv.q, mnv.n); err != nil {
+				return nil, err
+			}
+		}
 		for _, c := range node.GetChildren() {
-			g := genSamples(c, outputFile, thresholdN, gridChan, patChan, resultChan)
+			g, err := genSamples(c, sw, thresholdN, sf)
+			if err != nil {
+				return nil, err
+			}
 			expandCandidates = append(expandCandidates, g...)
 		}
 	} else {
@@ -35,5 +119,5 @@ func genSamples(node *tree.Node, outputFile *os.File, thresholdN uint, gridChan
 		// following MCTS
 		expandCandidates = append(expandCandidates, node)
 	}
-	return expandCandidates
+	return expandCandidates, nil
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
-	"os"
 	"time"
 
 	"github.com/RdecKa/0xAI/common/game"
@@ -43,9 +42,12 @@ func (mnv *mctsNodeValue) GetState() game.State {
 
 // MCTS represens Monte Carlo Tree Search
 type MCTS struct {
-	mcTree *tree.Tree // Monte Carlo tree
-	c      float64    // exploration parameter
-	minN   uint       // minimal number of visits of a node before it can be expanded
+	mcTree  *tree.Tree    // Monte Carlo tree
+	c       float64       // exploration parameter
+	minN    uint          // minimal number of visits of a node before it can be expanded
+	prune   bool          // if true, inferior actions are left out in expansion (see game.PrunedState)
+	samples *sampleFilter // handling of symmetric states in samples, nil if they are written as they are
+	planes  bool          // if true, samples include planes of stones (see hex.PatternMatcher.WithCellPlanes)
 }
 
 func (mcts *MCTS) String() string {
@@ -57,13 +59,33 @@ func InitMCTS(s game.State, c float64, minN uint) *MCTS {
 	node := createMCTSNode(s)
 	mctsTree := tree.NewTree(node)
 	rand.Seed(time.Now().UTC().UnixNano())
-	return &MCTS{mctsTree, c, minN}
+	return &MCTS{mctsTree, c, minN, false, nil, false}
 }
 
 // ContinueMCTSFromNode continues MCTS from Node node
 func (mcts *MCTS) ContinueMCTSFromNode(node *tree.Node) *MCTS {
 	mctsTree := tree.NewTree(node)
-	return &MCTS{mctsTree, mcts.c, mcts.minN}
+	return &MCTS{mctsTree, mcts.c, mcts.minN, mcts.prune, mcts.samples, mcts.planes}
+}
+
+// SetPruning sets whether actions that are provably inferior are left out when
+// nodes are expanded. It has effect only for states that implement
+// game.PrunedState.
+func (mcts *MCTS) SetPruning(prune bool) {
+	mcts.prune = prune
+}
+
+// SetSampleSymmetry sets how symmetric states are handled when samples are
+// written. Searches that continue from this search share the set of states
+// that have already been written.
+func (mcts *MCTS) SetSampleSymmetry(mode SampleSymmetryMode) {
+	mcts.samples = newSampleFilter(mode)
+}
+
+// SetCellPlanes sets whether learning samples include a feature for each cell
+// of the board and each player (see hex.AttrCellPlane)
+func (mcts *MCTS) SetCellPlanes(planes bool) {
+	mcts.planes = planes
 }
 
 // createMCTSNode creates new node with value {state=s, n=0, q=0}
@@ -78,12 +100,12 @@ func (mcts *MCTS) GetInitialNode() *tree.Node {
 	return mcts.mcTree.GetRoot()
 }
 
-// RunMCTS executes iterations of MCTS for timeToRun, given initialised MCTS
+// RunMCTS executes iterations of MCTS for timeToRun, given initialised MCTS,
+// and writes samples with SampleWriter sw.
 // If gameLengthImportant is true, then a goal state with a shorter path to
 // victory gets a higher estimated value than a goal state with a longer path.
 func RunMCTS(mc *MCTS, timeToRun time.Duration, thresholdN uint,
-	outputFile *os.File, gridChan chan []uint32, patChan chan []int,
-	resultChan chan [2][]int, gameLengthImportant bool) ([]*tree.Node, error) {
+	sw SampleWriter, gameLengthImportant bool) ([]*tree.Node, error) {
 
 	timer := time.NewTimer(timeToRun)
 
@@ -100,7 +122,7 @@ func RunMCTS(mc *MCTS, timeToRun time.Duration, thresholdN uint,
 
 	// Write input-output pairs for supervised machine learning, generate
 	// new nodes to continue MCTS
-	expCand, err := mc.GenSamples(outputFile, thresholdN, gridChan, patChan, resultChan)
+	expCand, err := mc.GenSamples(sw, thresholdN)
 	if err != nil {
 		return nil, err
 	}
@@ -114,12 +136,13 @@ func (mcts *MCTS) RunIteration(gameLengthImportant bool) {
 
 // selExpPlayBack performs one iteration of MCTS
 // Phases of MCTS:
-// 	selection: recursively call itself on the node's child with the highest
+//
+//	selection: recursively call itself on the node's child with the highest
 //		UCT value
-// 	expansion: expand leaf node that was reached by recursive call (only if the
+//	expansion: expand leaf node that was reached by recursive call (only if the
 //		node has been visited often enough)
-// 	playout: randomly select moves until goal state is reached
-// 	backpropagation: update values on nodes on selected branch in the tree
+//	playout: randomly select moves until goal state is reached
+//	backpropagation: update values on nodes on selected branch in the tree
 func (mcts *MCTS) selExpPlayBack(node *tree.Node, gameLengthImportant bool) float64 {
 	children := node.GetChildren()
 	nodeValue := node.GetValue().(*mctsNodeValue)
@@ -184,7 +207,7 @@ func (mcts *MCTS) expansion(node *tree.Node) {
 	nodeValue := node.GetValue().(*mctsNodeValue)
 	state := nodeValue.state
 
-	if g, _ := state.IsGoalState(false); g {
+	if g, _ := state.IsGoalState(true); g {
 		// Do not expand goal states
 		return
 	}
@@ -194,7 +217,12 @@ func (mcts *MCTS) expansion(node *tree.Node) {
 		return
 	}
 
-	possibleActions := state.GetPossibleActions()
+	var possibleActions []game.Action
+	if ps, ok := state.(game.PrunedState); ok && mcts.prune {
+		possibleActions = ps.GetPrunedActions()
+	} else {
+		possibleActions = state.GetPossibleActions()
+	}
 	successorNodes := make([]*tree.Node, len(possibleActions))
 
 	for i, action := range possibleActions {
@@ -210,18 +238,25 @@ func (mcts *MCTS) playout(node *tree.Node, gameLengthImportant bool) float64 {
 	return playoutFromState(state, gameLengthImportant)
 }
 
-// playoutFromState recursively performs a random action from the list of
-// possible actions. After reaching a goal state it returns its value
+// playoutFromState performs random actions from the list of possible actions
+// on a mutable copy of state. After reaching a goal state it returns its value
+// from the perspective of the player who made the last action in state. Only
+// actual connections end the playout, because looking for virtual connections
+// after each action would take most of the time of the playout.
 func playoutFromState(state game.State, gameLengthImportant bool) float64 {
-	if g, _ := state.IsGoalState(false); g {
-		return state.EvaluateGoalState(gameLengthImportant)
-	}
-	possibleActions := state.GetPossibleActions()
-	if len(possibleActions) == 0 {
-		panic(fmt.Sprintf("Not in a goal state yet, but no action possible. Something is wrong."))
+	board := state.GetBoard()
+	sign := 1.0
+	for {
+		if g, _ := board.IsGoalState(true); g {
+			return sign * board.EvaluateGoalState(gameLengthImportant)
+		}
+		possibleActions := board.GetPossibleActions()
+		if len(possibleActions) == 0 {
+			panic(fmt.Sprintf("Not in a goal state yet, but no action possible. Something is wrong."))
+		}
+		board.Play(possibleActions[rand.Intn(len(possibleActions))])
+		sign = -sign
 	}
-	randomAction := possibleActions[rand.Intn(len(possibleActions))]
-	return -playoutFromState(state.GetSuccessorState(randomAction), gameLengthImportant)
 }
 
 // getUCTValue calculates UCT value of a Node node.
@@ -254,7 +289,11 @@ func (mcts *MCTS) ContinueMCTSFromChild(state game.State) *MCTS {
 		}
 	}
 	// Not possible to continue previously started search, start from scratch.
-	return InitMCTS(state, mcts.c, mcts.minN)
+	mc := InitMCTS(state, mcts.c, mcts.minN)
+	mc.SetPruning(mcts.prune)
+	mc.SetCellPlanes(mcts.planes)
+	mc.samples = mcts.samples
+	return mc
 }
 
 // GetBestRootChildState returns agame.State of the direct descendant of the
@@ -0,0 +1,75 @@
+package mcts
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/RdecKa/0xAI/common/game"
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// playoutFromStateSearch performs a playout like playoutFromState, but ends it
+// as soon as the game is decided by a virtual connection, which needs a search
+// after each action. It is used only to compare the speed of both playouts.
+func playoutFromStateSearch(state game.State, gameLengthImportant bool) float64 {
+	board := state.GetBoard()
+	sign := 1.0
+	for {
+		if g, _ := board.IsGoalState(false); g {
+			return sign * board.EvaluateGoalState(gameLengthImportant)
+		}
+		possibleActions := board.GetPossibleActions()
+		board.Play(possibleActions[rand.Intn(len(possibleActions))])
+		sign = -sign
+	}
+}
+
+func TestPlayout(t *testing.T) {
+	rand.Seed(1)
+	for _, size := range []byte{3, 5, 7} {
+		state := hex.NewState(size, hex.Red)
+		for i := 0; i < 100; i++ {
+			if v := playoutFromState(state, false); v != 1 && v != -1 {
+				t.Fatalf("Size %d: expected value 1 or -1, got %f", size, v)
+			}
+			// The value also counts empty cells at the end of the game
+			if v := math.Abs(playoutFromState(state, true)); v < 1 || v > float64(size)*float64(size) {
+				t.Fatalf("Size %d: invalid value %f", size, v)
+			}
+		}
+	}
+}
+
+func benchmarkPlayout(b *testing.B, playout func(game.State, bool) float64) {
+	for _, size := range []byte{7, 11} {
+		b.Run(fmt.Sprintf("size%d", size), func(b *testing.B) {
+			rand.Seed(1)
+			state := hex.NewState(size, hex.Red)
+			for n := 0; n < b.N; n++ {
+				playout(state, false)
+			}
+		})
+	}
+}
+
+func BenchmarkPlayout(b *testing.B) {
+	benchmarkPlayout(b, playoutFromState)
+}
+
+func BenchmarkPlayoutSearch(b *testing.B) {
+	benchmarkPlayout(b, playoutFromStateSearch)
+}
+
+func TestContinueMCTSFromChildSettings(t *testing.T) {
+	mc := InitMCTS(hex.NewState(3, hex.Red), 1, 5)
+	mc.SetPruning(true)
+	mc.SetCellPlanes(true)
+
+	// The tree has no grandchildren yet, so the search starts from scratch
+	next := mc.ContinueMCTSFromChild(hex.NewState(3, hex.Blue))
+	if !next.prune || !next.planes {
+		t.Fatalf("Expected settings to be kept, got prune %t, planes %t", next.prune, next.planes)
+	}
+}
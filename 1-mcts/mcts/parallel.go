@@ -22,11 +22,12 @@ type workerChan struct {
 // RunMCTSinParallel takes care of running MCTS in parallel. It creates
 // numWorkers workers - each of them runs one instance of MCTS at once.
 // Iterations of MCTS are run on board of size boardSize for timeToRun. mc is
-// the initialised search that is completed first.
+// the initialised search that is completed first. Samples are written in a
+// given format.
 // If gameLengthImportant is true, then a goal state with a shorter path to
 // victory gets a higher estimated value than a goal state with a longer path.
 func RunMCTSinParallel(numWorkers, boardSize int, thresholdN uint, timeToRun time.Duration,
-	outputFolder, patFileName string, mc *MCTS, gameLengthImportant bool) {
+	outputFolder, patFileName string, mc *MCTS, format SampleFormat, gameLengthImportant bool) {
 	var err error
 
 	assign := make(chan *MCTS, numWorkers)
@@ -44,6 +45,15 @@ func RunMCTSinParallel(numWorkers, boardSize int, thresholdN uint, timeToRun tim
 
 	wc := workerChan{assign, gather, e, quit, terminated}
 
+	// Patterns are shared by all workers
+	pm, err := hex.Load(patFileName)
+	if err != nil {
+		panic(err)
+	}
+	if mc.planes {
+		pm = pm.WithCellPlanes(boardSize)
+	}
+
 	assign <- mc // Send first task
 
 	// Create a boss
@@ -75,9 +85,15 @@ func RunMCTSinParallel(numWorkers, boardSize int, thresholdN uint, timeToRun tim
 		}
 		defer fDet.Close()
 
+		// Create a writer of samples, which writes the header of the file
+		sw, err := NewSampleWriter(f, pm, format)
+		if err != nil {
+			panic(err)
+		}
+
 		// Start a worker process
-		go worker(w, timeToRun, boardSize, thresholdN, f, fDet, logFile,
-			patFileName, &wc, gameLengthImportant)
+		go worker(w, timeToRun, boardSize, thresholdN, sw, fDet, logFile,
+			&wc, gameLengthImportant)
 	}
 
 	candidateList := NewCandidateList(boardSize)
@@ -130,20 +146,18 @@ func RunMCTSinParallel(numWorkers, boardSize int, thresholdN uint, timeToRun tim
 // worker waits for tasks and executes them in an infinite loop until the quit
 // signal
 func worker(id int, timeToRun time.Duration, boardSize int, thresholdN uint,
-	outputFile, outputFileDet, logFile *os.File, patFileName string,
+	sw SampleWriter, outputFileDet, logFile *os.File,
 	wc *workerChan, gameLengthImportant bool) {
 
 	var mc *MCTS
 	taskID := 0
-	gridChan, patChan, stopChan, resultChan := hex.CreatePatChecker(patFileName)
-	outputFile.WriteString(hex.GetHeaderCSV())
 	for {
 		select {
 		case mc = <-wc.assign:
-			outputFile.WriteString(fmt.Sprintf("# Search ID %d\n", taskID))
+			sw.SetSearchID(taskID)
 			outputFileDet.WriteString(fmt.Sprintf("# Search ID %d started from:\n%v\n", taskID, mc.GetInitialNode()))
 			expCand, err := RunMCTS(mc, timeToRun, thresholdN,
-				outputFile, gridChan, patChan, resultChan, gameLengthImportant)
+				sw, gameLengthImportant)
 			if err != nil {
 				wc.e <- err
 			}
@@ -152,7 +166,6 @@ func worker(id int, timeToRun time.Duration, boardSize int, thresholdN uint,
 			taskID++
 		case <-wc.quit:
 			logFile.WriteString(fmt.Sprintf("Worker %d terminated\n", id))
-			stopChan <- struct{}{} // Stop the pattern checker
 			wc.terminated <- struct{}{}
 			return
 		}
@@ -0,0 +1,83 @@
+package mcts
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// ------------------------
+// |     SampleFormat     |
+// ------------------------
+
+// SampleFormat is the format of files with learning samples
+type SampleFormat byte
+
+// enum for sample formats
+const (
+	SampleFormatCSV   SampleFormat = iota // Attributes in CSV with comments (see hex.GenSample)
+	SampleFormatJSONL                     // Self-describing JSON Lines (see hex.SampleWriter)
+)
+
+func (f SampleFormat) String() string {
+	if f == SampleFormatJSONL {
+		return "jsonl"
+	}
+	return "csv"
+}
+
+// GetSampleFormatFromString returns the SampleFormat with a given name (see
+// SampleFormat.String)
+func GetSampleFormatFromString(s string) (SampleFormat, error) {
+	for _, f := range []SampleFormat{SampleFormatCSV, SampleFormatJSONL} {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return SampleFormatCSV, fmt.Errorf("Unknown sample format '%s'", s)
+}
+
+// ------------------------
+// |     SampleWriter     |
+// ------------------------
+
+// SampleWriter writes learning samples of states found by MCTS
+type SampleWriter interface {
+	SetSearchID(id int)
+	WriteSample(s hex.State, q float64, n uint) error
+}
+
+// NewSampleWriter returns a SampleWriter that writes samples in a given format
+// to w. Samples are computed with PatternMatcher pm. The header of the format
+// is written immediately.
+func NewSampleWriter(w io.Writer, pm *hex.PatternMatcher, format SampleFormat) (SampleWriter, error) {
+	if format == SampleFormatJSONL {
+		sw, err := hex.NewSampleWriter(w, pm)
+		if err != nil {
+			return nil, err
+		}
+		return sw, nil
+	}
+	if _, err := io.WriteString(w, hex.GetHeaderCSV(pm)); err != nil {
+		return nil, err
+	}
+	return &csvSampleWriter{w, pm}, nil
+}
+
+// csvSampleWriter writes samples in the CSV format. Each pair of samples is
+// preceded by a comment with the state in the board notation and the samples
+// of each search by a comment with the search ID.
+type csvSampleWriter struct {
+	w  io.Writer
+	pm *hex.PatternMatcher
+}
+
+func (cw *csvSampleWriter) SetSearchID(id int) {
+	io.WriteString(cw.w, fmt.Sprintf("# Search ID %d\n", id))
+}
+
+func (cw *csvSampleWriter) WriteSample(s hex.State, q float64, n uint) error {
+	_, err := io.WriteString(cw.w, fmt.Sprintf("# Board %s\n%s", s.GetBoardNotation(), s.GenSample(q, cw.pm)))
+	return err
+}
@@ -1,4 +1,3 @@
-import math
 
 from sklearn import tree
 from sklearn.tree import _tree, DecisionTreeRegressor
@@ -56,13 +55,14 @@ class DecisionTreeModel(Model):
                 if decision_tree.feature[node] != _tree.TREE_UNDEFINED:
                     feature = self.feature_names[decision_tree.feature[node]]
                     threshold = decision_tree.threshold[node]
-                    code_file.write("{}if s.{} <= {} {{\n".format(indent, feature, math.floor(threshold)))
+                    code_file.write("{}if s.{} <= {} {{\n".format(indent, feature, threshold))
                     subtree_to_go_code(decision_tree.children_left[node], depth + 1)
                     code_file.write("{}}}\n".format(indent))
                     subtree_to_go_code(decision_tree.children_right[node], depth)
                 else:
                     code_file.write("{}return {}\n".format(indent, decision_tree.value[node][0][0]))
 
+            code_file.write("//go:build gencode\n// +build gencode\n\n")
             code_file.write("// Package ab (Code generated by a Python script)\n")
             code_file.write("package ab\n\n")
             code_file.write("func getEstimatedValueDT(s *Sample) float64 {\n")
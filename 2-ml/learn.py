@@ -18,14 +18,27 @@ import analyse as ana
 
 def write_sample_file(outfolder, feature_names):
     with open(outfolder + "sample.go", "w") as sample_file:
+        sample_file.write("//go:build gencode\n// +build gencode\n\n")
         sample_file.write("// Package ab (Code generated by a Python script)\n")
         sample_file.write("package ab\n\n")
         sample_file.write("type Sample struct {\n\t")
-        sample_file.write(feature_names[0])
-        for f in feature_names[1:]:
-            sample_file.write(", " + f)
-        sample_file.write(" int\n")
-        sample_file.write("}")
+        sample_file.write(", ".join(feature_names))
+        sample_file.write(" float64\n")
+        sample_file.write("}\n\n")
+
+        # Names of features are checked by AB, values are given in the same
+        # order as in the header of learning samples
+        sample_file.write("// sampleAttributes are names of attributes in the order of fields of Sample\n")
+        sample_file.write("var sampleAttributes = []string{")
+        sample_file.write(", ".join("\"" + f + "\"" for f in feature_names))
+        sample_file.write("}\n\n")
+        sample_file.write("// newSample returns a Sample with values of attributes f, given in the order\n")
+        sample_file.write("// of sampleAttributes\n")
+        sample_file.write("func newSample(f []float64) *Sample {\n")
+        sample_file.write("\treturn &Sample{")
+        sample_file.write(", ".join("f[" + str(i) + "]" for i in range(len(feature_names))))
+        sample_file.write("}\n")
+        sample_file.write("}\n")
 
 
 def sort_value(x):
@@ -64,37 +77,10 @@ def main(argv):
 
     # Read data from file
     print("Reading data from file:", datafile)
-    df = pd.read_csv(datafile, comment="#",
-                     dtype={"value": np.float64, "num_stones": np.uint8,
-                            "lp": np.uint8, "sdtc_r": np.uint16, "sdtc_b": np.uint16,
-                            "rec_r": np.uint16, "rec_b": np.uint16,
-                            "occ_red_rows": np.uint8, "occ_red_cols": np.uint8,
-                            "occ_blue_rows": np.uint8, "occ_blue_cols": np.uint8,
-                            "red_p0": np.uint8, "blue_p0": np.uint8,
-                            "red_p1": np.uint8, "blue_p1": np.uint8,
-                            "red_p2": np.uint8, "blue_p2": np.uint8,
-                            "red_p3": np.uint8, "blue_p3": np.uint8,
-                            "red_p4": np.uint8, "blue_p4": np.uint8,
-                            "red_p5": np.uint8, "blue_p5": np.uint8,
-                            "red_p6": np.uint8, "blue_p6": np.uint8,
-                            "red_p7": np.uint8, "blue_p7": np.uint8,
-                            "red_p8": np.uint8, "blue_p8": np.uint8,
-                            "red_p9": np.uint8, "blue_p9": np.uint8,
-                            "red_p10": np.uint8, "blue_p10": np.uint8,
-                            "red_p11": np.uint8, "blue_p11": np.uint8,
-                            "red_p12": np.uint8, "blue_p12": np.uint8,
-                            "red_p13": np.uint8, "blue_p13": np.uint8,
-                            "red_p14": np.uint8, "blue_p14": np.uint8,
-                            "red_p15": np.uint8, "blue_p15": np.uint8,
-                            "red_p16": np.uint8, "blue_p16": np.uint8,
-                            "red_p17": np.uint8, "blue_p17": np.uint8,
-                            "red_p18": np.uint8, "blue_p18": np.uint8,
-                            "red_p19": np.uint8, "blue_p19": np.uint8,
-                            "red_p20": np.uint8, "blue_p20": np.uint8,
-                            "red_p21": np.uint8, "blue_p21": np.uint8,
-                            "red_p22": np.uint8, "blue_p22": np.uint8,
-                            "red_p23": np.uint8, "blue_p23": np.uint8,
-                            })
+    # All attributes are real numbers (see GetHeaderCSV in common/game/hex)
+    header = pd.read_csv(datafile, comment="#", nrows=0).columns
+    dtype = {name: np.float64 for name in header}
+    df = pd.read_csv(datafile, comment="#", dtype=dtype)
 
     y = df["value"]
     X = df.drop(columns=["value"])
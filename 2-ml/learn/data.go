@@ -0,0 +1,196 @@
+// Package learn provides learning of models that estimate values of hex states
+// from attributes of learning samples
+package learn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// ----------------
+// |     Data     |
+// ----------------
+
+// Data is a table of learning samples
+//	Attributes are names of features of samples
+//	X are features of samples, in the order of Attributes
+//	Y are values of samples for the red player
+type Data struct {
+	Attributes []string
+	X          [][]float64
+	Y          []float64
+}
+
+// ReadData reads learning samples from r. Both the sample format (see
+// hex.SampleReader) and the CSV format (see hex.GenSample) are accepted.
+func ReadData(r io.Reader) (*Data, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(1); err == nil && b[0] == '{' {
+		return readSampleFile(br)
+	}
+	return readCSV(br)
+}
+
+// ReadDataFile reads learning samples from a file with a given name (see
+// ReadData)
+func ReadDataFile(fileName string) (*Data, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := ReadData(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return d, nil
+}
+
+// readSampleFile reads learning samples in the sample format
+func readSampleFile(r io.Reader) (*Data, error) {
+	sr, err := hex.NewSampleReader(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &Data{Attributes: sr.GetHeader().Attributes}
+	for {
+		rec, err := sr.Read()
+		if err == io.EOF {
+			return d, nil
+		} else if err != nil {
+			return nil, err
+		}
+		d.X = append(d.X, rec.Features)
+		d.Y = append(d.Y, rec.Q)
+	}
+}
+
+// readCSV reads learning samples in the CSV format. Comments are skipped.
+func readCSV(r io.Reader) (*Data, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	d := &Data{}
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values := strings.Split(line, ",")
+		if d.Attributes == nil {
+			if values[0] != "value" {
+				return nil, fmt.Errorf("Line %d: invalid header, expected 'value' as the first column", lineNum)
+			}
+			d.Attributes = values[1:]
+			continue
+		}
+		if len(values) != len(d.Attributes)+1 {
+			return nil, fmt.Errorf("Line %d: sample has %d values, expected %d", lineNum, len(values), len(d.Attributes)+1)
+		}
+		x := make([]float64, len(d.Attributes))
+		for i, v := range values {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %s", lineNum, err)
+			}
+			if i == 0 {
+				d.Y = append(d.Y, f)
+			} else {
+				x[i-1] = f
+			}
+		}
+		d.X = append(d.X, x)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if d.Attributes == nil {
+		return nil, fmt.Errorf("Missing header of the sample file")
+	}
+	return d, nil
+}
+
+// Len returns the number of samples
+func (d *Data) Len() int {
+	return len(d.Y)
+}
+
+// GetIndex returns the index of the attribute with a given name. The second
+// return value is false if there is no such attribute.
+func (d *Data) GetIndex(name string) (int, bool) {
+	return getIndex(d.Attributes, name)
+}
+
+// getIndex returns the index of a name in names. The second return value is
+// false if there is no such name.
+func getIndex(names []string, name string) (int, bool) {
+	for i, n := range names {
+		if n == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// getAttributeNames returns names of attributes with indices in set, in the
+// order of names
+func getAttributeNames(names []string, set map[int]bool) []string {
+	used := make([]string, 0, len(set))
+	for i, n := range names {
+		if set[i] {
+			used = append(used, n)
+		}
+	}
+	return used
+}
+
+// subset returns data with samples with given indices. Samples are shared
+// with d.
+func (d *Data) subset(indices []int) *Data {
+	s := &Data{d.Attributes, make([][]float64, len(indices)), make([]float64, len(indices))}
+	for i, j := range indices {
+		s.X[i], s.Y[i] = d.X[j], d.Y[j]
+	}
+	return s
+}
+
+// -------------------
+// |     Scoring     |
+// -------------------
+
+// Predictor estimates values of samples for the red player
+type Predictor interface {
+	Predict(x []float64) float64
+}
+
+// Score returns the coefficient of determination (R^2) and the root mean
+// squared error of predictions of Predictor p on data d
+func Score(p Predictor, d *Data) (float64, float64) {
+	if d.Len() == 0 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, y := range d.Y {
+		mean += y
+	}
+	mean /= float64(d.Len())
+	var ssRes, ssTot float64
+	for i, x := range d.X {
+		e := d.Y[i] - p.Predict(x)
+		ssRes += e * e
+		ssTot += (d.Y[i] - mean) * (d.Y[i] - mean)
+	}
+	r2 := 1.0
+	if ssTot > 0 {
+		r2 = 1 - ssRes/ssTot
+	} else if ssRes > 0 {
+		r2 = 0
+	}
+	return r2, math.Sqrt(ssRes / float64(d.Len()))
+}
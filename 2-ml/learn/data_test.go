@@ -0,0 +1,55 @@
+package learn
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadData(t *testing.T) {
+	inputs := []string{
+		"value,a,b\n# Search ID 0\n# Board r../.../... r\n0.5,1,2\n-0.5,3,4\n",
+		`{"format":"0xai-samples","version":1,"board":"notation","attributes":["a","b"]}` + "\n" +
+			`{"search":0,"n":1,"q":0.5,"board":"r../.../... r","mirrored":false,"features":[1,2]}` + "\n" +
+			`{"search":0,"n":1,"q":-0.5,"board":"b../.../... b","mirrored":true,"features":[3,4]}` + "\n",
+	}
+	for _, in := range inputs {
+		d, err := ReadData(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Len() != 2 || strings.Join(d.Attributes, ",") != "a,b" {
+			t.Fatalf("Wrong data %v", d)
+		}
+		if d.Y[1] != -0.5 || d.X[1][0] != 3 || d.X[1][1] != 4 {
+			t.Fatalf("Wrong second sample %v %v", d.X[1], d.Y[1])
+		}
+		if i, ok := d.GetIndex("b"); !ok || i != 1 {
+			t.Fatalf("Wrong index of attribute b: %d", i)
+		}
+	}
+
+	for _, in := range []string{"", "a,b\n1,2\n", "value,a\n1,2,3\n", "value,a\n1,x\n"} {
+		if _, err := ReadData(strings.NewReader(in)); err == nil {
+			t.Fatalf("Expected an error for input '%s'", in)
+		}
+	}
+}
+
+type constPredictor float64
+
+func (c constPredictor) Predict(x []float64) float64 {
+	return float64(c)
+}
+
+func TestScore(t *testing.T) {
+	d := &Data{[]string{"a"}, [][]float64{{0}, {0}}, []float64{1, -1}}
+	r2, rmse := Score(constPredictor(0), d)
+	if r2 != 0 || rmse != 1 {
+		t.Fatalf("Expected R^2 0 and RMSE 1, got %f and %f", r2, rmse)
+	}
+	r2, rmse = Score(constPredictor(1), d)
+	if r2 != -1 || math.Abs(rmse-math.Sqrt(2)) > 1e-9 {
+		t.Fatalf("Expected R^2 -1 and RMSE sqrt(2), got %f and %f", r2, rmse)
+	}
+}
@@ -0,0 +1,139 @@
+package learn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file writes learned models as Go code of package ab (see 3-ab), in the
+// same form as the Python scripts in 2-ml do.
+
+// goCodeHeader is the beginning of generated files. They are only compiled
+// with build tag gencode, so that package ab builds without them.
+const goCodeHeader = "//go:build gencode\n// +build gencode\n\n// Package ab (Code generated by package learn)\npackage ab\n\n"
+
+// formatFloat returns the shortest representation of f in Go code
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// getPatternIndex returns the index of the pattern whose count is given by an
+// attribute with a given name (red_pN or blue_pN). The second return value is
+// false if the attribute is not a count of a pattern.
+func getPatternIndex(name string) (int, bool) {
+	for _, prefix := range []string{"red_p", "blue_p"} {
+		if strings.HasPrefix(name, prefix) {
+			p, err := strconv.Atoi(name[len(prefix):])
+			return p, err == nil
+		}
+	}
+	return 0, false
+}
+
+// WriteSampleGoCode writes the definition of Sample with given attributes to w
+// (sample.go)
+func WriteSampleGoCode(w io.Writer, attributes []string) error {
+	bw := bufio.NewWriter(w)
+	quoted := make([]string, len(attributes))
+	values := make([]string, len(attributes))
+	for i, a := range attributes {
+		quoted[i] = strconv.Quote(a)
+		values[i] = fmt.Sprintf("f[%d]", i)
+	}
+	bw.WriteString(goCodeHeader)
+	fmt.Fprintf(bw, "type Sample struct {\n\t%s float64\n}\n\n", strings.Join(attributes, ", "))
+	bw.WriteString("// sampleAttributes are names of attributes in the order of fields of Sample\n")
+	fmt.Fprintf(bw, "var sampleAttributes = []string{%s}\n\n", strings.Join(quoted, ", "))
+	bw.WriteString("// newSample returns a Sample with values of attributes f, given in the order\n")
+	bw.WriteString("// of sampleAttributes\n")
+	fmt.Fprintf(bw, "func newSample(f []float64) *Sample {\n\treturn &Sample{%s}\n}\n", strings.Join(values, ", "))
+	return bw.Flush()
+}
+
+// WriteGoCode writes the function getEstimatedValueLR that evaluates the model
+// to w (linearcode.go)
+func (m *LinearModel) WriteGoCode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(goCodeHeader)
+	bw.WriteString("func getEstimatedValueLR(s *Sample) float64 {\n")
+	bw.WriteString("\tif s.lp == 0 {\n")
+	m.writePhasesGoCode(bw, m.Phases[0])
+	bw.WriteString("\t} else {\n")
+	m.writePhasesGoCode(bw, m.Phases[1])
+	bw.WriteString("\t}\n}\n")
+	return bw.Flush()
+}
+
+// writePhasesGoCode writes a switch that selects one of phases by the number of
+// stones
+func (m *LinearModel) writePhasesGoCode(bw *bufio.Writer, phases []*LinearPhase) {
+	if len(phases) == 0 {
+		bw.WriteString("\t\treturn 0\n")
+		return
+	}
+	bw.WriteString("\t\tswitch {\n")
+	for i, phase := range phases {
+		if i == len(phases)-1 {
+			bw.WriteString("\t\tdefault:\n")
+		} else {
+			fmt.Fprintf(bw, "\t\tcase s.num_stones <= %d:\n", phase.MaxStones)
+		}
+		fmt.Fprintf(bw, "\t\t\treturn %s", formatFloat(phase.Intercept))
+		for j, f := range phase.Features {
+			fmt.Fprintf(bw, " +\n\t\t\t\t(%s)*s.%s", formatFloat(phase.Coefficients[j]), m.Attributes[f])
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("\t\t}\n")
+}
+
+// WriteUsedPatternsGoCode writes variables mxs and usedPatterns (see
+// GetUsedPatterns) to w (linearused.go)
+func (m *LinearModel) WriteUsedPatternsGoCode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	mxs, patterns := m.GetUsedPatterns()
+	bw.WriteString(goCodeHeader)
+	bw.WriteString("var mxs = []int{" + joinInts(mxs) + "}\n")
+	bw.WriteString("var usedPatterns = [][]int{\n")
+	for _, p := range patterns {
+		bw.WriteString("\t[]int{" + joinInts(p) + "},\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// joinInts returns integers separated by commas
+func joinInts(a []int) string {
+	s := make([]string, len(a))
+	for i, v := range a {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ", ")
+}
+
+// WriteGoCode writes the function getEstimatedValueDT that evaluates the tree
+// to w (treecode.go)
+func (m *TreeModel) WriteGoCode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(goCodeHeader)
+	bw.WriteString("func getEstimatedValueDT(s *Sample) float64 {\n")
+	m.writeSubtreeGoCode(bw, m.Root, 1)
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writeSubtreeGoCode writes code of a subtree with a given indentation. The
+// right subtree follows the if statement of the left one at the same depth.
+func (m *TreeModel) writeSubtreeGoCode(bw *bufio.Writer, n *TreeNode, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for !n.IsLeaf() {
+		fmt.Fprintf(bw, "%sif s.%s <= %s {\n", indent, m.Attributes[n.Feature], formatFloat(n.Threshold))
+		m.writeSubtreeGoCode(bw, n.Left, depth+1)
+		bw.WriteString(indent + "}\n")
+		n = n.Right
+	}
+	fmt.Fprintf(bw, "%sreturn %s\n", indent, formatFloat(n.Value))
+}
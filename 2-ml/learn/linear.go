@@ -0,0 +1,374 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// maxStonesUnbounded is the maximal number of stones of the last phase of a
+// model, which includes states with any number of stones
+const maxStonesUnbounded = 100000
+
+// -------------------------
+// |     LinearOptions     |
+// -------------------------
+
+// LinearOptions are parameters of learning a LinearModel
+//	Splits are maximal numbers of stones of all phases but the last one, in
+//		increasing order. Phases with a maximum of 0 stones are left out, as
+//		empty boards are never evaluated.
+//	Lambda is the strength of the regularization of standardized
+//		coefficients (ridge regression), relative to the number of samples
+//	MinCoefficient is the minimal absolute value of a standardized
+//		coefficient. Features with smaller coefficients are removed one by
+//		one and the phase is learned again without them.
+type LinearOptions struct {
+	Splits         []int
+	Lambda         float64
+	MinCoefficient float64
+}
+
+// -----------------------
+// |     LinearPhase     |
+// -----------------------
+
+// LinearPhase is a linear model of states with a limited number of stones
+//	MaxStones is the maximal number of stones of states in the phase
+//	Features are indices of attributes that are used
+//	Coefficients are coefficients of Features
+//	Intercept is the value of a sample with all features equal to 0
+//	NumSamples is the number of samples the phase was learned on
+type LinearPhase struct {
+	MaxStones    int       `json:"max_stones"`
+	Features     []int     `json:"features"`
+	Coefficients []float64 `json:"coefficients"`
+	Intercept    float64   `json:"intercept"`
+	NumSamples   int       `json:"samples"`
+}
+
+// predict returns the estimated value of a sample with features x
+func (lp *LinearPhase) predict(x []float64) float64 {
+	v := lp.Intercept
+	for i, f := range lp.Features {
+		v += lp.Coefficients[i] * x[f]
+	}
+	return v
+}
+
+// -----------------------
+// |     LinearModel     |
+// -----------------------
+
+// LinearModel estimates values of states with a linear model for each game
+// phase, separately for states where the last player is red and blue
+//	Attributes are names of attributes of samples
+//	Phases are phases for both last players (the red player first), in the
+//		order of MaxStones. The last phase of each player is unbounded.
+//	lastPlayer and numStones are indices of attributes 'lp' and 'num_stones'
+type LinearModel struct {
+	Attributes []string          `json:"-"`
+	Phases     [2][]*LinearPhase `json:"phases"`
+
+	lastPlayer, numStones int
+}
+
+// newLinearModel returns a LinearModel without phases for samples with given
+// attributes
+func newLinearModel(attributes []string) (*LinearModel, error) {
+	m := &LinearModel{Attributes: attributes}
+	var ok bool
+	if m.lastPlayer, ok = getIndex(attributes, "lp"); !ok {
+		return nil, fmt.Errorf("Attribute 'lp' is required to learn a linear model")
+	}
+	if m.numStones, ok = getIndex(attributes, "num_stones"); !ok {
+		return nil, fmt.Errorf("Attribute 'num_stones' is required to learn a linear model")
+	}
+	return m, nil
+}
+
+// TrainLinear learns a LinearModel on data d. Samples are divided into phases
+// by the last player and the number of stones, each phase is learned
+// separately. Features that are constant in a phase are not used.
+func TrainLinear(d *Data, opt LinearOptions) (*LinearModel, error) {
+	if d.Len() == 0 {
+		return nil, fmt.Errorf("No samples to learn from")
+	}
+	if !sort.IntsAreSorted(opt.Splits) {
+		return nil, fmt.Errorf("Splits must be in increasing order")
+	}
+	m, err := newLinearModel(d.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [2][][]int
+	for c := range groups {
+		groups[c] = make([][]int, len(opt.Splits)+1)
+	}
+	for i, x := range d.X {
+		c := m.getPlayerIndex(x)
+		p := sort.SearchInts(opt.Splits, int(math.Ceil(x[m.numStones])))
+		groups[c][p] = append(groups[c][p], i)
+	}
+
+	for c := range groups {
+		for p, indices := range groups[c] {
+			maxStones := maxStonesUnbounded
+			if p < len(opt.Splits) {
+				maxStones = opt.Splits[p]
+			}
+			if len(indices) == 0 || maxStones <= 0 {
+				continue
+			}
+			phase, err := trainLinearPhase(d.subset(indices), opt)
+			if err != nil {
+				return nil, fmt.Errorf("Phase with at most %d stones: %s", maxStones, err)
+			}
+			phase.MaxStones = maxStones
+			m.Phases[c] = append(m.Phases[c], phase)
+		}
+		if n := len(m.Phases[c]); n > 0 {
+			m.Phases[c][n-1].MaxStones = maxStonesUnbounded
+		}
+	}
+	return m, nil
+}
+
+// getPlayerIndex returns 0 if the last player of a sample with features x is
+// red and 1 if it is blue
+func (m *LinearModel) getPlayerIndex(x []float64) int {
+	if x[m.lastPlayer] == 0 {
+		return 0
+	}
+	return 1
+}
+
+// getPhase returns the phase of a sample with features x, nil if there is no
+// such phase
+func (m *LinearModel) getPhase(x []float64) *LinearPhase {
+	for _, phase := range m.Phases[m.getPlayerIndex(x)] {
+		if x[m.numStones] <= float64(phase.MaxStones) {
+			return phase
+		}
+	}
+	return nil
+}
+
+// Predict returns the estimated value of a sample with features x for the red
+// player
+func (m *LinearModel) Predict(x []float64) float64 {
+	if phase := m.getPhase(x); phase != nil {
+		return phase.predict(x)
+	}
+	return 0
+}
+
+// GetUsedAttributes returns the maximal numbers of stones of phases of both
+// players together and, for each of them, names of attributes that are used by
+// the phases of both players for states with at most that many stones.
+func (m *LinearModel) GetUsedAttributes() ([]int, [][]string) {
+	set := make(map[int]bool)
+	for c := range m.Phases {
+		for _, phase := range m.Phases[c] {
+			set[phase.MaxStones] = true
+		}
+	}
+	mxs := make([]int, 0, len(set))
+	for mx := range set {
+		mxs = append(mxs, mx)
+	}
+	sort.Ints(mxs)
+
+	names := make([][]string, len(mxs))
+	for i, mx := range mxs {
+		used := make(map[int]bool)
+		for c := range m.Phases {
+			// Phase of player c for states with at most mx stones
+			for _, phase := range m.Phases[c] {
+				if phase.MaxStones >= mx {
+					for _, f := range phase.Features {
+						used[f] = true
+					}
+					break
+				}
+			}
+		}
+		names[i] = getAttributeNames(m.Attributes, used)
+	}
+	return mxs, names
+}
+
+// GetUsedPatterns returns the maximal numbers of stones of phases of both
+// players together and, for each of them, indices of patterns whose counts are
+// used by the model. Pattern 0 is always included. They are used to count only
+// the patterns that are needed (see mxs and usedPatterns in 3-ab).
+func (m *LinearModel) GetUsedPatterns() ([]int, [][]int) {
+	mxs, names := m.GetUsedAttributes()
+	patterns := make([][]int, len(mxs))
+	for i := range mxs {
+		used := map[int]bool{0: true}
+		for _, name := range names[i] {
+			if p, ok := getPatternIndex(name); ok {
+				used[p] = true
+			}
+		}
+		for p := range used {
+			patterns[i] = append(patterns[i], p)
+		}
+		sort.Ints(patterns[i])
+	}
+	return mxs, patterns
+}
+
+// trainLinearPhase learns a LinearPhase on data d. Coefficients are learned on
+// standardized features and then transformed back.
+func trainLinearPhase(d *Data, opt LinearOptions) (*LinearPhase, error) {
+	n, numAttr := float64(d.Len()), len(d.Attributes)
+
+	mean, meanY := make([]float64, numAttr), 0.0
+	for i, x := range d.X {
+		for j, v := range x {
+			mean[j] += v
+		}
+		meanY += d.Y[i]
+	}
+	for j := range mean {
+		mean[j] /= n
+	}
+	meanY /= n
+
+	// Features that are not constant
+	std := make([]float64, numAttr)
+	for _, x := range d.X {
+		for j, v := range x {
+			std[j] += (v - mean[j]) * (v - mean[j])
+		}
+	}
+	features := make([]int, 0, numAttr)
+	for j := range std {
+		std[j] = math.Sqrt(std[j] / n)
+		if std[j] > 1e-12 {
+			features = append(features, j)
+		}
+	}
+
+	// Products of standardized features with each other and with the output
+	k := len(features)
+	gram := make([][]float64, k)
+	for a := range gram {
+		gram[a] = make([]float64, k)
+	}
+	xy := make([]float64, k)
+	z := make([]float64, k)
+	for i, x := range d.X {
+		for a, j := range features {
+			z[a] = (x[j] - mean[j]) / std[j]
+		}
+		for a := range z {
+			xy[a] += z[a] * (d.Y[i] - meanY)
+			for b := a; b < k; b++ {
+				gram[a][b] += z[a] * z[b]
+			}
+		}
+	}
+
+	// Remove features with small coefficients one by one
+	selected := make([]int, k) // Indices in features
+	for a := range selected {
+		selected[a] = a
+	}
+	var w []float64
+	for {
+		var ok bool
+		w, ok = solveRidge(gram, xy, selected, opt.Lambda*n)
+		if !ok {
+			return nil, fmt.Errorf("Features are linearly dependent, use a positive lambda")
+		}
+		smallest := -1
+		for a := range w {
+			if math.Abs(w[a]) < opt.MinCoefficient && (smallest < 0 || math.Abs(w[a]) < math.Abs(w[smallest])) {
+				smallest = a
+			}
+		}
+		if smallest < 0 {
+			break
+		}
+		selected = append(selected[:smallest], selected[smallest+1:]...)
+	}
+
+	phase := &LinearPhase{
+		Features:     make([]int, len(selected)),
+		Coefficients: make([]float64, len(selected)),
+		Intercept:    meanY,
+		NumSamples:   d.Len(),
+	}
+	for a, s := range selected {
+		j := features[s]
+		phase.Features[a] = j
+		phase.Coefficients[a] = w[a] / std[j]
+		phase.Intercept -= phase.Coefficients[a] * mean[j]
+	}
+	return phase, nil
+}
+
+// solveRidge returns coefficients w of selected features that minimize the sum
+// of squared errors plus lambda*|w|^2, given the upper triangle of the matrix
+// of products of features gram and products of features with the output xy.
+// The second return value is false if the system is singular.
+func solveRidge(gram [][]float64, xy []float64, selected []int, lambda float64) ([]float64, bool) {
+	k := len(selected)
+	a := make([][]float64, k)
+	b := make([]float64, k)
+	for i, si := range selected {
+		a[i] = make([]float64, k)
+		for j, sj := range selected {
+			if si <= sj {
+				a[i][j] = gram[si][sj]
+			} else {
+				a[i][j] = gram[sj][si]
+			}
+		}
+		a[i][i] += lambda
+		b[i] = xy[si]
+	}
+	return solveCholesky(a, b)
+}
+
+// solveCholesky returns x such that a*x = b, where a is a symmetric positive
+// definite matrix. It modifies a and b. The second return value is false if a
+// is not positive definite.
+func solveCholesky(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	// a = L*L^T, L is stored in the lower triangle of a
+	for j := 0; j < n; j++ {
+		d := a[j][j]
+		for k := 0; k < j; k++ {
+			d -= a[j][k] * a[j][k]
+		}
+		if d <= 1e-12*math.Max(1, a[j][j]) {
+			return nil, false
+		}
+		a[j][j] = math.Sqrt(d)
+		for i := j + 1; i < n; i++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= a[i][k] * a[j][k]
+			}
+			a[i][j] = s / a[j][j]
+		}
+	}
+	for i := 0; i < n; i++ {
+		for k := 0; k < i; k++ {
+			b[i] -= a[i][k] * b[k]
+		}
+		b[i] /= a[i][i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		for k := i + 1; k < n; k++ {
+			b[i] -= a[k][i] * b[k]
+		}
+		b[i] /= a[i][i]
+	}
+	return b, true
+}
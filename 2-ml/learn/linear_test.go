@@ -0,0 +1,145 @@
+package learn
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// getLinearTestData returns samples with attributes num_stones, lp, a, b, c
+// and red_p3. Values are 2a-b+1 for 0 or 1 stones and a+5b-2 for more stones
+// when the last player is red, and -3c for the blue player. Attribute red_p3
+// is constant.
+func getLinearTestData(n int) *Data {
+	r := rand.New(rand.NewSource(1))
+	d := &Data{Attributes: []string{"num_stones", "lp", "a", "b", "c", "red_p3"}}
+	for i := 0; i < n; i++ {
+		x := []float64{float64(r.Intn(4)), float64(r.Intn(2)), r.Float64(), r.Float64(), r.Float64(), 1}
+		var y float64
+		switch {
+		case x[1] == 1:
+			y = -3 * x[4]
+		case x[0] <= 1:
+			y = 2*x[2] - x[3] + 1
+		default:
+			y = x[2] + 5*x[3] - 2
+		}
+		d.X = append(d.X, x)
+		d.Y = append(d.Y, y)
+	}
+	return d
+}
+
+func TestTrainLinear(t *testing.T) {
+	d := getLinearTestData(1000)
+	m, err := TrainLinear(d, LinearOptions{Splits: []int{1}, Lambda: 1e-9, MinCoefficient: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Phases[0]) != 2 || len(m.Phases[1]) != 2 {
+		t.Fatalf("Expected 2 phases for each player, got %d and %d", len(m.Phases[0]), len(m.Phases[1]))
+	}
+	if m.Phases[0][0].MaxStones != 1 || m.Phases[0][1].MaxStones != maxStonesUnbounded {
+		t.Fatalf("Wrong maximal numbers of stones %d and %d", m.Phases[0][0].MaxStones, m.Phases[0][1].MaxStones)
+	}
+
+	// Irrelevant features are removed
+	phase := m.Phases[0][0]
+	if len(phase.Features) != 2 || phase.Features[0] != 2 || phase.Features[1] != 3 {
+		t.Fatalf("Expected features a and b, got %v", phase.Features)
+	}
+	if math.Abs(phase.Coefficients[0]-2) > 1e-6 || math.Abs(phase.Coefficients[1]+1) > 1e-6 || math.Abs(phase.Intercept-1) > 1e-6 {
+		t.Fatalf("Wrong coefficients %v and intercept %f", phase.Coefficients, phase.Intercept)
+	}
+	if phase := m.Phases[1][1]; len(phase.Features) != 1 || phase.Features[0] != 4 {
+		t.Fatalf("Expected feature c, got %v", phase.Features)
+	}
+
+	if r2, rmse := Score(m, d); r2 < 0.999999 || rmse > 1e-6 {
+		t.Fatalf("Expected a perfect fit, got R^2 %f and RMSE %f", r2, rmse)
+	}
+}
+
+func TestTrainLinearRegularization(t *testing.T) {
+	d := getLinearTestData(200)
+	m, err := TrainLinear(d, LinearOptions{Lambda: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Strong regularization shrinks coefficients towards 0
+	for _, phase := range m.Phases[1] {
+		for i, f := range phase.Features {
+			if f == 4 && (phase.Coefficients[i] > -0.1 || phase.Coefficients[i] < -1) {
+				t.Fatalf("Expected a shrunk coefficient of c, got %f", phase.Coefficients[i])
+			}
+		}
+	}
+
+	// Without regularization, linearly dependent features cannot be learned
+	d.Attributes = append(d.Attributes, "a2")
+	for i := range d.X {
+		d.X[i] = append(d.X[i], 2*d.X[i][2])
+	}
+	if _, err := TrainLinear(d, LinearOptions{}); err == nil {
+		t.Fatalf("Expected an error for linearly dependent features")
+	}
+	if _, err := TrainLinear(d, LinearOptions{Lambda: 0.001}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := TrainLinear(&Data{Attributes: []string{"a"}, X: [][]float64{{1}}, Y: []float64{1}}, LinearOptions{}); err == nil {
+		t.Fatalf("Expected an error for missing attributes")
+	}
+}
+
+func TestLinearGoCode(t *testing.T) {
+	d := getLinearTestData(1000)
+	d.Attributes[4] = "blue_p2"
+	m, err := TrainLinear(d, LinearOptions{Splits: []int{0, 1}, Lambda: 1e-9, MinCoefficient: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mxs, names := m.GetUsedAttributes()
+	if len(mxs) != 2 || len(names) != 2 {
+		t.Fatalf("Wrong maximal numbers of stones %v", mxs)
+	}
+	for _, n := range names {
+		if _, ok := getIndex(n, "blue_p2"); !ok {
+			t.Fatalf("Expected blue_p2 among used attributes, got %v", n)
+		}
+	}
+
+	mxs, patterns := m.GetUsedPatterns()
+	if len(mxs) != 2 || mxs[0] != 1 || mxs[1] != maxStonesUnbounded {
+		t.Fatalf("Wrong maximal numbers of stones %v", mxs)
+	}
+	for _, p := range patterns {
+		if len(p) != 2 || p[0] != 0 || p[1] != 2 {
+			t.Fatalf("Expected patterns 0 and 2, got %v", p)
+		}
+	}
+
+	// Generated files are valid Go code
+	writers := []func(*bytes.Buffer) error{
+		func(b *bytes.Buffer) error { return m.WriteGoCode(b) },
+		func(b *bytes.Buffer) error { return m.WriteUsedPatternsGoCode(b) },
+		func(b *bytes.Buffer) error { return WriteSampleGoCode(b, d.Attributes) },
+	}
+	for _, write := range writers {
+		var b bytes.Buffer
+		if err := write(&b); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "", b.Bytes(), 0); err != nil {
+			t.Fatalf("Invalid Go code: %s\n%s", err, b.String())
+		}
+		if !strings.HasPrefix(b.String(), "//go:build gencode\n") {
+			t.Fatalf("Missing header:\n%s", b.String())
+		}
+	}
+}
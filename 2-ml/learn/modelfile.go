@@ -0,0 +1,167 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// This file implements the format of model files, which are loaded by AB
+// players at runtime instead of the generated Go code (see 3-ab). A model file
+// is a JSON object with the format, the version, the type of the model, names
+// of attributes of samples and the model itself. Attributes are referred to by
+// their indices in the model.
+
+// ModelFormatName identifies model files
+const ModelFormatName = "0xai-model"
+
+// ModelFormatVersion is the version of the model format that is written. It is
+// increased whenever the format changes in an incompatible way.
+const ModelFormatVersion = 1
+
+// Types of models in model files
+const (
+	ModelTypeLinear = "linear"
+	ModelTypeTree   = "tree"
+)
+
+// Model is a learned model that can be written to a model file
+type Model interface {
+	Predictor
+	GetAttributes() []string // Returns names of attributes of samples
+}
+
+// GetAttributes returns names of attributes of samples
+func (m *LinearModel) GetAttributes() []string {
+	return m.Attributes
+}
+
+// GetAttributes returns names of attributes of samples
+func (m *TreeModel) GetAttributes() []string {
+	return m.Attributes
+}
+
+// modelFile is the content of a model file
+//	Format is always ModelFormatName
+//	Version is the version of the format (see ModelFormatVersion)
+//	Type is the type of the model (ModelTypeLinear or ModelTypeTree)
+//	Attributes are names of attributes of samples
+//	Linear and Tree are models, only the one given by Type is set
+type modelFile struct {
+	Format     string       `json:"format"`
+	Version    int          `json:"version"`
+	Type       string       `json:"type"`
+	Attributes []string     `json:"attributes"`
+	Linear     *LinearModel `json:"linear,omitempty"`
+	Tree       *TreeModel   `json:"tree,omitempty"`
+}
+
+// WriteModel writes Model m to w in the model format
+func WriteModel(w io.Writer, m Model) error {
+	mf := modelFile{
+		Format:     ModelFormatName,
+		Version:    ModelFormatVersion,
+		Attributes: m.GetAttributes(),
+	}
+	switch m := m.(type) {
+	case *LinearModel:
+		mf.Type, mf.Linear = ModelTypeLinear, m
+	case *TreeModel:
+		mf.Type, mf.Tree = ModelTypeTree, m
+	default:
+		return fmt.Errorf("Unknown type of model %T", m)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(mf)
+}
+
+// ReadModel reads a model in the model format from r. It returns either a
+// *LinearModel or a *TreeModel.
+func ReadModel(r io.Reader) (Model, error) {
+	var mf modelFile
+	if err := json.NewDecoder(r).Decode(&mf); err != nil {
+		return nil, fmt.Errorf("Invalid model file: %s", err)
+	}
+	if mf.Format != ModelFormatName {
+		return nil, fmt.Errorf("Unknown format '%s', expected '%s'", mf.Format, ModelFormatName)
+	}
+	if mf.Version != ModelFormatVersion {
+		return nil, fmt.Errorf("Unsupported version %d of the model format, expected %d", mf.Version, ModelFormatVersion)
+	}
+
+	switch {
+	case mf.Type == ModelTypeLinear && mf.Linear != nil:
+		m, err := newLinearModel(mf.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		m.Phases = mf.Linear.Phases
+		return m, m.validate()
+	case mf.Type == ModelTypeTree && mf.Tree != nil:
+		m := &TreeModel{mf.Attributes, mf.Tree.Root}
+		return m, m.validate()
+	default:
+		return nil, fmt.Errorf("Missing model of type '%s'", mf.Type)
+	}
+}
+
+// ReadModelFile reads a model from a file with a given name (see ReadModel)
+func ReadModelFile(fileName string) (Model, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := ReadModel(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return m, nil
+}
+
+// validate returns an error if phases of the model refer to unknown attributes
+func (m *LinearModel) validate() error {
+	for c := range m.Phases {
+		for _, phase := range m.Phases[c] {
+			if phase == nil {
+				return fmt.Errorf("Missing phase")
+			}
+			if len(phase.Coefficients) != len(phase.Features) {
+				return fmt.Errorf("Phase with at most %d stones has invalid coefficients", phase.MaxStones)
+			}
+			for _, f := range phase.Features {
+				if f < 0 || f >= len(m.Attributes) {
+					return fmt.Errorf("Phase with at most %d stones uses an unknown attribute %d", phase.MaxStones, f)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns an error if the tree is incomplete or refers to unknown
+// attributes
+func (m *TreeModel) validate() error {
+	if m.Root == nil {
+		return fmt.Errorf("Tree has no root")
+	}
+	var check func(n *TreeNode) error
+	check = func(n *TreeNode) error {
+		if (n.Left == nil) != (n.Right == nil) {
+			return fmt.Errorf("Node with %d samples has only one subtree", n.NumSamples)
+		}
+		if n.IsLeaf() {
+			return nil
+		}
+		if n.Feature < 0 || n.Feature >= len(m.Attributes) {
+			return fmt.Errorf("Node with %d samples uses an unknown attribute %d", n.NumSamples, n.Feature)
+		}
+		if err := check(n.Left); err != nil {
+			return err
+		}
+		return check(n.Right)
+	}
+	return check(m.Root)
+}
@@ -0,0 +1,65 @@
+package learn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestModelFile(t *testing.T) {
+	d := getLinearTestData(200)
+	lm, err := TrainLinear(d, LinearOptions{Splits: []int{1}, Lambda: 1e-9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt, err := TrainTree(d, TreeOptions{MaxDepth: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []Model{lm, dt} {
+		var b bytes.Buffer
+		if err := WriteModel(&b, m); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadModel(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(read.GetAttributes(), ",") != strings.Join(d.Attributes, ",") {
+			t.Fatalf("Wrong attributes %v", read.GetAttributes())
+		}
+		// The model that was read gives the same predictions
+		for _, x := range d.X {
+			if read.Predict(x) != m.Predict(x) {
+				t.Fatalf("Predictions of %T differ after writing and reading", m)
+			}
+		}
+	}
+}
+
+func TestModelFileInvalid(t *testing.T) {
+	inputs := []string{
+		`{"format":"other","version":1,"type":"tree","attributes":["a"],"tree":{"root":{"feature":-1,"value":1}}}`,
+		`{"format":"0xai-model","version":2,"type":"tree","attributes":["a"],"tree":{"root":{"feature":-1,"value":1}}}`,
+		`{"format":"0xai-model","version":1,"type":"linear","attributes":["a"],"tree":{"root":{"feature":-1,"value":1}}}`,
+		`{"format":"0xai-model","version":1,"type":"tree","attributes":["a"],"tree":{"root":{"feature":1,"left":{},"right":{}}}}`,
+		`{"format":"0xai-model","version":1,"type":"tree","attributes":["a"],"tree":{"root":{"feature":0,"left":{}}}}`,
+		`{"format":"0xai-model","version":1,"type":"linear","attributes":["lp","num_stones"],"linear":{"phases":[[{"features":[2],"coefficients":[1]}],[]]}}`,
+		`{"format":"0xai-model","version":1,"type":"linear","attributes":["a"],"linear":{"phases":[[],[]]}}`,
+	}
+	for _, in := range inputs {
+		if _, err := ReadModel(strings.NewReader(in)); err == nil {
+			t.Fatalf("Expected an error for model file\n%s", in)
+		}
+	}
+
+	in := `{"format":"0xai-model","version":1,"type":"tree","attributes":["a"],"tree":{"root":{"feature":0,"threshold":0.5,"left":{"feature":-1,"value":1},"right":{"feature":-1,"value":2}}}}`
+	m, err := ReadModel(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Predict([]float64{0}) != 1 || m.Predict([]float64{1}) != 2 {
+		t.Fatalf("Wrong predictions of the tree")
+	}
+}
@@ -0,0 +1,319 @@
+package learn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// -----------------------
+// |     TreeOptions     |
+// -----------------------
+
+// TreeOptions are parameters of learning a TreeModel
+//	MaxDepth is the maximal depth of the tree, 0 for no limit
+//	MinSamplesLeaf is the minimal number of samples in a leaf
+//	PruneAlpha is the complexity parameter of minimal cost-complexity
+//		pruning (see TreeModel.Prune), 0 for no pruning
+type TreeOptions struct {
+	MaxDepth       int
+	MinSamplesLeaf int
+	PruneAlpha     float64
+}
+
+// --------------------
+// |     TreeNode     |
+// --------------------
+
+// TreeNode is a node of a regression tree
+//	Feature is the index of the attribute that the node splits on, -1 for
+//		leaves
+//	Threshold is the value of the attribute, samples with values less than or
+//		equal to it go to the left subtree
+//	Value is the mean value of samples in the node
+//	MSE is the mean squared error of Value on samples in the node
+//	NumSamples is the number of samples in the node
+//	Left and Right are subtrees, nil for leaves
+type TreeNode struct {
+	Feature    int       `json:"feature"`
+	Threshold  float64   `json:"threshold"`
+	Value      float64   `json:"value"`
+	MSE        float64   `json:"mse"`
+	NumSamples int       `json:"samples"`
+	Left       *TreeNode `json:"left,omitempty"`
+	Right      *TreeNode `json:"right,omitempty"`
+}
+
+// IsLeaf returns true if the node has no subtrees
+func (n *TreeNode) IsLeaf() bool {
+	return n.Left == nil
+}
+
+// makeLeaf removes subtrees of the node
+func (n *TreeNode) makeLeaf() {
+	n.Feature, n.Threshold, n.Left, n.Right = -1, 0, nil, nil
+}
+
+// getNumLeaves returns the number of leaves in the subtree
+func (n *TreeNode) getNumLeaves() int {
+	if n.IsLeaf() {
+		return 1
+	}
+	return n.Left.getNumLeaves() + n.Right.getNumLeaves()
+}
+
+// getDepth returns the depth of the subtree, 0 for a leaf
+func (n *TreeNode) getDepth() int {
+	if n.IsLeaf() {
+		return 0
+	}
+	l, r := n.Left.getDepth(), n.Right.getDepth()
+	if l > r {
+		return l + 1
+	}
+	return r + 1
+}
+
+// addFeatures adds features that the subtree splits on to set
+func (n *TreeNode) addFeatures(set map[int]bool) {
+	if n.IsLeaf() {
+		return
+	}
+	set[n.Feature] = true
+	n.Left.addFeatures(set)
+	n.Right.addFeatures(set)
+}
+
+// getLeavesError returns the sum of squared errors of leaves in the subtree
+func (n *TreeNode) getLeavesError() float64 {
+	if n.IsLeaf() {
+		return n.MSE * float64(n.NumSamples)
+	}
+	return n.Left.getLeavesError() + n.Right.getLeavesError()
+}
+
+// ---------------------
+// |     TreeModel     |
+// ---------------------
+
+// TreeModel estimates values of states with a regression tree (CART)
+//	Attributes are names of attributes of samples
+//	Root is the root of the tree
+type TreeModel struct {
+	Attributes []string  `json:"-"`
+	Root       *TreeNode `json:"root"`
+}
+
+// TrainTree learns a TreeModel on data d. Each node is split on the attribute
+// and the threshold that reduce the squared error the most.
+func TrainTree(d *Data, opt TreeOptions) (*TreeModel, error) {
+	if d.Len() == 0 {
+		return nil, fmt.Errorf("No samples to learn from")
+	}
+	if opt.MinSamplesLeaf < 1 {
+		opt.MinSamplesLeaf = 1
+	}
+	indices := make([]int, d.Len())
+	for i := range indices {
+		indices[i] = i
+	}
+	b := &treeBuilder{d, opt, make([]int, d.Len())}
+	m := &TreeModel{d.Attributes, b.build(indices, 0)}
+	if opt.PruneAlpha > 0 {
+		m.Prune(opt.PruneAlpha)
+	}
+	return m, nil
+}
+
+// Predict returns the estimated value of a sample with features x for the red
+// player
+func (m *TreeModel) Predict(x []float64) float64 {
+	n := m.Root
+	for !n.IsLeaf() {
+		if x[n.Feature] <= n.Threshold {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return n.Value
+}
+
+// GetNumLeaves returns the number of leaves of the tree
+func (m *TreeModel) GetNumLeaves() int {
+	return m.Root.getNumLeaves()
+}
+
+// GetDepth returns the depth of the tree
+func (m *TreeModel) GetDepth() int {
+	return m.Root.getDepth()
+}
+
+// GetUsedAttributes returns names of attributes that the tree splits on, in
+// the order of attributes
+func (m *TreeModel) GetUsedAttributes() []string {
+	set := make(map[int]bool)
+	m.Root.addFeatures(set)
+	return getAttributeNames(m.Attributes, set)
+}
+
+// Prune removes subtrees with minimal cost-complexity pruning. The subtree
+// whose removal increases the error the least per removed leaf (the weakest
+// link) is replaced by a leaf, as long as the increase is at most alpha. The
+// error is the sum of squared errors divided by the number of samples in the
+// root, as in scikit-learn.
+func (m *TreeModel) Prune(alpha float64) {
+	total := float64(m.Root.NumSamples)
+	for {
+		var weakest *TreeNode
+		minCost := math.Inf(1)
+		var visit func(n *TreeNode)
+		visit = func(n *TreeNode) {
+			if n.IsLeaf() {
+				return
+			}
+			nodeError := n.MSE * float64(n.NumSamples) / total
+			cost := (nodeError - n.getLeavesError()/total) / float64(n.getNumLeaves()-1)
+			if cost < minCost {
+				weakest, minCost = n, cost
+			}
+			visit(n.Left)
+			visit(n.Right)
+		}
+		visit(m.Root)
+		if weakest == nil || minCost > alpha {
+			return
+		}
+		weakest.makeLeaf()
+	}
+}
+
+// WriteDOT writes the tree in the Graphviz DOT format to w, in the same form as
+// export_graphviz of scikit-learn
+func (m *TreeModel) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph Tree {\nnode [shape=box] ;\n")
+	id := 0
+	var visit func(n *TreeNode, parent int)
+	visit = func(n *TreeNode, parent int) {
+		nodeID := id
+		id++
+		label := ""
+		if !n.IsLeaf() {
+			label = fmt.Sprintf("%s <= %g\\n", m.Attributes[n.Feature], n.Threshold)
+		}
+		label += fmt.Sprintf("mse = %.4f\\nsamples = %d\\nvalue = %.4f", n.MSE, n.NumSamples, n.Value)
+		fmt.Fprintf(bw, "%d [label=\"%s\"] ;\n", nodeID, label)
+		if parent >= 0 {
+			fmt.Fprintf(bw, "%d -> %d", parent, nodeID)
+			if parent == 0 {
+				// Edges from the root are labelled
+				if nodeID == 1 {
+					bw.WriteString(" [labeldistance=2.5, labelangle=45, headlabel=\"True\"]")
+				} else {
+					bw.WriteString(" [labeldistance=2.5, labelangle=-45, headlabel=\"False\"]")
+				}
+			}
+			bw.WriteString(" ;\n")
+		}
+		if !n.IsLeaf() {
+			visit(n.Left, nodeID)
+			visit(n.Right, nodeID)
+		}
+	}
+	visit(m.Root, -1)
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// -----------------------
+// |     treeBuilder     |
+// -----------------------
+
+// treeBuilder builds a regression tree
+//	d is the data the tree is learned on
+//	opt are parameters of learning
+//	order is a buffer for sorting indices of samples
+type treeBuilder struct {
+	d     *Data
+	opt   TreeOptions
+	order []int
+}
+
+// build returns a subtree at a given depth, learned on samples with given
+// indices
+func (b *treeBuilder) build(indices []int, depth int) *TreeNode {
+	n := float64(len(indices))
+	var sum, sumSq float64
+	for _, i := range indices {
+		sum += b.d.Y[i]
+		sumSq += b.d.Y[i] * b.d.Y[i]
+	}
+	mean := sum / n
+	node := &TreeNode{
+		Feature:    -1,
+		Value:      mean,
+		MSE:        math.Max(0, sumSq/n-mean*mean),
+		NumSamples: len(indices),
+	}
+	if (b.opt.MaxDepth > 0 && depth >= b.opt.MaxDepth) || len(indices) < 2*b.opt.MinSamplesLeaf || node.MSE == 0 {
+		return node
+	}
+
+	feature, threshold, ok := b.findSplit(indices, sum)
+	if !ok {
+		return node
+	}
+	var left, right []int
+	for _, i := range indices {
+		if b.d.X[i][feature] <= threshold {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+	node.Feature, node.Threshold = feature, threshold
+	node.Left = b.build(left, depth+1)
+	node.Right = b.build(right, depth+1)
+	return node
+}
+
+// findSplit returns the attribute and the threshold that split samples with
+// given indices with the lowest sum of squared errors, given the sum of their
+// values. The third return value is false if the samples cannot be split.
+func (b *treeBuilder) findSplit(indices []int, sum float64) (int, float64, bool) {
+	n := len(indices)
+	minLeaf := b.opt.MinSamplesLeaf
+	order := b.order[:n]
+	bestFeature, bestThreshold := -1, 0.0
+	// Minimizing the sum of squared errors equals maximizing the sum of
+	// squared sums divided by sizes of both parts
+	bestScore := sum * sum / float64(n)
+
+	for f := range b.d.Attributes {
+		copy(order, indices)
+		x := b.d.X
+		sort.Slice(order, func(i, j int) bool { return x[order[i]][f] < x[order[j]][f] })
+
+		leftSum := 0.0
+		for k := 0; k < n-1; k++ {
+			leftSum += b.d.Y[order[k]]
+			v, next := x[order[k]][f], x[order[k+1]][f]
+			if k+1 < minLeaf || n-k-1 < minLeaf || v == next {
+				continue
+			}
+			rightSum := sum - leftSum
+			score := leftSum*leftSum/float64(k+1) + rightSum*rightSum/float64(n-k-1)
+			if score > bestScore+1e-12 {
+				bestFeature, bestThreshold, bestScore = f, (v+next)/2, score
+				if bestThreshold >= next {
+					// Rounding of close values
+					bestThreshold = v
+				}
+			}
+		}
+	}
+	return bestFeature, bestThreshold, bestFeature >= 0
+}
@@ -0,0 +1,141 @@
+package learn
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// getTreeTestData returns samples with attributes a, b and noise. Values are 1
+// if a <= 0.5 and b <= 0.3, 2 if a <= 0.5 and b > 0.3 and -1 if a > 0.5, plus
+// small noise if noisy is true.
+func getTreeTestData(n int, noisy bool) *Data {
+	r := rand.New(rand.NewSource(1))
+	d := &Data{Attributes: []string{"a", "b", "noise"}}
+	for i := 0; i < n; i++ {
+		x := []float64{float64(r.Intn(10)) / 10, float64(r.Intn(10)) / 10, r.Float64()}
+		var y float64
+		switch {
+		case x[0] > 0.5:
+			y = -1
+		case x[1] <= 0.3:
+			y = 1
+		default:
+			y = 2
+		}
+		if noisy {
+			y += (r.Float64() - 0.5) / 100
+		}
+		d.X = append(d.X, x)
+		d.Y = append(d.Y, y)
+	}
+	return d
+}
+
+func TestTrainTree(t *testing.T) {
+	d := getTreeTestData(500, false)
+	m, err := TrainTree(d, TreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := m.Root
+	if root.Feature != 0 || root.Threshold != 0.55 || root.NumSamples != 500 {
+		t.Fatalf("Expected a split on a at 0.55, got %d at %f", root.Feature, root.Threshold)
+	}
+	if !root.Right.IsLeaf() || root.Right.Value != -1 {
+		t.Fatalf("Expected a leaf with value -1")
+	}
+	if l := root.Left; l.Feature != 1 || l.Threshold != 0.35 {
+		t.Fatalf("Expected a split on b at 0.35, got %d at %f", l.Feature, l.Threshold)
+	}
+	if m.GetNumLeaves() != 3 || m.GetDepth() != 2 {
+		t.Fatalf("Expected 3 leaves and depth 2, got %d and %d", m.GetNumLeaves(), m.GetDepth())
+	}
+	if r2, _ := Score(m, d); r2 != 1 {
+		t.Fatalf("Expected a perfect fit, got R^2 %f", r2)
+	}
+	if used := m.GetUsedAttributes(); len(used) != 2 || used[0] != "a" || used[1] != "b" {
+		t.Fatalf("Expected used attributes a and b, got %v", used)
+	}
+
+	if _, err := TrainTree(&Data{Attributes: []string{"a"}}, TreeOptions{}); err == nil {
+		t.Fatalf("Expected an error for no samples")
+	}
+}
+
+func TestTrainTreeOptions(t *testing.T) {
+	d := getTreeTestData(500, true)
+
+	// Without limits, the tree fits the noise
+	full, err := TrainTree(d, TreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.GetNumLeaves() < 100 {
+		t.Fatalf("Expected a large tree, got %d leaves", full.GetNumLeaves())
+	}
+
+	if m, _ := TrainTree(d, TreeOptions{MaxDepth: 1}); m.GetDepth() != 1 {
+		t.Fatalf("Expected depth 1, got %d", m.GetDepth())
+	}
+
+	m, _ := TrainTree(d, TreeOptions{MinSamplesLeaf: 40})
+	var checkLeaves func(n *TreeNode)
+	checkLeaves = func(n *TreeNode) {
+		if n.IsLeaf() {
+			if n.NumSamples < 40 {
+				t.Fatalf("Leaf with %d samples", n.NumSamples)
+			}
+			return
+		}
+		checkLeaves(n.Left)
+		checkLeaves(n.Right)
+	}
+	checkLeaves(m.Root)
+
+	// Pruning removes splits that only fit the noise
+	m, _ = TrainTree(d, TreeOptions{PruneAlpha: 0.001})
+	if m.GetNumLeaves() != 3 {
+		t.Fatalf("Expected 3 leaves after pruning, got %d", m.GetNumLeaves())
+	}
+	full.Prune(0.001)
+	if full.GetNumLeaves() != 3 {
+		t.Fatalf("Expected 3 leaves after pruning, got %d", full.GetNumLeaves())
+	}
+	full.Prune(10)
+	if !full.Root.IsLeaf() {
+		t.Fatalf("Expected a single leaf after pruning with a large alpha")
+	}
+}
+
+func TestTreeOutput(t *testing.T) {
+	m, err := TrainTree(getTreeTestData(100, false), TreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := m.WriteGoCode(&b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", b.Bytes(), 0); err != nil {
+		t.Fatalf("Invalid Go code: %s\n%s", err, b.String())
+	}
+	expected := "func getEstimatedValueDT(s *Sample) float64 {\n" +
+		"\tif s.a <= 0.55 {\n\t\tif s.b <= 0.35 {\n\t\t\treturn 1\n\t\t}\n\t\treturn 2\n\t}\n\treturn -1\n}\n"
+	if !strings.HasSuffix(b.String(), expected) {
+		t.Fatalf("Unexpected Go code:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := m.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+	if !strings.HasPrefix(dot, "digraph Tree {") || strings.Count(dot, "[label=") != 5 || strings.Count(dot, "->") != 4 {
+		t.Fatalf("Unexpected DOT file:\n%s", dot)
+	}
+}
@@ -87,6 +87,7 @@ class LinearRegressionModel(Model):
 
     def used_patters_to_code(self, model_index, outfolder):
         with open(outfolder + "linear" + str(model_index) + "used.go", "w") as code_file:
+            code_file.write("//go:build gencode\n// +build gencode\n\n")
             code_file.write("// Package ab (Code generated by a Python script)\n")
             code_file.write("package ab\n\n")
 
@@ -155,7 +156,7 @@ class LinearRegressionModel(Model):
                 code_file.write("\t\t}\n")  # end of switch
 
             def get_one_factor(feature_name, coefficient):
-                return "({})*float64(s.{})".format(coefficient, feature_name)
+                return "({})*s.{}".format(coefficient, feature_name)
 
             def get_one_submodel(submodel, coefficients):
                 key = submodel.get_ID()
@@ -170,6 +171,7 @@ class LinearRegressionModel(Model):
                 s += "\n"
                 return s
 
+            code_file.write("//go:build gencode\n// +build gencode\n\n")
             code_file.write("// Package ab (Code generated by a Python script)\n")
             code_file.write("package ab\n\n")
             code_file.write("func getEstimatedValueLR(s *Sample) float64 {\n")
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/RdecKa/0xAI/2-ml/learn"
+)
+
+// main learns models that estimate values of states from learning samples and
+// writes them as Go code for package ab and as model files that AB players can
+// load at runtime
+func main() {
+	// Read flags
+	pTrainFile := flag.String("train", "train.in", "File with training samples")
+	pTestFile := flag.String("test", "", "File with test samples (empty for none)")
+	pOutputFolder := flag.String("output", "./", "Output folder")
+	pSplits := flag.String("splits", "0,3,5,7,9,12,15,19,25,32,40,50,65,85,100", "Maximal numbers of stones of phases of the linear model")
+	pLambda := flag.Float64("lambda", 0.001, "Strength of the regularization of the linear model")
+	pMinCoef := flag.Float64("mincoef", 0.001, "Minimal absolute standardized coefficient of a feature of the linear model")
+	pMaxDepth := flag.Int("depth", 0, "Maximal depth of the decision tree (0 for no limit)")
+	pMinLeaf := flag.Int("minleaf", 1, "Minimal number of samples in a leaf of the decision tree")
+	pPrune := flag.Float64("prune", 0, "Complexity parameter of pruning of the decision tree (0 for no pruning)")
+	flag.Parse()
+	trainFile, testFile, outputFolder := *pTrainFile, *pTestFile, *pOutputFolder
+
+	splits, err := parseSplits(*pSplits)
+	if err != nil {
+		fail(err)
+	}
+
+	// Read data
+	train, err := learn.ReadDataFile(trainFile)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("Read %d training samples from %s\n", train.Len(), trainFile)
+	var test *learn.Data
+	if testFile != "" {
+		if test, err = learn.ReadDataFile(testFile); err != nil {
+			fail(err)
+		}
+		fmt.Printf("Read %d test samples from %s\n", test.Len(), testFile)
+	}
+
+	// Learn the linear model
+	lm, err := learn.TrainLinear(train, learn.LinearOptions{Splits: splits, Lambda: *pLambda, MinCoefficient: *pMinCoef})
+	if err != nil {
+		fail(err)
+	}
+
+	// Learn the decision tree
+	dt, err := learn.TrainTree(train, learn.TreeOptions{MaxDepth: *pMaxDepth, MinSamplesLeaf: *pMinLeaf, PruneAlpha: *pPrune})
+	if err != nil {
+		fail(err)
+	}
+
+	// Write results
+	outputs := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"sample.go", func(w io.Writer) error { return learn.WriteSampleGoCode(w, train.Attributes) }},
+		{"linearcode.go", lm.WriteGoCode},
+		{"linearused.go", lm.WriteUsedPatternsGoCode},
+		{"linear.json", func(w io.Writer) error { return learn.WriteModel(w, lm) }},
+		{"stats_lr.txt", func(w io.Writer) error { return writeLinearStats(w, lm, train, test) }},
+		{"treecode.go", dt.WriteGoCode},
+		{"tree.dot", dt.WriteDOT},
+		{"tree.json", func(w io.Writer) error { return learn.WriteModel(w, dt) }},
+		{"stats_dt.txt", func(w io.Writer) error { return writeTreeStats(w, dt, train, test) }},
+	}
+	for _, o := range outputs {
+		if err := writeFile(filepath.Join(outputFolder, o.name), o.write); err != nil {
+			fail(err)
+		}
+	}
+	fmt.Printf("Models written to %s\n", outputFolder)
+}
+
+// parseSplits reads comma-separated numbers of stones
+func parseSplits(s string) ([]int, error) {
+	var splits []int
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid split '%s'", f)
+		}
+		splits = append(splits, n)
+	}
+	return splits, nil
+}
+
+// writeLinearStats writes coefficients of phases of the linear model and its
+// scores on training and test data
+func writeLinearStats(w io.Writer, lm *learn.LinearModel, train, test *learn.Data) error {
+	for c, player := range []string{"r", "b"} {
+		for _, phase := range lm.Phases[c] {
+			fmt.Fprintf(w, "Phase %s_%d (%d samples):\n", player, phase.MaxStones, phase.NumSamples)
+			fmt.Fprintf(w, "\tintercept: %g\n", phase.Intercept)
+			for i, f := range phase.Features {
+				fmt.Fprintf(w, "\t%s: %g\n", lm.Attributes[f], phase.Coefficients[i])
+			}
+		}
+	}
+	return writeScores(w, lm, train, test)
+}
+
+// writeTreeStats writes the size of the decision tree and its scores on
+// training and test data
+func writeTreeStats(w io.Writer, dt *learn.TreeModel, train, test *learn.Data) error {
+	fmt.Fprintf(w, "Depth: %d, leaves: %d\n", dt.GetDepth(), dt.GetNumLeaves())
+	return writeScores(w, dt, train, test)
+}
+
+// writeScores writes scores of a model on training and test data
+func writeScores(w io.Writer, p learn.Predictor, train, test *learn.Data) error {
+	r2, rmse := learn.Score(p, train)
+	_, err := fmt.Fprintf(w, "SCORE (training): R^2 %f, RMSE %f (%d samples)\n", r2, rmse, train.Len())
+	if test != nil {
+		r2, rmse = learn.Score(p, test)
+		_, err = fmt.Fprintf(w, "SCORE (test): R^2 %f, RMSE %f (%d samples)\n", r2, rmse, test.Len())
+	}
+	return err
+}
+
+// writeFile creates a file with a given name and writes to it with a function
+// write
+func writeFile(fileName string, write func(io.Writer) error) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fail prints an error and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -9,56 +9,7 @@ import (
 	"github.com/RdecKa/0xAI/common/game/hex"
 )
 
-const patFileName = "../../common/game/hex/patterns.txt"
-
-func benchmarkAB(actions []*hex.Action, size byte, b *testing.B) {
-	state := hex.NewState(size, hex.Red)
-	for _, a := range actions {
-		s := state.GetSuccessorState(a).(hex.State)
-		state = &s
-	}
-
-	gridChan, patChan, stopChan, resultChan := hex.CreatePatChecker(patFileName)
-	defer func() { stopChan <- struct{}{} }()
-
-	for n := 0; n < b.N; n++ {
-		// Now when time is added, results cannot really be compared anymore ...
-		AlphaBeta(state, time.Second, false, gridChan, patChan, resultChan,
-			GetEstimateFunction("abLR"), "abLR")
-	}
-}
-
-func _Benchmark0(b *testing.B) {
-	actions := []*hex.Action{}
-
-	benchmarkAB(actions, 7, b)
-}
-
-func _Benchmark1(b *testing.B) {
-	actions := []*hex.Action{
-		hex.NewAction(2, 2, hex.Red),
-		hex.NewAction(3, 5, hex.Blue),
-		hex.NewAction(1, 4, hex.Red),
-		hex.NewAction(5, 4, hex.Blue),
-	}
-
-	benchmarkAB(actions, 7, b)
-}
-
-func _Benchmark2(b *testing.B) {
-	actions := []*hex.Action{
-		hex.NewAction(5, 0, hex.Red),
-		hex.NewAction(3, 1, hex.Blue),
-		hex.NewAction(6, 0, hex.Red),
-		hex.NewAction(2, 3, hex.Blue),
-		hex.NewAction(4, 3, hex.Red),
-		hex.NewAction(3, 4, hex.Blue),
-		hex.NewAction(5, 3, hex.Red),
-		hex.NewAction(1, 5, hex.Blue),
-	}
-
-	benchmarkAB(actions, 7, b)
-}
+const patFileName = "../common/game/hex/patterns.txt"
 
 /*
 . . . . . r r
@@ -91,43 +42,67 @@ func getActionsAndStateSample() ([]*hex.Action, *hex.State) {
 }
 
 func benchAB(b *testing.B, depthLimit int, abSubtype string) {
-	gridChan, patChan, stopChan, resultChan := hex.CreatePatChecker(patFileName)
-	defer func() { stopChan <- struct{}{} }()
+	pm, err := hex.Load(patFileName)
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	_, state := getActionsAndStateSample()
+	est, err := GetEstimateFunction(abSubtype, "", pm.GetAttributes())
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	for n := 0; n < b.N; n++ {
 		var oldTranspositionTable map[uint64]float64
 		for depth := 2; depth <= depthLimit; depth += 2 {
 			transpositionTable := make(map[uint64]float64)
-			alphaBeta(context.TODO(), 0, depth, state, nil, math.Inf(-1), math.Inf(1),
-				gridChan, patChan, resultChan, transpositionTable, oldTranspositionTable,
-				false, GetEstimateFunction(abSubtype), abSubtype)
+			alphaBeta(context.TODO(), 0, depth, hex.NewBoard(state), nil, math.Inf(-1), math.Inf(1),
+				pm.NewCounts(*state), nil, transpositionTable, oldTranspositionTable,
+				false, est)
 			oldTranspositionTable = transpositionTable
 		}
 	}
 }
 
-func BenchmarkAbLrLevel2(b *testing.B) {
-	benchAB(b, 2, "abLR")
+func BenchmarkAbErLevel2(b *testing.B) {
+	benchAB(b, 2, "abER")
 }
 
-func BenchmarkAbLrLevel4(b *testing.B) {
-	benchAB(b, 4, "abLR")
+func BenchmarkAbErLevel4(b *testing.B) {
+	benchAB(b, 4, "abER")
 }
 
-func BenchmarkAbLrLevel6(b *testing.B) {
-	benchAB(b, 6, "abLR")
-}
-
-func BenchmarkAbDtLevel2(b *testing.B) {
-	benchAB(b, 2, "abDT")
-}
+/*
+b r .
+ . r b
+  . . .
+*/
+func TestAlphaBetaER(t *testing.T) {
+	pm, err := hex.Load(patFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-func BenchmarkAbDtLevel4(b *testing.B) {
-	benchAB(b, 4, "abDT")
-}
+	actions := []*hex.Action{
+		hex.NewAction(1, 0, hex.Red),
+		hex.NewAction(0, 0, hex.Blue),
+		hex.NewAction(1, 1, hex.Red),
+		hex.NewAction(2, 1, hex.Blue),
+	}
+	state := hex.NewState(3, hex.Red)
+	for _, a := range actions {
+		s := state.GetSuccessorState(a).(hex.State)
+		state = &s
+	}
 
-func BenchmarkAbDtLevel6(b *testing.B) {
-	benchAB(b, 6, "abDT")
+	est, err := GetEstimateFunction("abER", "", pm.GetAttributes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := AlphaBeta(state, time.Second, false, pm, est)
+	s := state.GetSuccessorState(a).(hex.State)
+	if goal, _ := s.IsGoalState(false); !goal {
+		t.Fatalf("Expected a winning action, got %v", a)
+	}
 }
@@ -0,0 +1,79 @@
+//go:build gencode
+// +build gencode
+
+package ab
+
+import (
+	"fmt"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// This file uses the code generated in the ML phase (Sample, sampleAttributes,
+// mxs, usedPatterns, getEstimatedValueDT and getEstimatedValueLR), which is
+// copied to this directory by the Makefile. Without build tag gencode, players
+// need model files instead (see nocodeestimator.go).
+
+// getCodeEstimator returns the estimator of states for AB players of a given
+// subtype that uses the generated code
+func getCodeEstimator(subtype string, as *hex.AttributeSet) (Estimator, error) {
+	if err := checkSampleAttributes(as); err != nil {
+		return nil, err
+	}
+	switch subtype {
+	case "abDT":
+		return &codeEstimator{getEstimatedValueDT, false}, nil
+	case "abLR":
+		return &codeEstimator{getEstimatedValueLR, true}, nil
+	default:
+		return nil, fmt.Errorf("Invalid AB subtype: %s", subtype)
+	}
+}
+
+// checkSampleAttributes returns an error if attributes of Sample (see
+// sampleAttributes) differ from attributes as, which means that the generated
+// models were trained on samples with different attributes
+func checkSampleAttributes(as *hex.AttributeSet) error {
+	names := as.GetNames()
+	if len(names) != len(sampleAttributes) {
+		return fmt.Errorf("Sample has %d attributes, expected %d", len(sampleAttributes), len(names))
+	}
+	for i, n := range names {
+		if sampleAttributes[i] != n {
+			return fmt.Errorf("Attribute %d of Sample is %s, expected %s", i, sampleAttributes[i], n)
+		}
+	}
+	return nil
+}
+
+func getUsedPatternsForStoneNum(numStones int) []int {
+	for i, m := range mxs {
+		if numStones <= m {
+			return usedPatterns[i]
+		}
+	}
+	panic("Cannot find patterns")
+}
+
+// -------------------------
+// |     codeEstimator     |
+// -------------------------
+
+// codeEstimator estimates values of states with a function in the generated
+// code
+//	getEstimatedValue returns the value of a Sample for the Red player
+//	limitPatterns is true if only patterns in usedPatterns are needed
+type codeEstimator struct {
+	getEstimatedValue func(s *Sample) float64
+	limitPatterns     bool
+}
+
+// Estimate returns the value of State state for the Red player
+func (ce *codeEstimator) Estimate(state *hex.State, patCounts *hex.PatternCounts) float64 {
+	var usedPatterns []int
+	if ce.limitPatterns {
+		r, b, _ := state.GetNumOfStones()
+		usedPatterns = getUsedPatternsForStoneNum(r + b)
+	}
+	return ce.getEstimatedValue(newSample(getFeatures(state, patCounts, usedPatterns)))
+}
@@ -0,0 +1,185 @@
+package ab
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/RdecKa/0xAI/2-ml/learn"
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// Estimator estimates values of states. Estimators are safe for concurrent use,
+// so players can share them.
+type Estimator interface {
+	// Estimate returns the value of State state for the Red player. Pattern
+	// counts patCounts are updated to state if the estimator needs features
+	// of the state (see hex.PatternCounts.GetFeatures).
+	Estimate(state *hex.State, patCounts *hex.PatternCounts) float64
+}
+
+// GetEstimateFunction returns the estimator of states for AB players of a
+// given subtype. If modelFile is not empty, the model is loaded from the file
+// (see learn.ReadModelFile), otherwise the generated code is used, which is
+// only available with build tag gencode. Features of states are computed with
+// attributes as. Players of subtype abER need neither a model nor features.
+func GetEstimateFunction(subtype, modelFile string, as *hex.AttributeSet) (Estimator, error) {
+	if subtype == "abER" {
+		if modelFile != "" {
+			return nil, fmt.Errorf("%s: abER players do not use models", modelFile)
+		}
+		return resistanceEstimator{}, nil
+	}
+	if modelFile != "" {
+		return loadEstimator(subtype, modelFile, as)
+	}
+	return getCodeEstimator(subtype, as)
+}
+
+// getFeatures updates pattern counts patCounts to State state and returns
+// features of the state. Only patterns with indices usedPatterns are counted,
+// all of them if usedPatterns is nil.
+func getFeatures(state *hex.State, patCounts *hex.PatternCounts, usedPatterns []int) []float64 {
+	patCounts.Update(*state)
+	return patCounts.GetFeatures(usedPatterns, false)
+}
+
+// -------------------------------
+// |     resistanceEstimator     |
+// -------------------------------
+
+// resistanceEstimator estimates values of states only from effective
+// resistances between the edges of both players (see hex.State.GetResistance)
+type resistanceEstimator struct{}
+
+// Estimate returns the value of State state for the Red player. The value is
+// in [-1, 1]: 1 if the Red player has no resistance and -1 if the Blue player
+// has none.
+func (resistanceEstimator) Estimate(state *hex.State, _ *hex.PatternCounts) float64 {
+	r, b := state.GetResistance(hex.Red), state.GetResistance(hex.Blue)
+	switch {
+	case math.IsInf(r, 1):
+		return -1
+	case math.IsInf(b, 1):
+		return 1
+	case r+b == 0:
+		return 0
+	}
+	return (b - r) / (b + r)
+}
+
+// --------------------------
+// |     modelEstimator     |
+// --------------------------
+
+// modelEstimator estimates values of states with a model loaded from a model
+// file
+//	model is the loaded model
+//	indices are indices of attributes of the model among features of states,
+//		nil if they are the same
+//	mxs and usedPatterns are maximal numbers of stones and patterns that are
+//		needed for states with at most that many stones (see
+//		getModelUsedPatterns), nil if all patterns are needed
+type modelEstimator struct {
+	model        learn.Model
+	indices      []int
+	mxs          []int
+	usedPatterns [][]int
+}
+
+// loadEstimator returns the estimator with the model from a file. The type of
+// the model must match the subtype.
+func loadEstimator(subtype, modelFile string, as *hex.AttributeSet) (*modelEstimator, error) {
+	m, err := learn.ReadModelFile(modelFile)
+	if err != nil {
+		return nil, err
+	}
+	me := &modelEstimator{model: m}
+	switch m.(type) {
+	case *learn.LinearModel:
+		if subtype != "abLR" {
+			return nil, fmt.Errorf("%s: a linear model cannot be used by %s players", modelFile, subtype)
+		}
+	case *learn.TreeModel:
+		if subtype != "abDT" {
+			return nil, fmt.Errorf("%s: a decision tree cannot be used by %s players", modelFile, subtype)
+		}
+	}
+
+	names := as.GetNames()
+	attributes := m.GetAttributes()
+	same := len(attributes) == len(names)
+	indices := make([]int, len(attributes))
+	for i, a := range attributes {
+		j, ok := as.GetIndex(a)
+		if !ok {
+			return nil, fmt.Errorf("%s: attribute %s of the model is not computed for states", modelFile, a)
+		}
+		indices[i] = j
+		same = same && i == j
+	}
+	if !same {
+		me.indices = indices
+	}
+
+	if me.mxs, me.usedPatterns, err = getModelUsedPatterns(m, as); err != nil {
+		return nil, fmt.Errorf("%s: %s", modelFile, err)
+	}
+	return me, nil
+}
+
+// getModelUsedPatterns returns maximal numbers of stones and, for each of them,
+// indices of patterns whose counts are needed to compute attributes that Model
+// m uses for states with at most that many stones (see
+// hex.AttributeSet.GetUsedPatterns)
+func getModelUsedPatterns(m learn.Model, as *hex.AttributeSet) ([]int, [][]int, error) {
+	var mxs []int
+	var names [][]string
+	switch m := m.(type) {
+	case *learn.LinearModel:
+		mxs, names = m.GetUsedAttributes()
+	case *learn.TreeModel:
+		mxs, names = []int{math.MaxInt32}, [][]string{m.GetUsedAttributes()}
+	default:
+		return nil, nil, nil
+	}
+
+	usedPatterns := make([][]int, len(mxs))
+	for i := range mxs {
+		patterns, err := as.GetUsedPatterns(names[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		usedPatterns[i] = patterns
+	}
+	return mxs, usedPatterns, nil
+}
+
+// Estimate returns the value of State state for the Red player
+func (me *modelEstimator) Estimate(state *hex.State, patCounts *hex.PatternCounts) float64 {
+	r, b, _ := state.GetNumOfStones()
+	return me.estimateFeatures(getFeatures(state, patCounts, me.getUsedPatterns(r+b)))
+}
+
+// estimateFeatures returns the value of a state with features x for the Red
+// player
+func (me *modelEstimator) estimateFeatures(x []float64) float64 {
+	if me.indices != nil {
+		y := make([]float64, len(me.indices))
+		for i, j := range me.indices {
+			y[i] = x[j]
+		}
+		x = y
+	}
+	return me.model.Predict(x)
+}
+
+// getUsedPatterns returns indices of patterns needed for states with a given
+// number of stones, nil if all patterns are needed
+func (me *modelEstimator) getUsedPatterns(numStones int) []int {
+	for i, m := range me.mxs {
+		if numStones <= m {
+			return me.usedPatterns[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package ab
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RdecKa/0xAI/2-ml/learn"
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// writeModelFile writes Model m to a file in a temporary directory and returns
+// the name of the file
+func writeModelFile(t *testing.T, m learn.Model) string {
+	fileName := filepath.Join(t.TempDir(), "model.json")
+	f, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := learn.WriteModel(f, m); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestEstimatorFromFile(t *testing.T) {
+	pm, err := hex.Load(patFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := pm.GetAttributes()
+	resR, _ := as.GetIndex("res_r")
+	resB, _ := as.GetIndex("res_b")
+
+	// The tree uses only attributes res_b and res_r, in a different order than
+	// the pattern matcher
+	tree := &learn.TreeModel{
+		Attributes: []string{"res_b", "res_r"},
+		Root: &learn.TreeNode{
+			Feature:   1,
+			Threshold: 2,
+			Left:      &learn.TreeNode{Feature: -1, Value: 0.5},
+			Right:     &learn.TreeNode{Feature: -1, Value: -0.5},
+		},
+	}
+	fileName := writeModelFile(t, tree)
+	est, err := GetEstimateFunction("abDT", fileName, as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	me := est.(*modelEstimator)
+	x := make([]float64, as.Len())
+	x[resR], x[resB] = 1, 3
+	if v := me.estimateFeatures(x); v != 0.5 {
+		t.Fatalf("Expected 0.5, got %f", v)
+	}
+	x[resR] = 3
+	if v := me.estimateFeatures(x); v != -0.5 {
+		t.Fatalf("Expected -0.5, got %f", v)
+	}
+	// Resistances do not need counts of patterns
+	if p := me.getUsedPatterns(5); p == nil || len(p) != 0 {
+		t.Fatalf("Expected no patterns, got %v", p)
+	}
+
+	// The model can be used by AB
+	state := hex.NewState(3, hex.Red)
+	if a, _ := AlphaBeta(state, 100*time.Millisecond, false, pm, est); a == nil {
+		t.Fatalf("Expected an action")
+	}
+
+	// Models must match the subtype
+	for _, subtype := range []string{"abLR", "abER"} {
+		if _, err := GetEstimateFunction(subtype, fileName, as); err == nil {
+			t.Fatalf("Expected an error for subtype %s", subtype)
+		}
+	}
+
+	// Attributes of the model must be computed
+	tree.Attributes[0] = "unknown"
+	if _, err := GetEstimateFunction("abDT", writeModelFile(t, tree), as); err == nil {
+		t.Fatalf("Expected an error for an unknown attribute")
+	}
+
+	if _, err := GetEstimateFunction("abDT", filepath.Join(t.TempDir(), "missing.json"), as); err == nil {
+		t.Fatalf("Expected an error for a missing file")
+	}
+}
+
+func TestEstimatorFromFileLinear(t *testing.T) {
+	pm, err := hex.Load(patFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := pm.GetAttributes()
+
+	d := &learn.Data{Attributes: as.GetNames()}
+	for i := 0; i < 20; i++ {
+		x := make([]float64, as.Len())
+		x[0], x[1], x[len(x)-1] = float64(i), float64(i%2), float64(i*i%7)
+		d.X = append(d.X, x)
+		d.Y = append(d.Y, x[len(x)-1]/10)
+	}
+	lm, err := learn.TrainLinear(d, learn.LinearOptions{Splits: []int{10}, Lambda: 1e-9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	est, err := GetEstimateFunction("abLR", writeModelFile(t, lm), as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	me := est.(*modelEstimator)
+	for _, x := range d.X {
+		if me.estimateFeatures(x) != lm.Predict(x) {
+			t.Fatalf("Estimates differ from the model")
+		}
+	}
+	// Only patterns used by the model are counted, the same as in the
+	// generated code
+	mxs, patterns := lm.GetUsedPatterns()
+	if fmt.Sprint(me.mxs, me.usedPatterns) != fmt.Sprint(mxs, patterns) {
+		t.Fatalf("Expected patterns %v, got %v", patterns, me.usedPatterns)
+	}
+}
+
+func TestResistanceEstimator(t *testing.T) {
+	// abER players need no model and no attributes
+	est, err := GetEstimateFunction("abER", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetEstimateFunction("abER", "model.json", nil); err == nil {
+		t.Fatalf("Expected an error for a model file")
+	}
+
+	state := hex.NewState(3, hex.Red)
+	if v := est.Estimate(state, nil); math.Abs(v) > 1e-9 {
+		t.Fatalf("Expected 0 for the empty board, got %f", v)
+	}
+	s := state.GetSuccessorState(hex.NewAction(1, 1, hex.Red)).(hex.State)
+	if v := est.Estimate(&s, nil); v <= 0 || v >= 1 {
+		t.Fatalf("Expected a value in (0, 1) after a red stone in the center, got %f", v)
+	}
+	for _, a := range []*hex.Action{hex.NewAction(0, 0, hex.Blue), hex.NewAction(1, 0, hex.Red),
+		hex.NewAction(2, 0, hex.Blue), hex.NewAction(1, 2, hex.Red)} {
+		s = s.GetSuccessorState(a).(hex.State)
+	}
+	if v := est.Estimate(&s, nil); v != 1 {
+		t.Fatalf("Expected 1 after Red has connected the edges, got %f", v)
+	}
+}
@@ -11,25 +11,50 @@ import (
 type sortData struct {
 	data       []game.Action // slice of possible actions to be sorted
 	dataValues []float64     // values of actions
+	potentials []int         // potentials of cells of actions, used for actions with equal values
 	increasing bool          // true if the slice is to be sorted in increasing order, false for decreasing order
 }
 
-func initSortData(possibleActions []game.Action, state *hex.State,
+// potentialsInterval is the number of plies in which potentials of cells are
+// reused (see getPotentials). Children of a node order their moves by the
+// potentials of the node, which differ in a single stone.
+const potentialsInterval = 2
+
+// getPotentials returns the sum of potentials of each cell for both players in
+// the state on the board (see hex.State.GetCellPotentials). Cells that are
+// important for both players have the lowest sum. Potentials are computed on
+// the board itself, the state is not cloned.
+func getPotentials(board *hex.Board) []int {
+	potentials := board.State.GetCellPotentials(hex.Red)
+	for i, p := range board.State.GetCellPotentials(hex.Blue) {
+		potentials[i] += p
+	}
+	return potentials
+}
+
+func initSortData(possibleActions []game.Action, board *hex.Board, potentials []int,
 	oldTransitionTable map[uint64]float64, increasing bool) *sortData {
 	sd := &sortData{
 		data:       possibleActions,
 		dataValues: make([]float64, len(possibleActions)),
+		potentials: make([]int, len(possibleActions)),
 		increasing: increasing,
 	}
 
+	size := board.GetSize()
 	for i, a := range possibleActions {
-		successorState := state.GetSuccessorState(a).(hex.State)
-		tt, ok := oldTransitionTable[successorState.GetMapKey()]
-		if !ok {
-			sd.dataValues[i] = 0
-		} else {
-			sd.dataValues[i] = tt
+		if oldTransitionTable != nil {
+			board.Play(a)
+			tt, ok := oldTransitionTable[board.GetMapKey()]
+			board.Undo()
+			if !ok {
+				sd.dataValues[i] = 0
+			} else {
+				sd.dataValues[i] = tt
+			}
 		}
+		x, y := a.(*hex.Action).GetCoordinates()
+		sd.potentials[i] = potentials[y*size+x]
 	}
 	return sd
 }
@@ -39,25 +64,28 @@ func (d *sortData) Len() int {
 }
 
 func (d *sortData) Less(i, j int) bool {
-	if d.increasing {
-		return d.dataValues[i] < d.dataValues[j]
+	if d.dataValues[i] != d.dataValues[j] {
+		if d.increasing {
+			return d.dataValues[i] < d.dataValues[j]
+		}
+		return d.dataValues[i] > d.dataValues[j]
 	}
-	return d.dataValues[i] >= d.dataValues[j]
+	return d.potentials[i] < d.potentials[j]
 }
 
 func (d *sortData) Swap(i, j int) {
 	d.data[i], d.data[j] = d.data[j], d.data[i]
 	d.dataValues[i], d.dataValues[j] = d.dataValues[j], d.dataValues[i]
+	d.potentials[i], d.potentials[j] = d.potentials[j], d.potentials[i]
 }
 
-func orderMoves(state *hex.State, possibleActions []game.Action,
+// orderMoves sorts possibleActions by values of the states they lead to in
+// oldTransitionTable (if it is not nil) and then by potentials of their cells
+// (see getPotentials)
+func orderMoves(board *hex.Board, possibleActions []game.Action, potentials []int,
 	oldTransitionTable map[uint64]float64, increasing bool) []game.Action {
 
-	if oldTransitionTable == nil {
-		return possibleActions
-	}
-
-	sd := initSortData(possibleActions, state, oldTransitionTable, increasing)
+	sd := initSortData(possibleActions, board, potentials, oldTransitionTable, increasing)
 	sort.Sort(sd)
 
 	return sd.data
@@ -0,0 +1,21 @@
+//go:build !gencode
+// +build !gencode
+
+package ab
+
+import (
+	"fmt"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// getCodeEstimator returns an error, because the generated code is only
+// compiled with build tag gencode (see codeestimator.go)
+func getCodeEstimator(subtype string, as *hex.AttributeSet) (Estimator, error) {
+	switch subtype {
+	case "abDT", "abLR":
+		return nil, fmt.Errorf("%s players need a model file, generated code is only compiled with build tag gencode", subtype)
+	default:
+		return nil, fmt.Errorf("Invalid AB subtype: %s", subtype)
+	}
+}
@@ -1,4 +1,4 @@
-package astarsearch
+package astarsearch_test
 
 import (
 	"fmt"
@@ -141,6 +141,24 @@ func Benchmark3(b *testing.B) {
 	benchmarkAStarSearchOnHexGrid(actions, 7, b)
 }
 
+// testIsGoalState plays actions on an empty grid of a given size and checks
+// whether the last player has a (virtual) connection
+func testIsGoalState(actions []*hex.Action, size byte, firstPlayer hex.Color, exists bool, t *testing.T) {
+	state := hex.NewState(size, firstPlayer)
+	for _, a := range actions {
+		s := state.GetSuccessorState(a).(hex.State)
+		state = &s
+	}
+
+	e, s := state.IsGoalState(false)
+	if e && !exists {
+		t.Fatalf("Solution found, but it does not exist")
+		fmt.Println(s)
+	} else if !e && exists {
+		t.Fatalf("Solution exists, but it was not found")
+	}
+}
+
 /*
 . . . . .
  . . b . .
@@ -149,6 +167,8 @@ func Benchmark3(b *testing.B) {
     . . . r .
 */
 func Test1(t *testing.T) {
+	// Blue's stone in the third column is connected to the left edge by a
+	// ziggurat and to the other stone by a bridge
 	actions := []*hex.Action{
 		hex.NewAction(1, 3, hex.Blue),
 		hex.NewAction(2, 3, hex.Red),
@@ -157,15 +177,25 @@ func Test1(t *testing.T) {
 		hex.NewAction(3, 2, hex.Blue),
 	}
 
-	state := hex.NewState(5, hex.Blue)
-	for _, a := range actions {
-		s := state.GetSuccessorState(a).(hex.State)
-		state = &s
-	}
+	testIsGoalState(actions, 5, hex.Blue, true, t)
+}
 
-	e, s := state.IsGoalState(false)
-	if e {
-		t.Fatalf("Solution found, but it does not exist")
-		fmt.Println(s)
+/*
+. . . . .
+ . r b . .
+  . . . b .
+   . b r . .
+    . . . . .
+*/
+func Test2(t *testing.T) {
+	// Red's stone breaks the ziggurat, and the two bridges share a cell
+	actions := []*hex.Action{
+		hex.NewAction(1, 3, hex.Blue),
+		hex.NewAction(2, 3, hex.Red),
+		hex.NewAction(2, 1, hex.Blue),
+		hex.NewAction(1, 1, hex.Red),
+		hex.NewAction(3, 2, hex.Blue),
 	}
+
+	testIsGoalState(actions, 5, hex.Blue, false, t)
 }
@@ -12,7 +12,25 @@ type State interface {
 	IsGoalState(bool) (bool, interface{})
 	EvaluateGoalState(bool) float64
 	Same(State) bool
-	GenSample(float64, chan []uint32, chan []int, chan [2][]int) string // Returns a string representing state attributes for supervised machine learning
+	GetBoard() Board // Returns a mutable copy of the state
+}
+
+// PrunedState represents a state in a game that can leave out actions that are
+// provably not better than other possible actions
+type PrunedState interface {
+	GetPrunedActions() []Action
+}
+
+// -----------------
+// |     Board     |
+// -----------------
+
+// Board represents a state in a game that is changed in place. Actions are
+// played and undone without allocating new states.
+type Board interface {
+	State
+	Play(Action)
+	Undo()
 }
 
 // ------------------
@@ -28,8 +46,24 @@ type Action interface {
 // |     Attribute     |
 // ---------------------
 
-// Attribute represents one charasteristic of a game state
+// Attribute represents one charasteristic of a game state. Its value is
+// computed in an evaluation context of the game.
 type Attribute interface {
 	GetAttributeName() string
-	GetAttributeValue(*[]interface{}) int
+	GetAttributeValue(EvalContext) float64
+}
+
+// VectorAttribute represents several charasteristics of a game state with a
+// common name, such as a value for each cell of a board. Values are written
+// into a slice of length GetAttributeLength().
+type VectorAttribute interface {
+	GetAttributeName() string
+	GetAttributeLength() int
+	GetAttributeValues(EvalContext, []float64)
+}
+
+// EvalContext holds a game state and everything that was computed for it and
+// is needed to get values of attributes (for example counts of patterns)
+type EvalContext interface {
+	GetState() State
 }
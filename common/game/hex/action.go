@@ -7,26 +7,54 @@ import "fmt"
 // ------------------
 
 // Action shows where next stone will be placed and of which color
+//	swap is true if the action is a swap move (the second player takes over the
+//		opponent's first stone). The stone is then reflected over the long
+//		diagonal and recolored, so (x, y) of opponent's stone becomes (y, x) of
+//		player c. x and y of a swap action are the coordinates of the reflected
+//		stone (they are set once the swap is applied to a state).
 type Action struct {
 	x, y byte
 	c    Color
+	swap bool
 }
 
 // NewAction creates a new action. x and y are coordinates of a stone placed by
 // the player c
 func NewAction(x, y byte, c Color) *Action {
-	return &Action{x, y, c}
+	return &Action{x, y, c, false}
 }
 
+// NewSwapAction creates a new swap action for the player c. Coordinates of the
+// swapped stone are determined when the action is applied to a state.
+func NewSwapAction(c Color) *Action {
+	return &Action{0, 0, c, true}
+}
+
+// String returns the color of the player and the action in the standard
+// notation (see GetNotation), for example "r: c4". The cell of the swapped
+// stone is added to the swap action, for example "b: swap d3".
 func (a *Action) String() string {
-	return fmt.Sprintf("%s: (%d, %d)", a.c, a.x, a.y)
+	if a.swap {
+		return fmt.Sprintf("%s: %s %s", a.c, swapNotation, getCellNotation(int(a.x), int(a.y)))
+	}
+	return fmt.Sprintf("%s: %s", a.c, a.GetNotation())
 }
 
 func (a *Action) clone() *Action {
-	return NewAction(a.x, a.y, a.c)
+	return &Action{a.x, a.y, a.c, a.swap}
 }
 
 // GetCoordinates returns X and Y coordinates of an action
 func (a *Action) GetCoordinates() (int, int) {
 	return int(a.x), int(a.y)
 }
+
+// GetColor returns the color of the player who makes an action
+func (a *Action) GetColor() Color {
+	return a.c
+}
+
+// IsSwap returns true if the action is a swap move
+func (a *Action) IsSwap() bool {
+	return a.swap
+}
@@ -34,11 +34,12 @@ type searchState struct {
 	c               Color        // The color that a solution is searched for
 	gameState       *State       // State in a game where solution is searched for
 	prevSearchState *searchState // The preceeding search state
+	carrier         [][2]int     // Empty cells that hold the (virtual) connection from the preceeding search state
 }
 
 // GetInitialState returns inital state of the search
 func GetInitialState(gameState *State) searchState {
-	return searchState{-1, -1, gameState.lastAction.c, gameState, nil}
+	return searchState{-1, -1, gameState.lastAction.c, gameState, nil, nil}
 }
 
 // GetClean returns an array that is unique for each searchState.
@@ -85,23 +86,21 @@ func (s searchState) GetSuccessorStates(veryEnd bool) []astarsearch.State {
 		// Add cells in the first row/column (directly connected to the edge)
 		for ; a < int(s.gameState.size); a++ {
 			if s.gameState.getColorOn(byte(*xp), byte(*yp)) == s.c {
-				successors = append(successors, searchState{*xp, *yp, s.c, s.gameState, &s})
+				successors = append(successors, searchState{*xp, *yp, s.c, s.gameState, &s, nil})
 			}
 		}
 
 		if !veryEnd {
-			// Add cells in the second row/column (those which are virtually
-			// connected to the player's first edge)
-			b = 1
-			xDiffFirst, yDiffFirst, xDiffSecond, yDiffSecond := 0, -1, 1, -1
-			if s.c == Blue {
-				xDiffFirst, yDiffFirst, xDiffSecond, yDiffSecond = yDiffFirst, xDiffFirst, yDiffSecond, xDiffSecond
-			}
-			for a = 0; a < int(s.gameState.size)-1; a++ {
-				if s.gameState.getColorOn(byte(*xp), byte(*yp)) == s.c &&
-					s.gameState.getColorOn(byte(*xp+xDiffFirst), byte(*yp+yDiffFirst)) == None &&
-					s.gameState.getColorOn(byte(*xp+xDiffSecond), byte(*yp+yDiffSecond)) == None {
-					successors = append(successors, searchState{*xp, *yp, s.c, s.gameState, &s})
+			// Add cells further away that are connected to the player's first
+			// edge by an edge template
+			for b = 1; b < int(s.gameState.size); b++ {
+				for a = 0; a < int(s.gameState.size); a++ {
+					if s.gameState.getColorOn(byte(*xp), byte(*yp)) != s.c {
+						continue
+					}
+					if _, carrier, ok := s.gameState.GetEdgeTemplate(*xp, *yp, 0); ok {
+						successors = append(successors, searchState{*xp, *yp, s.c, s.gameState, &s, carrier})
+					}
 				}
 			}
 		}
@@ -113,7 +112,7 @@ func (s searchState) GetSuccessorStates(veryEnd bool) []astarsearch.State {
 			if s.gameState.IsCellValid(x, y) {
 				c := s.gameState.getColorOn(byte(x), byte(y))
 				if c == s.c {
-					successors = append(successors, searchState{x, y, s.c, s.gameState, &s})
+					successors = append(successors, searchState{x, y, s.c, s.gameState, &s, nil})
 				} else if c == None {
 					directNeighboursEmpty[in] = true
 				}
@@ -128,39 +127,40 @@ func (s searchState) GetSuccessorStates(veryEnd bool) []astarsearch.State {
 				// Both cells in between are empty
 
 				x, y := s.x+vc[0], s.y+vc[1]
+				if !s.gameState.IsCellValid(x, y) || s.gameState.getColorOn(byte(x), byte(y)) != s.c {
+					continue
+				}
+				n1, n2 := neighbours[(ivc+5)%6], neighbours[ivc]
+				carrier := [][2]int{{s.x + n1[0], s.y + n1[1]}, {s.x + n2[0], s.y + n2[1]}}
+				if !s.overlapsWithIncomingCarrier(carrier) {
+					successors = append(successors, searchState{x, y, s.c, s.gameState, &s, carrier})
+				}
+			}
 
-				if s.gameState.IsEndingCell(x, y, s.c) ||
-					s.gameState.IsCellValid(x, y) &&
-						s.gameState.getColorOn(byte(x), byte(y)) == s.c &&
-						!s.overlapsWithIncomingVirtualConnection(x, y) {
-					successors = append(successors, searchState{x, y, s.c, s.gameState, &s})
+			// Add the cell beyond the opposite edge if the current cell is
+			// connected to the edge by an edge template
+			if _, carrier, ok := s.gameState.GetEdgeTemplate(s.x, s.y, 1); ok && !s.overlapsWithIncomingCarrier(carrier) {
+				x, y := s.x, int(s.gameState.size)
+				if s.c == Blue {
+					x, y = y, s.y
 				}
+				successors = append(successors, searchState{x, y, s.c, s.gameState, &s, carrier})
 			}
 		}
 	}
 	return successors
 }
 
-func (s searchState) overlapsWithIncomingVirtualConnection(x, y int) bool {
-	curCoords := [2]int{s.x, s.y}
-	newCoords := [2]int{x, y}
-	if DirectlyConnected(newCoords, curCoords) {
-		return false
-	}
-
-	prvCoords := [2]int{s.prevSearchState.x, s.prevSearchState.y}
-	if DirectlyConnected(curCoords, prvCoords) {
-		return false
-	}
-
-	lastConn := GetTwoCellsBewteen(curCoords, prvCoords)
-	nextConn := GetTwoCellsBewteen(curCoords, newCoords)
-
-	if lastConn[0] == nextConn[0] || lastConn[0] == nextConn[1] ||
-		lastConn[1] == nextConn[0] || lastConn[1] == nextConn[1] {
-		return true
+// overlapsWithIncomingCarrier returns true if carrier shares a cell with the
+// carrier of the connection that leads to the searchState s
+func (s searchState) overlapsWithIncomingCarrier(carrier [][2]int) bool {
+	for _, a := range s.carrier {
+		for _, b := range carrier {
+			if a == b {
+				return true
+			}
+		}
 	}
-
 	return false
 }
 
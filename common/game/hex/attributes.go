@@ -2,22 +2,29 @@
 // evaluating a hex board are listed.
 //
 // To add an attribute, do the following:
-// 	- Implement a type implementing game.Attribute
-// 	- Initialize instance(s) of that attribute
-// 	- Add this/these instance(s) to the slice GenSamAttributes (together with
-// 		matching opposite attribute)
-// 	- In 2-ml/learn.py, select a type of the attribute when reading a CSV
-// 		file (for now only integer values are supported)
-// 	- In 3-ab/ab.go, add a line to initialization of Sample sample for each
-// 		instance of the attribute
+// 	- Implement a type implementing game.Attribute (or game.VectorAttribute
+// 		for an attribute with several values), getting the state and pattern
+// 		counts from the EvalContext
+// 	- Add an instance of that attribute to baseAttributes, together with its
+// 		color-mirrored counterpart (see AttributeSet.Register), or register a
+// 		vector attribute in newSampleAttributes (see
+// 		AttributeSet.RegisterVector)
+// 	- Generate learning samples and train models again (models for AB must
+// 		use the same attributes as samples in AB)
 //
-// To remove an attribute, simply delete it from the GenSamAttributes. To
-// completely remove it, undo the steps listed in instructions for adding an
-// attribute.
+// Learning samples (see GenSample), the CSV header (see GetHeaderCSV) and
+// samples evaluated by AB (see PatternCounts.GetFeatures) are all produced
+// from the same AttributeSet, so they contain new attributes automatically.
+// Attributes that count patterns are generated for each pattern in the pattern
+// file (see newSampleAttributes). Planes of stones, which depend on the size of
+// the board, are added only on request (see PatternMatcher.WithCellPlanes).
+//
+// To remove an attribute, simply delete it from baseAttributes.
 package hex
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/RdecKa/0xAI/common/game"
 )
@@ -39,65 +46,23 @@ var (
 	AttrOccBlueRows = AttrOccupiedRowsCols{Blue, true}
 	AttrOccBlueCols = AttrOccupiedRowsCols{Blue, false}
 
-	AttrPatCountRed0  = AttrPatternCount{Red, 0}
-	AttrPatCountRed1  = AttrPatternCount{Red, 1}
-	AttrPatCountRed2  = AttrPatternCount{Red, 2}
-	AttrPatCountRed3  = AttrPatternCount{Red, 3}
-	AttrPatCountRed4  = AttrPatternCount{Red, 4}
-	AttrPatCountRed5  = AttrPatternCount{Red, 5}
-	AttrPatCountRed6  = AttrPatternCount{Red, 6}
-	AttrPatCountRed7  = AttrPatternCount{Red, 7}
-	AttrPatCountRed8  = AttrPatternCount{Red, 8}
-	AttrPatCountRed9  = AttrPatternCount{Red, 9}
-	AttrPatCountRed10 = AttrPatternCount{Red, 10}
-	AttrPatCountRed11 = AttrPatternCount{Red, 11}
-	AttrPatCountRed12 = AttrPatternCount{Red, 12}
-	AttrPatCountRed13 = AttrPatternCount{Red, 13}
-	AttrPatCountRed14 = AttrPatternCount{Red, 14}
-	AttrPatCountRed15 = AttrPatternCount{Red, 15}
-	AttrPatCountRed16 = AttrPatternCount{Red, 16}
-	AttrPatCountRed17 = AttrPatternCount{Red, 17}
-	AttrPatCountRed18 = AttrPatternCount{Red, 18}
-	AttrPatCountRed19 = AttrPatternCount{Red, 19}
-	AttrPatCountRed20 = AttrPatternCount{Red, 20}
-	AttrPatCountRed21 = AttrPatternCount{Red, 21}
-	AttrPatCountRed22 = AttrPatternCount{Red, 22}
-	AttrPatCountRed23 = AttrPatternCount{Red, 23}
-
-	AttrPatCountBlue0  = AttrPatternCount{Blue, 0}
-	AttrPatCountBlue1  = AttrPatternCount{Blue, 1}
-	AttrPatCountBlue2  = AttrPatternCount{Blue, 2}
-	AttrPatCountBlue3  = AttrPatternCount{Blue, 3}
-	AttrPatCountBlue4  = AttrPatternCount{Blue, 4}
-	AttrPatCountBlue5  = AttrPatternCount{Blue, 5}
-	AttrPatCountBlue6  = AttrPatternCount{Blue, 6}
-	AttrPatCountBlue7  = AttrPatternCount{Blue, 7}
-	AttrPatCountBlue8  = AttrPatternCount{Blue, 8}
-	AttrPatCountBlue9  = AttrPatternCount{Blue, 9}
-	AttrPatCountBlue10 = AttrPatternCount{Blue, 10}
-	AttrPatCountBlue11 = AttrPatternCount{Blue, 11}
-	AttrPatCountBlue12 = AttrPatternCount{Blue, 12}
-	AttrPatCountBlue13 = AttrPatternCount{Blue, 13}
-	AttrPatCountBlue14 = AttrPatternCount{Blue, 14}
-	AttrPatCountBlue15 = AttrPatternCount{Blue, 15}
-	AttrPatCountBlue16 = AttrPatternCount{Blue, 16}
-	AttrPatCountBlue17 = AttrPatternCount{Blue, 17}
-	AttrPatCountBlue18 = AttrPatternCount{Blue, 18}
-	AttrPatCountBlue19 = AttrPatternCount{Blue, 19}
-	AttrPatCountBlue20 = AttrPatternCount{Blue, 20}
-	AttrPatCountBlue21 = AttrPatternCount{Blue, 21}
-	AttrPatCountBlue22 = AttrPatternCount{Blue, 22}
-	AttrPatCountBlue23 = AttrPatternCount{Blue, 23}
+	AttrResistanceRed  = AttrResistance{Red}
+	AttrResistanceBlue = AttrResistance{Blue}
+
+	AttrShortestConnectionRed  = AttrShortestConnection{Red}
+	AttrShortestConnectionBlue = AttrShortestConnection{Blue}
+	AttrTwoDistanceRed         = AttrTwoDistance{Red}
+	AttrTwoDistanceBlue        = AttrTwoDistance{Blue}
 )
 
-// GenSamAttributes contains the attributes that are included in the sample
-// generation. Each sub-slice represents a pair of attributes that are oppposite
-// to each other. This information is used in generation of learning samples
-// when two samples are generated for each state - one as it is and one with
-// switched roles of red and blue player.
+// baseAttributes contains the attributes that are included in learning
+// samples, besides pattern counts. Each sub-slice represents a pair of
+// attributes that are oppposite to each other. This information is used in
+// generation of learning samples when two samples are generated for each state
+// - one as it is and one with switched roles of red and blue player.
 // If the second element of a pair is nil, the attribute is the same for both
 // players.
-var GenSamAttributes = [][2]game.Attribute{
+var baseAttributes = [][2]game.Attribute{
 	[2]game.Attribute{AttrNumStones, nil},
 	[2]game.Attribute{AttrLastPlayer, AttrLastPlayerOpponent},
 
@@ -112,55 +77,30 @@ var GenSamAttributes = [][2]game.Attribute{
 	[2]game.Attribute{AttrOccBlueRows, AttrOccRedCols},
 	[2]game.Attribute{AttrOccBlueCols, AttrOccRedRows},
 
-	[2]game.Attribute{AttrPatCountRed0, AttrPatCountBlue0},
-	[2]game.Attribute{AttrPatCountRed1, AttrPatCountBlue3},
-	[2]game.Attribute{AttrPatCountRed2, AttrPatCountBlue2},
-	[2]game.Attribute{AttrPatCountRed3, AttrPatCountBlue1},
-	[2]game.Attribute{AttrPatCountRed4, AttrPatCountBlue4},
-	[2]game.Attribute{AttrPatCountRed5, AttrPatCountBlue7},
-	[2]game.Attribute{AttrPatCountRed6, AttrPatCountBlue6},
-	[2]game.Attribute{AttrPatCountRed7, AttrPatCountBlue5},
-	[2]game.Attribute{AttrPatCountRed8, AttrPatCountBlue10},
-	[2]game.Attribute{AttrPatCountRed9, AttrPatCountBlue9},
-	[2]game.Attribute{AttrPatCountRed10, AttrPatCountBlue8},
-	[2]game.Attribute{AttrPatCountRed11, AttrPatCountBlue12},
-	[2]game.Attribute{AttrPatCountRed12, AttrPatCountBlue11},
-	[2]game.Attribute{AttrPatCountRed13, AttrPatCountBlue13},
-	[2]game.Attribute{AttrPatCountRed14, AttrPatCountBlue15},
-	[2]game.Attribute{AttrPatCountRed15, AttrPatCountBlue14},
-	[2]game.Attribute{AttrPatCountRed16, AttrPatCountBlue16},
-	[2]game.Attribute{AttrPatCountRed17, AttrPatCountBlue17},
-	[2]game.Attribute{AttrPatCountRed18, AttrPatCountBlue19},
-	[2]game.Attribute{AttrPatCountRed19, AttrPatCountBlue18},
-	[2]game.Attribute{AttrPatCountRed20, AttrPatCountBlue20},
-	[2]game.Attribute{AttrPatCountRed21, AttrPatCountBlue21},
-	[2]game.Attribute{AttrPatCountRed22, AttrPatCountBlue23},
-	[2]game.Attribute{AttrPatCountRed23, AttrPatCountBlue22},
-
-	[2]game.Attribute{AttrPatCountBlue0, AttrPatCountRed0},
-	[2]game.Attribute{AttrPatCountBlue1, AttrPatCountRed3},
-	[2]game.Attribute{AttrPatCountBlue2, AttrPatCountRed2},
-	[2]game.Attribute{AttrPatCountBlue3, AttrPatCountRed1},
-	[2]game.Attribute{AttrPatCountBlue4, AttrPatCountRed4},
-	[2]game.Attribute{AttrPatCountBlue5, AttrPatCountRed7},
-	[2]game.Attribute{AttrPatCountBlue6, AttrPatCountRed6},
-	[2]game.Attribute{AttrPatCountBlue7, AttrPatCountRed5},
-	[2]game.Attribute{AttrPatCountBlue8, AttrPatCountRed10},
-	[2]game.Attribute{AttrPatCountBlue9, AttrPatCountRed9},
-	[2]game.Attribute{AttrPatCountBlue10, AttrPatCountRed8},
-	[2]game.Attribute{AttrPatCountBlue11, AttrPatCountRed12},
-	[2]game.Attribute{AttrPatCountBlue12, AttrPatCountRed11},
-	[2]game.Attribute{AttrPatCountBlue13, AttrPatCountRed13},
-	[2]game.Attribute{AttrPatCountBlue14, AttrPatCountRed15},
-	[2]game.Attribute{AttrPatCountBlue15, AttrPatCountRed14},
-	[2]game.Attribute{AttrPatCountBlue16, AttrPatCountRed16},
-	[2]game.Attribute{AttrPatCountBlue17, AttrPatCountRed17},
-	[2]game.Attribute{AttrPatCountBlue18, AttrPatCountRed19},
-	[2]game.Attribute{AttrPatCountBlue19, AttrPatCountRed18},
-	[2]game.Attribute{AttrPatCountBlue20, AttrPatCountRed20},
-	[2]game.Attribute{AttrPatCountBlue21, AttrPatCountRed21},
-	[2]game.Attribute{AttrPatCountBlue22, AttrPatCountRed23},
-	[2]game.Attribute{AttrPatCountBlue23, AttrPatCountRed22},
+	[2]game.Attribute{AttrResistanceRed, AttrResistanceBlue},
+	[2]game.Attribute{AttrResistanceBlue, AttrResistanceRed},
+
+	[2]game.Attribute{AttrShortestConnectionRed, AttrShortestConnectionBlue},
+	[2]game.Attribute{AttrShortestConnectionBlue, AttrShortestConnectionRed},
+	[2]game.Attribute{AttrTwoDistanceRed, AttrTwoDistanceBlue},
+	[2]game.Attribute{AttrTwoDistanceBlue, AttrTwoDistanceRed},
+}
+
+// newSampleAttributes returns attributes of learning samples: baseAttributes
+// followed by counts of each pattern of PatternMatcher pm for the red and then
+// for the blue player. The opposite attribute of a pattern count is the count
+// of its color-swapped counterpart for the opponent.
+func newSampleAttributes(pm *PatternMatcher) *AttributeSet {
+	as := NewAttributeSet()
+	for _, pair := range baseAttributes {
+		as.Register(pair[0], pair[1])
+	}
+	for _, c := range []Color{Red, Blue} {
+		for i := 0; i < pm.GetNumPatterns(); i++ {
+			as.Register(AttrPatternCount{c, i}, AttrPatternCount{c.Opponent(), pm.GetCounterpart(i)})
+		}
+	}
+	return as
 }
 
 // ----------------------------
@@ -175,10 +115,20 @@ func (a AttrNumberStones) GetAttributeName() string {
 	return "num_stones"
 }
 
-// GetAttributeValue returns the value of an attribute
-func (a AttrNumberStones) GetAttributeValue(args *[]interface{}) int {
-	patCount := (*args)[1].([2][]int)
-	return patCount[0][0] + patCount[1][0] // red_p0 + blue_p0
+// GetAttributeValue returns the value of an attribute. The value is taken from
+// counts of the first pattern (a single stone) if patterns are counted.
+func (a AttrNumberStones) GetAttributeValue(ctx game.EvalContext) float64 {
+	hc := getEvalContext(ctx)
+	if len(hc.PatCount[0]) == 0 {
+		r, b, _ := hc.State.GetNumOfStones()
+		return float64(r + b)
+	}
+	return float64(hc.PatCount[0][0] + hc.PatCount[1][0]) // red_p0 + blue_p0
+}
+
+// getUsedPatterns returns the index of the first pattern (a single stone)
+func (a AttrNumberStones) getUsedPatterns() []int {
+	return []int{0}
 }
 
 // --------------------------------
@@ -217,8 +167,8 @@ func (a AttrOccupiedRowsCols) GetAttributeName() string {
 }
 
 // GetAttributeValue returns the value of an attribute
-func (a AttrOccupiedRowsCols) GetAttributeValue(args *[]interface{}) int {
-	patCount := (*args)[1].([2][]int)
+func (a AttrOccupiedRowsCols) GetAttributeValue(ctx game.EvalContext) float64 {
+	patCount := getEvalContext(ctx).PatCount
 	i := -1
 	switch a.color {
 	case Red:
@@ -236,7 +186,13 @@ func (a AttrOccupiedRowsCols) GetAttributeValue(args *[]interface{}) int {
 		r = patCount[i][len(patCount[i])-1]
 	}
 
-	return r
+	return float64(r)
+}
+
+// getUsedPatterns returns nil, because stones and virtual connections of all
+// patterns occupy rows and columns
+func (a AttrOccupiedRowsCols) getUsedPatterns() []int {
+	return nil
 }
 
 // ----------------------------
@@ -267,8 +223,8 @@ func (a AttrPatternCount) GetAttributeName() string {
 }
 
 // GetAttributeValue returns the value of an attribute
-func (a AttrPatternCount) GetAttributeValue(args *[]interface{}) int {
-	patCount := (*args)[1].([2][]int)
+func (a AttrPatternCount) GetAttributeValue(ctx game.EvalContext) float64 {
+	patCount := getEvalContext(ctx).PatCount
 	i := -1
 	switch a.color {
 	case Red:
@@ -279,7 +235,12 @@ func (a AttrPatternCount) GetAttributeValue(args *[]interface{}) int {
 		panic(fmt.Errorf("Invalid color %v", a.color))
 	}
 
-	return patCount[i][a.patternIndex]
+	return float64(patCount[i][a.patternIndex])
+}
+
+// getUsedPatterns returns the index of the counted pattern
+func (a AttrPatternCount) getUsedPatterns() []int {
+	return []int{a.patternIndex}
 }
 
 // ------------------------------
@@ -297,9 +258,8 @@ func (a AttrLastPlayerTurn) GetAttributeName() string {
 }
 
 // GetAttributeValue returns 0 if the Red player had the last turn and 1 otherwise
-func (a AttrLastPlayerTurn) GetAttributeValue(args *[]interface{}) int {
-	s := (*args)[0].(State)
-	lp := s.GetLastPlayer()
+func (a AttrLastPlayerTurn) GetAttributeValue(ctx game.EvalContext) float64 {
+	lp := getEvalContext(ctx).State.GetLastPlayer()
 	switch {
 	// Actual state
 	case a.isLastPlayer && lp == Red:
@@ -331,24 +291,20 @@ func (a AttrSumOfDistancesToCenter) GetAttributeName() string {
 }
 
 // GetAttributeValue returns the value of an attribute
-func (a AttrSumOfDistancesToCenter) GetAttributeValue(args *[]interface{}) int {
+func (a AttrSumOfDistancesToCenter) GetAttributeValue(ctx game.EvalContext) float64 {
 	sum := 0
-	state := (*args)[0].(State)
+	state := getEvalContext(ctx).State
 	size := state.GetSize()
-	board := state.GetCopyGrid()
 
-	for rowIndex, row := range board {
-		r := row
+	for rowIndex, row := range state.grid {
 		for colIndex := 0; colIndex < size; colIndex++ {
-			c := GetColorFromBits(r & 3)
-			if c == a.color {
+			if getCellInRow(row, byte(colIndex)) == a.color {
 				cx, cy := getClosestCenterCoordinates(size, colIndex, rowIndex)
 				sum += getDistanceBetween(cx, cy, colIndex, rowIndex)
 			}
-			r = r >> 2
 		}
 	}
-	return sum
+	return float64(sum)
 }
 
 // ------------------------------------------
@@ -367,9 +323,74 @@ func (a AttrNumberOfReachableEmptyCells) GetAttributeName() string {
 }
 
 // GetAttributeValue returns the value of an attribute
-func (a AttrNumberOfReachableEmptyCells) GetAttributeValue(args *[]interface{}) int {
-	state := (*args)[0].(State)
-	return state.GetNumberOfReachableEmptyCellsForPlayer(a.color)
+func (a AttrNumberOfReachableEmptyCells) GetAttributeValue(ctx game.EvalContext) float64 {
+	state := getEvalContext(ctx).State
+	return float64(state.GetNumberOfReachableEmptyCellsForPlayer(a.color))
+}
+
+// --------------------------
+// |     AttrResistance     |
+// --------------------------
+
+// AttrResistance returns the effective resistance between the edges of a
+// player (see State.GetResistance). If the opponent has connected the edges,
+// the highest finite resistance on the board is returned instead of +Inf. The
+// value is rounded to 6 decimal places, so that it is the same in a state with
+// swapped roles of the players, whose circuit is solved in a different order.
+type AttrResistance struct {
+	color Color
+}
+
+// GetAttributeName returns the name of an attribute
+func (a AttrResistance) GetAttributeName() string {
+	return "res_" + a.color.String()
+}
+
+// GetAttributeValue returns the value of an attribute
+func (a AttrResistance) GetAttributeValue(ctx game.EvalContext) float64 {
+	state := getEvalContext(ctx).State
+	r := math.Min(state.GetResistance(a.color), getMaxResistance(state.GetSize()))
+	return math.Round(r*1e6) / 1e6
+}
+
+// ----------------------------------
+// |     AttrShortestConnection     |
+// ----------------------------------
+
+// AttrShortestConnection returns the minimal number of stones that a player
+// needs to connect the edges (see State.GetShortestConnection)
+type AttrShortestConnection struct {
+	color Color
+}
+
+// GetAttributeName returns the name of an attribute
+func (a AttrShortestConnection) GetAttributeName() string {
+	return "sc_" + a.color.String()
+}
+
+// GetAttributeValue returns the value of an attribute
+func (a AttrShortestConnection) GetAttributeValue(ctx game.EvalContext) float64 {
+	return float64(getEvalContext(ctx).State.GetShortestConnection(a.color))
+}
+
+// ---------------------------
+// |     AttrTwoDistance     |
+// ---------------------------
+
+// AttrTwoDistance returns the minimal number of stones that a player needs to
+// connect the edges, measured with two-distances (see State.GetTwoDistance)
+type AttrTwoDistance struct {
+	color Color
+}
+
+// GetAttributeName returns the name of an attribute
+func (a AttrTwoDistance) GetAttributeName() string {
+	return "td_" + a.color.String()
+}
+
+// GetAttributeValue returns the value of an attribute
+func (a AttrTwoDistance) GetAttributeValue(ctx game.EvalContext) float64 {
+	return float64(getEvalContext(ctx).State.GetTwoDistance(a.color))
 }
 
 // getDistanceBetween returns the distance between points (x1, y1) and (x2, y2)
@@ -411,3 +432,59 @@ func getClosestCenterCoordinates(boardSize, x, y int) (int, int) {
 	}
 	return centerX, centerY
 }
+
+// -------------------------
+// |     AttrCellPlane     |
+// -------------------------
+
+// AttrCellPlane is a vector attribute with one value for each cell of a board
+// of a given size: 1 if the cell is occupied by a player and 0 otherwise.
+// Values are ordered by rows. If transposed is true, values are ordered by
+// columns, as in the state with swapped roles of players.
+type AttrCellPlane struct {
+	size       int
+	color      Color
+	transposed bool
+}
+
+// NewAttrCellPlanes returns planes of stones of both players on a board of a
+// given size, as pairs of attributes that are opposite to each other (see
+// AttributeSet.RegisterVector)
+func NewAttrCellPlanes(size int) [][2]game.VectorAttribute {
+	return [][2]game.VectorAttribute{
+		[2]game.VectorAttribute{AttrCellPlane{size, Red, false}, AttrCellPlane{size, Blue, true}},
+		[2]game.VectorAttribute{AttrCellPlane{size, Blue, false}, AttrCellPlane{size, Red, true}},
+	}
+}
+
+// GetAttributeName returns the name of an attribute
+func (a AttrCellPlane) GetAttributeName() string {
+	return "plane_" + a.color.String()
+}
+
+// GetAttributeLength returns the number of values of an attribute
+func (a AttrCellPlane) GetAttributeLength() int {
+	return a.size * a.size
+}
+
+// GetAttributeValues writes values of an attribute to values
+func (a AttrCellPlane) GetAttributeValues(ctx game.EvalContext, values []float64) {
+	state := getEvalContext(ctx).State
+	if state.GetSize() != a.size {
+		panic(fmt.Errorf("Attribute %s is defined for size %d, got a state of size %d",
+			a.GetAttributeName(), a.size, state.GetSize()))
+	}
+	for y := 0; y < a.size; y++ {
+		for x := 0; x < a.size; x++ {
+			v := 0.0
+			if state.getColorOn(byte(x), byte(y)) == a.color {
+				v = 1
+			}
+			if a.transposed {
+				values[x*a.size+y] = v
+			} else {
+				values[y*a.size+x] = v
+			}
+		}
+	}
+}
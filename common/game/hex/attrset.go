@@ -0,0 +1,221 @@
+package hex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RdecKa/0xAI/common/game"
+)
+
+// -----------------------
+// |     EvalContext     |
+// -----------------------
+
+// EvalContext is the context in which values of attributes of a hex state are
+// computed
+//	State is the evaluated state
+//	PatCount are counts of patterns in State (see PatternMatcher.Count)
+type EvalContext struct {
+	State    State
+	PatCount [2][]int
+}
+
+// GetState returns the evaluated state
+func (ctx *EvalContext) GetState() game.State {
+	return ctx.State
+}
+
+// getEvalContext returns the hex context of an attribute. It panics if the
+// context does not belong to a hex state.
+func getEvalContext(ctx game.EvalContext) *EvalContext {
+	hc, ok := ctx.(*EvalContext)
+	if !ok {
+		panic(fmt.Sprintf("Invalid evaluation context %T", ctx))
+	}
+	return hc
+}
+
+// patternAttribute is implemented by attributes whose values depend on counts
+// of patterns (see EvalContext.PatCount)
+type patternAttribute interface {
+	// getUsedPatterns returns indices of patterns whose counts the attribute
+	// needs, nil if it needs all of them
+	getUsedPatterns() []int
+}
+
+// ---------------------------
+// |     scalarAttribute     |
+// ---------------------------
+
+// scalarAttribute is a game.Attribute used as a VectorAttribute with a single
+// value
+type scalarAttribute struct {
+	game.Attribute
+}
+
+// GetAttributeLength returns the number of values of an attribute
+func (a scalarAttribute) GetAttributeLength() int {
+	return 1
+}
+
+// GetAttributeValues writes the value of an attribute to values
+func (a scalarAttribute) GetAttributeValues(ctx game.EvalContext, values []float64) {
+	values[0] = a.GetAttributeValue(ctx)
+}
+
+// ------------------------
+// |     AttributeSet     |
+// ------------------------
+
+// AttributeSet is an ordered set of attributes that describe a state. Each
+// attribute is registered under its name, together with its color-mirrored
+// counterpart: the attribute whose value in a state equals the value of the
+// registered attribute in the same state with swapped roles of the players.
+// Values of all attributes of a state form its feature vector, where a vector
+// attribute takes as many features as it has values.
+//	attrs are the registered attributes, in the order of registration
+//	mirrors are the counterparts of the registered attributes
+//	offsets are indices of the first features of the attributes
+//	names are names of all features
+//	index maps names of features to their indices
+type AttributeSet struct {
+	attrs   []game.VectorAttribute
+	mirrors []game.VectorAttribute
+	offsets []int
+	names   []string
+	index   map[string]int
+}
+
+// NewAttributeSet returns an empty AttributeSet
+func NewAttributeSet() *AttributeSet {
+	return &AttributeSet{
+		attrs:   make([]game.VectorAttribute, 0, 64),
+		mirrors: make([]game.VectorAttribute, 0, 64),
+		offsets: make([]int, 0, 64),
+		names:   make([]string, 0, 64),
+		index:   make(map[string]int),
+	}
+}
+
+// Register adds attribute a with its color-mirrored counterpart mirror to the
+// set. If mirror is nil, the attribute is the same for both players. It panics
+// if an attribute with the same name is already registered.
+func (as *AttributeSet) Register(a, mirror game.Attribute) {
+	var m game.VectorAttribute
+	if mirror != nil {
+		m = scalarAttribute{mirror}
+	}
+	as.register(scalarAttribute{a}, m, []string{a.GetAttributeName()})
+}
+
+// RegisterVector adds vector attribute a with its color-mirrored counterpart
+// mirror to the set. Its features are named <name>_<index>. If mirror is nil,
+// the attribute is the same for both players. It panics if a feature with the
+// same name is already registered or if a and mirror differ in length.
+func (as *AttributeSet) RegisterVector(a, mirror game.VectorAttribute) {
+	if mirror != nil && mirror.GetAttributeLength() != a.GetAttributeLength() {
+		panic(fmt.Sprintf("Attribute %s has %d values, its counterpart %s has %d", a.GetAttributeName(),
+			a.GetAttributeLength(), mirror.GetAttributeName(), mirror.GetAttributeLength()))
+	}
+	names := make([]string, a.GetAttributeLength())
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_%d", a.GetAttributeName(), i)
+	}
+	as.register(a, mirror, names)
+}
+
+// register adds attribute a with its counterpart mirror and names of its
+// features to the set
+func (as *AttributeSet) register(a, mirror game.VectorAttribute, names []string) {
+	for _, name := range names {
+		if _, ok := as.index[name]; ok {
+			panic(fmt.Sprintf("Attribute %s is already registered", name))
+		}
+	}
+	if mirror == nil {
+		mirror = a
+	}
+	as.offsets = append(as.offsets, len(as.names))
+	for _, name := range names {
+		as.index[name] = len(as.names)
+		as.names = append(as.names, name)
+	}
+	as.attrs = append(as.attrs, a)
+	as.mirrors = append(as.mirrors, mirror)
+}
+
+// Len returns the number of features of the attributes in the set
+func (as *AttributeSet) Len() int {
+	return len(as.names)
+}
+
+// GetNames returns names of all features, in order
+func (as *AttributeSet) GetNames() []string {
+	names := make([]string, len(as.names))
+	copy(names, as.names)
+	return names
+}
+
+// GetIndex returns the index of the feature with a given name. The second
+// return value is false if there is no such feature.
+func (as *AttributeSet) GetIndex(name string) (int, bool) {
+	i, ok := as.index[name]
+	return i, ok
+}
+
+// GetUsedPatterns returns indices of patterns whose counts are needed to
+// compute features with given names (see PatternCounts.GetFeatures), in
+// increasing order. It returns nil if all patterns are needed.
+func (as *AttributeSet) GetUsedPatterns(names []string) ([]int, error) {
+	used := make(map[int]bool)
+	for _, name := range names {
+		i, ok := as.index[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown attribute %s", name)
+		}
+		// Index of the attribute whose values include feature i
+		k := sort.Search(len(as.offsets), func(k int) bool { return as.offsets[k] > i }) - 1
+		var a interface{} = as.attrs[k]
+		if sa, ok := a.(scalarAttribute); ok {
+			a = sa.Attribute
+		}
+		pa, ok := a.(patternAttribute)
+		if !ok {
+			continue
+		}
+		patterns := pa.getUsedPatterns()
+		if patterns == nil {
+			return nil, nil
+		}
+		for _, p := range patterns {
+			used[p] = true
+		}
+	}
+	patterns := make([]int, 0, len(used))
+	for p := range used {
+		patterns = append(patterns, p)
+	}
+	sort.Ints(patterns)
+	return patterns, nil
+}
+
+// GetFeatures returns values of all attributes in the context ctx. If mirrored
+// is true, values are given for the state with swapped roles of the players.
+func (as *AttributeSet) GetFeatures(ctx *EvalContext, mirrored bool) []float64 {
+	attrs := as.attrs
+	if mirrored {
+		attrs = as.mirrors
+	}
+	features := make([]float64, len(as.names))
+	for i, a := range attrs {
+		a.GetAttributeValues(ctx, features[as.offsets[i]:as.offsets[i]+a.GetAttributeLength()])
+	}
+	return features
+}
+
+// GetHeaderCSV returns a line with names of all features, preceded by the name
+// of the output value, as in the header of a file with learning samples
+func (as *AttributeSet) GetHeaderCSV() string {
+	return "value," + strings.Join(as.names, ",") + "\n"
+}
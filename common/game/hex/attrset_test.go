@@ -0,0 +1,141 @@
+package hex
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSampleAttributes(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := pm.GetAttributes()
+	if n := as.Len(); n != 16+2*pm.GetNumPatterns() {
+		t.Fatalf("Expected %d attributes, got %d", 16+2*pm.GetNumPatterns(), n)
+	}
+	header := as.GetHeaderCSV()
+	if !strings.HasPrefix(header, "value,num_stones,lp,sdtc_r,") || !strings.HasSuffix(header, ",blue_p25\n") {
+		t.Fatalf("Unexpected header %s", header)
+	}
+	if i, ok := as.GetIndex("red_p0"); !ok || i != 16 {
+		t.Fatalf("Expected red_p0 on index 16, got %d (%v)", i, ok)
+	}
+	if _, ok := as.GetIndex("red_p26"); ok {
+		t.Fatal("Attribute red_p26 should not exist")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Registering an attribute twice should panic")
+		}
+	}()
+	as.Register(AttrNumStones, nil)
+}
+
+func TestUsedPatterns(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := pm.GetAttributes()
+	tests := []struct {
+		names    []string
+		expected string
+	}{
+		{[]string{}, "[]"},
+		{[]string{"lp", "sdtc_r"}, "[]"},
+		{[]string{"blue_p7", "num_stones", "red_p3", "red_p7"}, "[0 3 7]"},
+		{[]string{"red_p3", "occ_red_rows"}, "[]"},
+	}
+	for _, test := range tests {
+		patterns, err := as.GetUsedPatterns(test.names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(patterns) != test.expected {
+			t.Fatalf("Names %v: expected %s, got %v", test.names, test.expected, patterns)
+		}
+	}
+	// All patterns are needed for occupied rows and columns
+	if patterns, _ := as.GetUsedPatterns([]string{"red_p3", "occ_red_rows"}); patterns != nil {
+		t.Fatalf("Expected nil, got %v", patterns)
+	}
+	if _, err := as.GetUsedPatterns([]string{"red_p26"}); err == nil {
+		t.Fatal("Expected an error for an unknown attribute")
+	}
+}
+
+// TestMirroredFeatures checks that mirrored features of a state are the
+// features of the state with swapped roles of the players
+func TestMirroredFeatures(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		size := byte(r.Intn(6) + 3)
+		state := getRandomState(r, size, r.Intn(int(size)*int(size)))
+		swapped := NewState(size, state.GetLastPlayer())
+		for y := byte(0); y < size; y++ {
+			for x := byte(0); x < size; x++ {
+				if c := state.getColorOn(x, y); c != None {
+					swapped.setCell(y, x, c.Opponent())
+				}
+			}
+		}
+
+		mirrored := pm.attributes.GetFeatures(&EvalContext{*state, pm.Count(*state, nil)}, true)
+		expected := pm.attributes.GetFeatures(&EvalContext{*swapped, pm.Count(*swapped, nil)}, false)
+		if fmt.Sprint(mirrored) != fmt.Sprint(expected) {
+			t.Fatalf("State\n%v\nExpected %v, got %v", state, expected, mirrored)
+		}
+	}
+}
+
+/*
+r r .
+ . b .
+  . . r
+*/
+func TestVectorAttributes(t *testing.T) {
+	state := NewState(3, Red)
+	state.setCell(0, 0, Red)
+	state.setCell(1, 1, Blue)
+	state.setCell(2, 2, Red)
+	state.setCell(1, 0, Red)
+
+	as := NewAttributeSet()
+	as.Register(AttrNumStones, nil)
+	for _, pair := range NewAttrCellPlanes(3) {
+		as.RegisterVector(pair[0], pair[1])
+	}
+	if as.Len() != 19 {
+		t.Fatalf("Expected 19 features, got %d", as.Len())
+	}
+	if i, ok := as.GetIndex("plane_b_4"); !ok || i != 14 {
+		t.Fatalf("Expected plane_b_4 on index 14, got %d (%v)", i, ok)
+	}
+
+	ctx := &EvalContext{*state, [2][]int{[]int{3}, []int{1}}}
+	expected := "[4 1 1 0 0 0 0 0 0 1 0 0 0 0 1 0 0 0 0]"
+	if f := as.GetFeatures(ctx, false); fmt.Sprint(f) != expected {
+		t.Fatalf("Expected %s, got %v", expected, f)
+	}
+	expected = "[4 0 0 0 0 1 0 0 0 0 1 0 0 1 0 0 0 0 1]"
+	if f := as.GetFeatures(ctx, true); fmt.Sprint(f) != expected {
+		t.Fatalf("Expected mirrored %s, got %v", expected, f)
+	}
+
+	// Stones are counted on the grid if patterns are not counted
+	ctx = &EvalContext{State: *state}
+	if f := as.GetFeatures(ctx, false); f[0] != 4 {
+		t.Fatalf("Expected 4 stones without patterns, got %v", f[0])
+	}
+}
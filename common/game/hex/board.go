@@ -0,0 +1,103 @@
+package hex
+
+import (
+	"fmt"
+
+	"github.com/RdecKa/0xAI/common/game"
+)
+
+// -----------------
+// |     Board     |
+// -----------------
+
+// Board is a mutable State. Actions are played and undone in place, so no new
+// grid is allocated for each move.
+//	history is a stack of last actions of the board before each of the played
+//		actions, used to undo them
+//	connections holds groups of connected stones. It is updated with each
+//		action, so whether the game has ended is known without a search.
+//	numUnions is a stack of numbers of unions in connections before each of
+//		the played actions, used to undo them
+type Board struct {
+	State
+	history     []*Action
+	connections *unionFind
+	numUnions   []int
+}
+
+// NewBoard returns a new Board with the same position as State s. Changes on
+// the board do not affect s.
+func NewBoard(s *State) *Board {
+	b := &Board{s.Clone().(State), nil, nil, nil}
+	b.connections = newUnionFindFromState(&b.State)
+	return b
+}
+
+// Play performs Action action on the board
+func (b *Board) Play(action game.Action) {
+	a := action.(*Action)
+	last := b.lastAction
+	if a.c == last.c {
+		panic(fmt.Sprintf("Player cannot do two moves in a row! (last player: %s, current action: %s)", last.c, a))
+	}
+	if a.swap {
+		if !b.IsSwapPossible() {
+			panic(fmt.Sprintf("Swap is not possible (player %v's turn)!", a.c))
+		}
+		b.clearCell(last.x, last.y)
+		b.setCell(last.y, last.x, a.c)
+		b.lastAction = &Action{last.y, last.x, a.c, true}
+		b.numUnions = append(b.numUnions, len(b.connections.unions))
+		b.connections = newUnionFindFromState(&b.State)
+	} else {
+		if b.getColorOn(a.x, a.y) != None {
+			panic(fmt.Sprintf("Cell (%d, %d) already occupied (player %v's turn)!", a.x, a.y, a.c))
+		}
+		b.setCell(a.x, a.y, a.c)
+		b.lastAction = a
+		b.numUnions = append(b.numUnions, len(b.connections.unions))
+		b.connections.addStone(&b.State, a.x, a.y, a.c)
+	}
+	b.key ^= getZobristActionKey(last) ^ getZobristActionKey(b.lastAction)
+	b.history = append(b.history, last)
+}
+
+// Undo takes back the last action played on the board
+func (b *Board) Undo() {
+	n := len(b.history)
+	if n == 0 {
+		panic("No action to undo!")
+	}
+	last := b.history[n-1]
+	b.history = b.history[:n-1]
+
+	numUnions := b.numUnions[n-1]
+	b.numUnions = b.numUnions[:n-1]
+
+	a := b.lastAction
+	b.clearCell(a.x, a.y)
+	if a.swap {
+		b.setCell(last.x, last.y, last.c)
+		b.connections = newUnionFindFromState(&b.State)
+	} else {
+		b.connections.undoUnions(numUnions)
+	}
+	b.key ^= getZobristActionKey(a) ^ getZobristActionKey(last)
+	b.lastAction = last
+}
+
+// IsGoalState returns true if the game is decided and false otherwise (see
+// State.IsGoalState). Actual connections are looked up in the groups of stones
+// that are kept up to date, without a search.
+func (b *Board) IsGoalState(veryEnd bool) (bool, interface{}) {
+	if veryEnd {
+		return getConnection(b.connections, b.lastAction.c)
+	}
+	return b.State.IsGoalState(veryEnd)
+}
+
+// GetState returns a copy of the current position on the board
+func (b *Board) GetState() *State {
+	s := b.Clone().(State)
+	return &s
+}
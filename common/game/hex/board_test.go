@@ -0,0 +1,38 @@
+package hex
+
+import "testing"
+
+func TestPlayUndo(t *testing.T) {
+	state := NewState(5, Red)
+	board := NewBoard(state)
+	actions := []*Action{
+		NewAction(2, 1, Red),
+		NewSwapAction(Blue),
+		NewAction(3, 3, Red),
+		NewAction(0, 4, Blue),
+	}
+
+	states := []*State{state}
+	for _, a := range actions {
+		s := states[len(states)-1].GetSuccessorState(a).(State)
+		states = append(states, &s)
+		board.Play(a)
+		if !board.Same(&s) {
+			t.Fatalf("Board after %v differs from the successor state:\n%v\n%v", a, board, s)
+		}
+		if board.GetMapKey() != s.GetMapKey() {
+			t.Fatalf("Board after %v has key %x, successor state has key %x", a, board.GetMapKey(), s.GetMapKey())
+		}
+	}
+
+	for i := len(actions) - 1; i >= 0; i-- {
+		board.Undo()
+		if s := states[i]; !board.Same(s) || board.GetMapKey() != s.GetMapKey() {
+			t.Fatalf("Board after undoing %v differs from the previous state:\n%v\n%v", actions[i], board, s)
+		}
+	}
+
+	if r, b, _ := state.GetNumOfStones(); r+b != 0 {
+		t.Fatalf("Playing on a board should not change the original state:\n%v", state)
+	}
+}
@@ -0,0 +1,381 @@
+package hex
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// -------------------
+// |     Dataset     |
+// -------------------
+
+// Dataset is a collection of learning samples merged from several sample files.
+// Samples with the same board are merged into one sample, whose Q value is the
+// average of Q values weighted by visit counts.
+//	attributes are names of features of samples
+//	samples are merged samples in the order of their first occurrence
+//	index maps keys of boards to indices of samples
+//	canonical is true if symmetric boards are merged as well (see Canonical)
+//	numSearches is the number of searches read so far, used to give each
+//		search a unique ID
+//	numRecords is the number of records read so far
+type Dataset struct {
+	attributes  []string
+	samples     []*DatasetSample
+	index       map[uint64]int
+	canonical   bool
+	numSearches int
+	numRecords  int
+}
+
+// DatasetSample is a learning sample merged from one or more records
+//	SampleRecord holds the merged values. Search is the ID of the search in
+//		which the board was found first, unique within the dataset.
+//	Records is the number of merged records
+//	Stones is the number of stones on the board
+//	Test is true if the sample belongs to the test set (see Split)
+//	swapped is true if the canonical form of the board is color-swapped (see
+//		Symmetry.SwapsColors), always false if symmetric boards are not merged
+type DatasetSample struct {
+	SampleRecord
+	Records int
+	Stones  int
+	Test    bool
+	swapped bool
+}
+
+// NewDataset returns an empty Dataset. If canonical is true, boards that are
+// symmetric to each other (see Symmetry) are treated as the same board.
+func NewDataset(canonical bool) *Dataset {
+	return &Dataset{index: make(map[uint64]int), canonical: canonical}
+}
+
+// GetAttributes returns names of features of samples
+func (d *Dataset) GetAttributes() []string {
+	return d.attributes
+}
+
+// GetSamples returns all merged samples
+func (d *Dataset) GetSamples() []*DatasetSample {
+	return d.samples
+}
+
+// GetNumRecords returns the number of records that were read
+func (d *Dataset) GetNumRecords() int {
+	return d.numRecords
+}
+
+// ReadSamples reads samples from r and merges them into the dataset. Both the
+// sample format (see SampleReader) and the CSV format (see GenSample) are
+// accepted. Samples in the CSV format must be preceded by '# Board' comments
+// and have a visit count of 1. Search IDs are only unique within a file, so
+// they are renumbered. It returns the number of records that were read.
+func (d *Dataset) ReadSamples(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	var rr sampleRecordReader
+	var attributes []string
+	if b, err := br.Peek(1); err == nil && b[0] == '{' {
+		sr, err := NewSampleReader(br)
+		if err != nil {
+			return 0, err
+		}
+		rr, attributes = sr, sr.GetHeader().Attributes
+	} else {
+		cr, err := newCSVSampleReader(br)
+		if err != nil {
+			return 0, err
+		}
+		rr, attributes = cr, cr.attributes
+	}
+
+	if d.attributes == nil {
+		d.attributes = attributes
+	} else if strings.Join(d.attributes, ",") != strings.Join(attributes, ",") {
+		return 0, fmt.Errorf("Attributes of samples do not match attributes of the dataset")
+	}
+
+	searches := make(map[int]int) // Unique IDs of searches in the file
+	read := 0
+	for {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			return read, nil
+		} else if err != nil {
+			return read, err
+		}
+		id, ok := searches[rec.Search]
+		if !ok {
+			id = d.numSearches
+			searches[rec.Search] = id
+			d.numSearches++
+		}
+		rec.Search = id
+		if err := d.add(rec); err != nil {
+			return read, err
+		}
+		read++
+	}
+}
+
+// add merges a record into the dataset
+func (d *Dataset) add(rec *SampleRecord) error {
+	s, err := rec.GetState()
+	if err != nil {
+		return err
+	}
+	key, swapped := s.GetMapKey(), false
+	if d.canonical {
+		c, sym := s.Canonical()
+		key, swapped = c.GetMapKey(), sym.SwapsColors()
+	}
+	d.numRecords++
+
+	i, ok := d.index[key]
+	if !ok {
+		r, b, _ := s.GetNumOfStones()
+		d.index[key] = len(d.samples)
+		d.samples = append(d.samples, &DatasetSample{*rec, 1, r + b, false, swapped})
+		return nil
+	}
+
+	// Average of Q values weighted by visit counts. The Q value of a board
+	// with swapped colors has the opposite sign.
+	ds := d.samples[i]
+	q := rec.Q
+	if swapped != ds.swapped {
+		q = -q
+	}
+	n, rn := float64(ds.N), float64(rec.N)
+	if n+rn > 0 {
+		ds.Q = (ds.Q*n + q*rn) / (n + rn)
+	}
+	ds.N += rec.N
+	ds.Records++
+	return nil
+}
+
+// Split divides samples into a training and a test set. Whole searches are
+// put into the test set, so that boards from the same search are not in both
+// sets. Each sample belongs to the search in which its board was found first.
+// Approximately testFraction of searches are chosen randomly with a given
+// seed. It returns the training and the test set.
+func (d *Dataset) Split(testFraction float64, seed int64) ([]*DatasetSample, []*DatasetSample) {
+	ids := rand.New(rand.NewSource(seed)).Perm(d.numSearches)
+	numTest := int(testFraction*float64(d.numSearches) + 0.5)
+	test := make([]bool, d.numSearches)
+	for _, id := range ids[:numTest] {
+		test[id] = true
+	}
+
+	trainSet := make([]*DatasetSample, 0, len(d.samples))
+	testSet := make([]*DatasetSample, 0)
+	for _, ds := range d.samples {
+		ds.Test = test[ds.Search]
+		if ds.Test {
+			testSet = append(testSet, ds)
+		} else {
+			trainSet = append(trainSet, ds)
+		}
+	}
+	return trainSet, testSet
+}
+
+// WriteStats writes a table with statistics of samples for each number of
+// stones on the board: the number of samples, the number of samples in the
+// test set, the number of merged records, the average visit count, the average
+// Q value and the average absolute Q value. The average Q value is computed
+// only from samples that were not read as color-swapped records (see
+// SampleRecord.Mirrored), because the values of a board and its color-swapped
+// twin cancel out.
+func (d *Dataset) WriteStats(w io.Writer) error {
+	type stats struct {
+		samples, test, records, notMirrored int
+		sumN, sumQ, sumAbsQ                 float64
+	}
+	byStones := make(map[int]*stats)
+	for _, ds := range d.samples {
+		st, ok := byStones[ds.Stones]
+		if !ok {
+			st = &stats{}
+			byStones[ds.Stones] = st
+		}
+		st.samples++
+		if ds.Test {
+			st.test++
+		}
+		st.records += ds.Records
+		st.sumN += float64(ds.N)
+		if !ds.Mirrored {
+			st.notMirrored++
+			st.sumQ += ds.Q
+		}
+		st.sumAbsQ += math.Abs(ds.Q)
+	}
+	stones := make([]int, 0, len(byStones))
+	for s := range byStones {
+		stones = append(stones, s)
+	}
+	sort.Ints(stones)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "stones\tsamples\ttest\trecords\tavg n\tavg q\tavg |q|\t")
+	for _, s := range stones {
+		st := byStones[s]
+		avgQ := "-"
+		if st.notMirrored > 0 {
+			avgQ = fmt.Sprintf("%.4f", st.sumQ/float64(st.notMirrored))
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.1f\t%s\t%.4f\t\n", s, st.samples, st.test, st.records,
+			st.sumN/float64(st.samples), avgQ, st.sumAbsQ/float64(st.samples))
+	}
+	fmt.Fprintf(tw, "all\t%d\t\t%d\t\t\t\t\n", len(d.samples), d.numRecords)
+	return tw.Flush()
+}
+
+// WriteSampleFile writes samples with given attributes to w in the sample
+// format (see SampleReader)
+func WriteSampleFile(w io.Writer, attributes []string, samples []*DatasetSample) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(newSampleHeader(attributes)); err != nil {
+		return err
+	}
+	for _, ds := range samples {
+		if err := enc.Encode(ds.SampleRecord); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteSampleFileCSV writes samples with given attributes to w in the CSV
+// format (see GenSample). Each sample is preceded by a comment with its board.
+func WriteSampleFileCSV(w io.Writer, attributes []string, samples []*DatasetSample) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("value," + strings.Join(attributes, ",") + "\n")
+	for _, ds := range samples {
+		bw.WriteString("# Board " + ds.Board + "\n")
+		bw.WriteString(strconv.FormatFloat(ds.Q, 'g', -1, 64))
+		bw.WriteString(featuresToCSV(ds.Features))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ------------------------------
+// |     sampleRecordReader     |
+// ------------------------------
+
+// sampleRecordReader reads records of learning samples one by one, it returns
+// io.EOF when there are no more records
+type sampleRecordReader interface {
+	Read() (*SampleRecord, error)
+}
+
+// csvSampleReader reads records from a file in the CSV format (see GenSample)
+//	scanner reads lines of the file
+//	attributes are names of features, read from the header
+//	line is the number of the last line that was read
+//	search is the ID of the current search
+//	board is the state of the following sample, nil if it is unknown
+//	mirrored is true if the following sample is the color-swapped version of
+//		the previous one
+type csvSampleReader struct {
+	scanner    *bufio.Scanner
+	attributes []string
+	line       int
+	search     int
+	board      *State
+	mirrored   bool
+}
+
+// newCSVSampleReader returns a csvSampleReader that reads from r. The header is
+// read immediately.
+func newCSVSampleReader(r io.Reader) (*csvSampleReader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSampleLineLength)
+	cr := &csvSampleReader{scanner: scanner}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Missing header of the sample file")
+	}
+	cr.line++
+	names := strings.Split(strings.TrimSpace(scanner.Text()), ",")
+	if names[0] != "value" {
+		return nil, fmt.Errorf("Line 1: invalid header, expected 'value' as the first column")
+	}
+	cr.attributes = names[1:]
+	return cr, nil
+}
+
+// Read returns the next record of the file
+func (cr *csvSampleReader) Read() (*SampleRecord, error) {
+	for cr.scanner.Scan() {
+		cr.line++
+		line := strings.TrimSpace(cr.scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "# Search ID "):
+			id, err := strconv.Atoi(line[len("# Search ID "):])
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: invalid search ID: %s", cr.line, err)
+			}
+			cr.search = id
+			continue
+		case strings.HasPrefix(line, "# Board "):
+			s, err := ParseBoardNotation(line[len("# Board "):])
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %s", cr.line, err)
+			}
+			cr.board, cr.mirrored = s, false
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		if cr.board == nil {
+			return nil, fmt.Errorf("Line %d: sample without a '# Board' comment", cr.line)
+		}
+		values := strings.Split(line, ",")
+		if len(values) != len(cr.attributes)+1 {
+			return nil, fmt.Errorf("Line %d: sample has %d values, expected %d", cr.line, len(values), len(cr.attributes)+1)
+		}
+		rec := &SampleRecord{Search: cr.search, N: 1, Mirrored: cr.mirrored, Features: make([]float64, len(cr.attributes))}
+		for i, v := range values {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %s", cr.line, err)
+			}
+			if i == 0 {
+				rec.Q = f
+			} else {
+				rec.Features[i-1] = f
+			}
+		}
+		if cr.mirrored {
+			// The board of the sample that follows the color-swapped one is
+			// unknown
+			rec.Board = cr.board.Transform(SwapTranspose).GetBoardNotation()
+			cr.board = nil
+		} else {
+			rec.Board = cr.board.GetBoardNotation()
+			cr.mirrored = true
+		}
+		return rec, nil
+	}
+	if err := cr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
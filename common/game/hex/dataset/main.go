@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// dataset merges learning samples from MCTS runs, merges samples with the same
+// board or a symmetric one (unless -canonical=false), splits them into a
+// training and a test set by searches and prints statistics for each number of
+// stones. Arguments are files with learning samples or folders with such files
+// (sample_*.in).
+func main() {
+	// Read flags
+	pOutputFolder := flag.String("output", "./", "Output folder for train.in and test.in")
+	pTestFraction := flag.Float64("test", 0.1, "Fraction of searches in the test set")
+	pSeed := flag.Int64("seed", 4224, "Seed for the random split")
+	pFormat := flag.String("format", "csv", "Format of output files: csv or jsonl")
+	pCanonical := flag.Bool("canonical", true, "Merge symmetric boards as well, including color-swapped ones")
+	flag.Parse()
+	outputFolder, testFraction, seed, format := *pOutputFolder, *pTestFraction, *pSeed, *pFormat
+
+	if testFraction < 0 || testFraction > 1 {
+		fmt.Fprintln(os.Stderr, "Fraction of the test set must be between 0 and 1")
+		os.Exit(2)
+	}
+	write := hex.WriteSampleFileCSV
+	if format == "jsonl" {
+		write = hex.WriteSampleFile
+	} else if format != "csv" {
+		fmt.Fprintf(os.Stderr, "Unknown format '%s'\n", format)
+		os.Exit(2)
+	}
+
+	// Read samples
+	d := hex.NewDataset(*pCanonical)
+	for _, arg := range flag.Args() {
+		files := []string{arg}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			files, _ = filepath.Glob(filepath.Join(arg, "sample_*.in"))
+		}
+		for _, fileName := range files {
+			f, err := os.Open(fileName)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			n, err := d.ReadSamples(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", fileName, err)
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %d records\n", fileName, n)
+		}
+	}
+	if len(d.GetSamples()) == 0 {
+		fmt.Fprintln(os.Stderr, "No samples found")
+		os.Exit(1)
+	}
+
+	// Write samples
+	train, test := d.Split(testFraction, seed)
+	sets := []struct {
+		name    string
+		samples []*hex.DatasetSample
+	}{{"train.in", train}, {"test.in", test}}
+	for _, set := range sets {
+		err := writeFile(filepath.Join(outputFolder, set.name), func(w io.Writer) error {
+			return write(w, d.GetAttributes(), set.samples)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	fmt.Printf("%d records merged into %d samples (%d training, %d test)\n",
+		d.GetNumRecords(), len(d.GetSamples()), len(train), len(test))
+	d.WriteStats(os.Stdout)
+}
+
+// writeFile creates a file with a given name and writes to it with a function
+// write
+func writeFile(fileName string, write func(io.Writer) error) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
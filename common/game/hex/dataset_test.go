@@ -0,0 +1,162 @@
+package hex
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const datasetHeader = `{"format":"0xai-samples","version":1,"board":"notation","attributes":["a"]}` + "\n"
+
+func readDataset(t *testing.T, d *Dataset, inputs ...string) {
+	for _, in := range inputs {
+		if _, err := d.ReadSamples(strings.NewReader(in)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDatasetMerge(t *testing.T) {
+	// Both files have a search with ID 0, which are different searches
+	file1 := datasetHeader +
+		`{"search":0,"n":30,"q":1,"board":"r../.../... r","mirrored":false,"features":[1]}` + "\n" +
+		`{"search":0,"n":30,"q":-1,"board":"b../.../... b","mirrored":true,"features":[1]}` + "\n"
+	file2 := "value,a\n# Search ID 0\n# Board r../.../... r\n0,1\n0,1\n# Search ID 1\n# Board .../.r./... r\n0.5,1\n-0.5,1\n"
+
+	d := NewDataset(false)
+	readDataset(t, d, file1, file2)
+	if d.GetNumRecords() != 6 {
+		t.Fatalf("Expected 6 records, got %d", d.GetNumRecords())
+	}
+	samples := d.GetSamples()
+	if len(samples) != 4 {
+		t.Fatalf("Expected 4 samples, got %d", len(samples))
+	}
+	// Q is averaged with visit counts 30 and 1
+	if s := samples[0]; s.Records != 2 || s.N != 31 || math.Abs(s.Q-30.0/31) > 1e-9 || s.Search != 0 {
+		t.Fatalf("Wrong merged sample %v", s)
+	}
+	if s := samples[2]; s.Board != ".../.r./... r" || s.Search != 2 || s.Stones != 1 {
+		t.Fatalf("Wrong sample %v", s)
+	}
+	if s := samples[3]; s.Board != ".../.b./... b" || !s.Mirrored || s.Q != -0.5 {
+		t.Fatalf("Wrong color-swapped sample %v", s)
+	}
+
+	// The average Q value leaves out color-swapped samples
+	var b bytes.Buffer
+	if err := d.WriteStats(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || strings.Join(strings.Fields(lines[1]), " ") != "1 4 0 6 16.0 0.7339 0.7339" {
+		t.Fatalf("Unexpected statistics:\n%s", b.String())
+	}
+}
+
+func TestDatasetCanonical(t *testing.T) {
+	// The second board is the first one rotated, the third one is the first
+	// one with swapped colors
+	in := datasetHeader +
+		`{"search":0,"n":1,"q":1,"board":"r../.../... r","mirrored":false,"features":[1]}` + "\n" +
+		`{"search":0,"n":1,"q":0.5,"board":".../.../..r r","mirrored":false,"features":[1]}` + "\n" +
+		`{"search":1,"n":2,"q":-1,"board":"b../.../... b","mirrored":false,"features":[1]}` + "\n"
+
+	d := NewDataset(false)
+	readDataset(t, d, in)
+	if len(d.GetSamples()) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(d.GetSamples()))
+	}
+
+	d = NewDataset(true)
+	readDataset(t, d, in)
+	samples := d.GetSamples()
+	if len(samples) != 1 {
+		t.Fatalf("Expected 1 sample, got %d", len(samples))
+	}
+	if s := samples[0]; s.N != 4 || math.Abs(s.Q-(1+0.5+2)/4) > 1e-9 || s.Board != "r../.../... r" {
+		t.Fatalf("Wrong merged sample %v", s)
+	}
+}
+
+func TestDatasetSplit(t *testing.T) {
+	in := datasetHeader
+	for i := 0; i < 10; i++ {
+		board := []byte("..../..../..../.... r")
+		board[i+i/4] = 'r'
+		in += `{"search":` + string(rune('0'+i)) + `,"n":1,"q":0,"board":"` + string(board) + `","mirrored":false,"features":[1]}` + "\n"
+	}
+	d := NewDataset(false)
+	readDataset(t, d, in, in)
+	train, test := d.Split(0.3, 1)
+	if len(train) != 7 || len(test) != 3 {
+		t.Fatalf("Expected 7 training and 3 test samples, got %d and %d", len(train), len(test))
+	}
+	for _, s := range test {
+		if !s.Test || s.Records != 2 {
+			t.Fatalf("Wrong test sample %v", s)
+		}
+	}
+
+	// The same seed gives the same split
+	_, test2 := d.Split(0.3, 1)
+	for i := range test {
+		if test[i] != test2[i] {
+			t.Fatalf("Splits with the same seed differ")
+		}
+	}
+
+	var b bytes.Buffer
+	if err := d.WriteStats(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || strings.Join(strings.Fields(lines[1]), " ") != "1 10 3 20 2.0 0.0000 0.0000" {
+		t.Fatalf("Unexpected statistics:\n%s", b.String())
+	}
+}
+
+func TestDatasetWrite(t *testing.T) {
+	in := datasetHeader +
+		`{"search":0,"n":3,"q":0.25,"board":"r../.../... r","mirrored":false,"features":[1.5]}` + "\n"
+	d := NewDataset(false)
+	readDataset(t, d, in)
+
+	var b bytes.Buffer
+	if err := WriteSampleFileCSV(&b, d.GetAttributes(), d.GetSamples()); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "value,a\n# Board r../.../... r\n0.25,1.5\n" {
+		t.Fatalf("Unexpected CSV file:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteSampleFile(&b, d.GetAttributes(), d.GetSamples()); err != nil {
+		t.Fatal(err)
+	}
+	d2 := NewDataset(false)
+	readDataset(t, d2, b.String())
+	if s := d2.GetSamples(); len(s) != 1 || s[0].SampleRecord.Q != 0.25 || s[0].N != 3 {
+		t.Fatalf("Unexpected samples after writing and reading %v", s)
+	}
+}
+
+func TestDatasetInvalid(t *testing.T) {
+	inputs := []string{
+		"value,a\n0.5,1\n",
+		"value,a\n# Board r../.../... r\n0.5,1,2\n",
+		"a,b\n",
+	}
+	for _, in := range inputs {
+		if _, err := NewDataset(false).ReadSamples(strings.NewReader(in)); err == nil {
+			t.Fatalf("Expected an error for input\n%s", in)
+		}
+	}
+
+	d := NewDataset(false)
+	readDataset(t, d, datasetHeader)
+	if _, err := d.ReadSamples(strings.NewReader("value,b\n")); err == nil {
+		t.Fatalf("Expected an error for different attributes")
+	}
+}
@@ -0,0 +1,221 @@
+package hex
+
+// This file implements distances between the edges of a player, measured in
+// the number of stones that the player still needs to connect the edges.
+// Connected stones of the player are transparent: empty cells next to the
+// same group of stones are neighbours, and so are empty cells next to a group
+// that touches an edge and the edge itself. Stones of the opponent block.
+//
+// The distance of an empty cell to an edge is 1 if the cell is next to the
+// edge. Otherwise it is one more than the distance of its closest neighbour
+// (shortest path) or one more than the distance of its second closest
+// neighbour (two-distance, as in Hexy and Six). The two-distance assumes that
+// the opponent always blocks the best neighbour, so it favours connections
+// with several ways to continue.
+
+// GetShortestConnection returns the minimal number of stones that player c
+// needs to connect the edges. It returns 0 if the edges are connected and
+// getMaxDistance if the opponent has connected the edges.
+func (s State) GetShortestConnection(c Color) int {
+	return s.getConnectionDistance(c, 1)
+}
+
+// GetTwoDistance returns the minimal number of stones that player c needs to
+// connect the edges, where distances to edges are two-distances. It returns 0
+// if the edges are connected and getMaxDistance if the opponent can prevent
+// the connection by blocking single cells.
+func (s State) GetTwoDistance(c Color) int {
+	return s.getConnectionDistance(c, 2)
+}
+
+// GetCellPotentials returns the sum of two-distances to both edges of player c
+// for each cell, indexed by y*size + x. The lower the potential, the more
+// important the cell is for the connection. Cells that are not empty or that
+// cannot be part of a connection have potential 2*getMaxDistance.
+func (s State) GetCellPotentials(c Color) []int {
+	en := s.getEmptyNetwork(c)
+	first, second := getPlayerEdges(c)
+	d1, d2 := en.getDistances(first, 2), en.getDistances(second, 2)
+	for i := range d1 {
+		d1[i] += d2[i]
+	}
+	return d1
+}
+
+// getConnectionDistance returns the minimal number of stones that player c
+// needs to connect the edges, where the distance of a cell is one more than
+// the distance of its rank-th closest neighbour
+func (s State) getConnectionDistance(c Color, rank int) int {
+	en := s.getEmptyNetwork(c)
+	first, second := getPlayerEdges(c)
+	if en.u.find(en.u.getEdgeNode(first)) == en.u.find(en.u.getEdgeNode(second)) {
+		return 0
+	}
+	d1, d2 := en.getDistances(first, rank), en.getDistances(second, rank)
+	best := getMaxDistance(en.size)
+	for i := range d1 {
+		// The cell itself is counted in both distances
+		if d := d1[i] + d2[i] - 1; d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// getPlayerEdges returns both edges of player c (see edgeTop)
+func getPlayerEdges(c Color) (int, int) {
+	if c == Red {
+		return edgeTop, edgeBottom
+	}
+	return edgeLeft, edgeRight
+}
+
+// getMaxDistance returns the distance of cells that cannot be connected to an
+// edge on a board of a given size, which is higher than any other distance
+func getMaxDistance(size int) int {
+	return size * size
+}
+
+// ------------------------
+// |     emptyNetwork     |
+// ------------------------
+
+// emptyNetwork holds empty cells of a state and their neighbours for one
+// player (see the description of distances above)
+//	size is a length of the grid
+//	u is a unionFind with stones of the player
+//	empty is true for empty cells, indexed by y*size + x
+//	neighbours[i] are indices of neighbours of empty cell i, including cells
+//		next to the same groups of stones
+//	groups maps roots of groups of stones in u to indices of empty cells next
+//		to them
+type emptyNetwork struct {
+	size       int
+	u          *unionFind
+	empty      []bool
+	neighbours [][]int
+	groups     map[int32][]int
+}
+
+// getEmptyNetwork returns the network of empty cells of State s for player c
+func (s State) getEmptyNetwork(c Color) *emptyNetwork {
+	size := int(s.size)
+	en := &emptyNetwork{
+		size:       size,
+		u:          newUnionFind(s.size),
+		empty:      make([]bool, size*size),
+		neighbours: make([][]int, size*size),
+		groups:     make(map[int32][]int),
+	}
+	en.u.addStones(&s, c)
+	for i := range en.empty {
+		en.empty[i] = s.getColorOn(byte(i%size), byte(i/size)) == None
+	}
+
+	// Roots of groups next to each empty cell
+	roots := make([][]int32, size*size)
+	for i, e := range en.empty {
+		if !e {
+			continue
+		}
+		x, y := i%size, i/size
+		for _, n := range neighbours {
+			nx, ny := x+n[0], y+n[1]
+			if !s.IsCellValid(nx, ny) {
+				continue
+			}
+			if j := ny*size + nx; en.empty[j] {
+				en.neighbours[i] = append(en.neighbours[i], j)
+			} else if s.getColorOn(byte(nx), byte(ny)) == c {
+				root := en.u.find(en.u.getNode(byte(nx), byte(ny)))
+				if g := en.groups[root]; len(g) == 0 || g[len(g)-1] != i {
+					en.groups[root] = append(g, i)
+					roots[i] = append(roots[i], root)
+				}
+			}
+		}
+	}
+
+	// Each neighbour is included once
+	added := make([]int, size*size) // The last cell that a cell was added to, plus 1
+	for i, r := range roots {
+		if len(r) == 0 {
+			continue
+		}
+		added[i] = i + 1
+		for _, j := range en.neighbours[i] {
+			added[j] = i + 1
+		}
+		for _, root := range r {
+			for _, j := range en.groups[root] {
+				if added[j] != i+1 {
+					added[j] = i + 1
+					en.neighbours[i] = append(en.neighbours[i], j)
+				}
+			}
+		}
+	}
+	return en
+}
+
+// isNextToEdge returns true if empty cell i is directly next to edge (see
+// edgeTop)
+func (en *emptyNetwork) isNextToEdge(i, edge int) bool {
+	x, y := i%en.size, i/en.size
+	switch edge {
+	case edgeTop:
+		return y == 0
+	case edgeBottom:
+		return y == en.size-1
+	case edgeLeft:
+		return x == 0
+	default:
+		return x == en.size-1
+	}
+}
+
+// getDistances returns distances of all cells to edge (see edgeTop), where
+// the distance of a cell is one more than the distance of its rank-th closest
+// neighbour. Cells that are not empty or are not connected to the edge have
+// distance getMaxDistance.
+func (en *emptyNetwork) getDistances(edge, rank int) []int {
+	n := en.size * en.size
+	maxDistance := getMaxDistance(en.size)
+	dist := make([]int, n)
+	for i := range dist {
+		dist[i] = maxDistance
+	}
+
+	// Cells at distance 1
+	level := make([]int, 0, n)
+	for _, i := range en.groups[en.u.find(en.u.getEdgeNode(edge))] {
+		dist[i] = 1
+		level = append(level, i)
+	}
+	for i, e := range en.empty {
+		if e && dist[i] != 1 && en.isNextToEdge(i, edge) {
+			dist[i] = 1
+			level = append(level, i)
+		}
+	}
+
+	// Cells at distance d+1 have at least rank neighbours at distance d or
+	// less
+	counts := make([]int, n)
+	for d := 1; len(level) > 0; d++ {
+		next := make([]int, 0, len(level))
+		for _, i := range level {
+			for _, j := range en.neighbours[i] {
+				if dist[j] != maxDistance {
+					continue
+				}
+				if counts[j]++; counts[j] == rank {
+					dist[j] = d + 1
+					next = append(next, j)
+				}
+			}
+		}
+		level = next
+	}
+	return dist
+}
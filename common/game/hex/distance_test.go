@@ -0,0 +1,85 @@
+package hex
+
+import (
+	"testing"
+)
+
+func testDistances(t *testing.T, s *State, c Color, shortest, twoDistance int) {
+	if d := s.GetShortestConnection(c); d != shortest {
+		t.Fatalf("Expected shortest connection %d for %v, got %d\n%v", shortest, c, d, s)
+	}
+	if d := s.GetTwoDistance(c); d != twoDistance {
+		t.Fatalf("Expected two-distance %d for %v, got %d\n%v", twoDistance, c, d, s)
+	}
+}
+
+func TestDistancesEmpty(t *testing.T) {
+	for size := byte(1); size < 8; size++ {
+		s := NewState(size, Red)
+		testDistances(t, s, Red, int(size), int(size))
+		testDistances(t, s, Blue, int(size), int(size))
+	}
+}
+
+/*
+. . .
+ . r .
+  . . .
+*/
+func TestDistancesStone(t *testing.T) {
+	s := NewState(3, Red)
+	s.setCell(1, 1, Red)
+	testDistances(t, s, Red, 2, 2)
+	// The red player can block each connection of the blue player
+	testDistances(t, s, Blue, 3, 9)
+
+	s.setCell(1, 0, Red)
+	s.setCell(0, 2, Red)
+	testDistances(t, s, Red, 0, 0)
+	testDistances(t, s, Blue, 9, 9)
+}
+
+/*
+. . b
+ b . b
+  . . .
+*/
+func TestDistancesBlocked(t *testing.T) {
+	// The only connection of the red player goes through two cells that the
+	// blue player can block
+	s := NewState(3, Red)
+	s.setCell(2, 0, Blue)
+	s.setCell(0, 1, Blue)
+	s.setCell(2, 1, Blue)
+	testDistances(t, s, Red, 3, 9)
+	testDistances(t, s, Blue, 1, 1)
+}
+
+/*
+. . . . .
+ . . . . .
+  . . r . .
+   . . . . .
+    . . . . .
+*/
+func TestCellPotentials(t *testing.T) {
+	s := NewState(5, Red)
+	s.setCell(2, 2, Red)
+	p := s.GetCellPotentials(Red)
+	if p[2*5+2] != 2*getMaxDistance(5) {
+		t.Fatalf("Expected potential %d of an occupied cell, got %d", 2*getMaxDistance(5), p[2*5+2])
+	}
+	// The cells next to the stone towards the edges are among the most
+	// important for the red player
+	best := p[0]
+	for i := range p {
+		if p[i] < best {
+			best = p[i]
+		}
+	}
+	for _, c := range [][2]int{{2, 1}, {3, 1}, {1, 3}, {2, 3}} {
+		if p[c[1]*5+c[0]] != best {
+			t.Fatalf("Expected the lowest potential %d on (%d, %d), got potentials %v", best, c[0], c[1], p)
+		}
+	}
+}
@@ -2,48 +2,62 @@ package hex
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
-// GenSample returns a string representation of two learning samples in format:
-// (output, attributes...)
-// First learning sample is a representation of a given State s, the second is a
-// representation of the same state but with reversed roles of red and blue
-// player
-func (s State) GenSample(q float64, gridChan chan []uint32, patChan chan []int, resultChan chan [2][]int) string {
-	gridChan <- s.GetCopyGrid()
-	patChan <- nil
+// sampleRow is a learning sample of a state
+//	q is the value of the state for the red player
+//	features are values of attributes of the state
+//	mirrored is true if the sample is the color-swapped version of the state
+type sampleRow struct {
+	q        float64
+	features []float64
+	mirrored bool
+}
 
+// genSampleRows returns two learning samples of State s, which has value q
+// from the perspective of the player who made the last move. The first sample
+// represents State s, the second one the same state but with reversed roles of
+// red and blue player. Patterns in State s are counted by PatternMatcher pm
+// and attributes are given by pm.GetAttributes().
+func (s State) genSampleRows(q float64, pm *PatternMatcher) [2]sampleRow {
 	if s.lastAction.c == Blue {
 		// Always store the Q value for the red player
 		q = -q
 	}
+	ctx := &EvalContext{s, pm.Count(s, nil)}
+	return [2]sampleRow{
+		{q, pm.attributes.GetFeatures(ctx, false), false},
+		{-q, pm.attributes.GetFeatures(ctx, true), true},
+	}
+}
 
-	// o1 <- State s
-	// o2 <- inversed State s
-	o1 := fmt.Sprintf("%f", q)
-	o2 := fmt.Sprintf("%f", -q)
-
-	patCount := <-resultChan
-	args := &[]interface{}{s, patCount}
-	for _, attrPair := range GenSamAttributes {
-		aVal := attrPair[0].GetAttributeValue(args)
-		o1 += fmt.Sprintf(",%v", aVal)
-
-		if attrPair[1] != nil {
-			aVal = attrPair[1].GetAttributeValue(args)
-		}
-		o2 += fmt.Sprintf(",%v", aVal)
+// GenSample returns a string representation of two learning samples in format:
+// (output, attributes...)
+// The samples are given by genSampleRows.
+func (s State) GenSample(q float64, pm *PatternMatcher) string {
+	var b strings.Builder
+	for _, row := range s.genSampleRows(q, pm) {
+		b.WriteString(fmt.Sprintf("%f", row.q) + featuresToCSV(row.features) + "\n")
 	}
-	return o1 + "\n" + o2 + "\n"
+	return b.String()
 }
 
-// GetHeaderCSV returns a string consisting of attribute names.
-func GetHeaderCSV() string {
-	o := "value"
-	for _, attrPair := range GenSamAttributes {
-		o += ","
-		o += attrPair[0].GetAttributeName()
+// featuresToCSV returns values of attributes, each preceded by a comma.
+// Integer values are written without a decimal point.
+func featuresToCSV(features []float64) string {
+	var b strings.Builder
+	for _, f := range features {
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
 	}
-	o += "\n"
-	return o
+	return b.String()
+}
+
+// GetHeaderCSV returns a string consisting of attribute names of learning
+// samples generated with PatternMatcher pm. See SampleWriter for a format that
+// also stores states, visit counts and search IDs.
+func GetHeaderCSV(pm *PatternMatcher) string {
+	return pm.attributes.GetHeaderCSV()
 }
@@ -14,10 +14,12 @@ func test(t *testing.T, size byte, actions []*Action, expectedRed, expectedBlue
 
 	q := 0.5
 
-	gridChan, patChan, stopChan, resultChan := CreatePatChecker("patterns.txt")
-	defer func() { stopChan <- struct{}{} }()
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	sam := strings.Trim(state.GenSample(q, gridChan, patChan, resultChan), "\n")
+	sam := strings.Trim(state.GenSample(q, pm), "\n")
 
 	expected := expectedRed + "\n" + expectedBlue
 
@@ -39,10 +41,10 @@ func TestGenSample1(t *testing.T) {
 		NewAction(1, 2, Red),
 		NewAction(4, 1, Blue),
 	}
-	redP := "1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	blueP := "1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "-0.500000,2,1,1,1,11,10,1,1,1,1," + redP + "," + blueP
-	expectedBlue := "0.500000,2,0,1,1,10,11,1,1,1,1," + blueP + "," + redP
+	redP := "1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	blueP := "1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1,0"
+	expectedRed := "-0.500000,2,1,1,1,11,10,1,1,1,1,1.465492,1.404225,5,5,6,6," + redP + "," + blueP
+	expectedBlue := "0.500000,2,0,1,1,10,11,1,1,1,1,1.404225,1.465492,5,5,6,6," + blueP + "," + redP
 
 	test(t, 6, actions, expectedRed, expectedBlue)
 }
@@ -68,12 +70,12 @@ func TestGenSample2(t *testing.T) {
 		NewAction(5, 3, Red),
 		NewAction(1, 5, Blue),
 	}
-	rr := "4,0,0,0,1,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0,0" // Red attributes
-	rt := "4,0,0,0,1,0,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0" // Red attributes transposed
-	bb := "4,2,1,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0" // Blue attributes
-	bt := "4,1,1,2,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0" // Blue attributes transposed
-	expectedRed := "-0.500000,8,1,7,5,17,23,4,3,5,3," + rr + "," + bb
-	expectedBlue := "0.500000,8,0,5,7,23,17,3,5,3,4," + bt + "," + rt
+	rr := "4,0,0,0,1,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0,0,0,0" // Red attributes
+	rt := "4,0,0,0,1,0,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0,0,0" // Red attributes transposed
+	bb := "4,2,1,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1,0" // Blue attributes
+	bt := "4,1,1,2,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1,0" // Blue attributes transposed
+	expectedRed := "-0.500000,8,1,7,5,17,23,4,3,5,3,1.302072,1.458554,6,6,6,7," + rr + "," + bb
+	expectedBlue := "0.500000,8,0,5,7,23,17,3,5,3,4,1.458554,1.302072,6,6,7,6," + bt + "," + rt
 
 	test(t, 8, actions, expectedRed, expectedBlue)
 }
@@ -100,12 +102,12 @@ func TestGenSample3(t *testing.T) {
 		NewAction(6, 2, Blue),
 		NewAction(3, 4, Red),
 	}
-	rr := "5,0,0,0,0,0,0,0,0,0,0,1,1,2,0,0,0,0,2,1,0,0,0,0"
-	rt := "5,0,0,0,0,0,0,0,0,0,0,1,1,2,0,0,0,0,1,2,0,0,0,0"
-	bb := "4,0,1,0,0,0,0,1,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bt := "4,0,1,0,0,1,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "0.500000,9,0,4,5,16,20,4,4,3,4," + rr + "," + bb
-	expectedBlue := "-0.500000,9,1,5,4,20,16,4,3,4,4," + bt + "," + rt
+	rr := "5,0,0,0,0,0,0,0,0,0,0,1,1,2,0,0,0,0,2,1,0,0,0,0,1,0"
+	rt := "5,0,0,0,0,0,0,0,0,0,0,1,1,2,0,0,0,0,1,2,0,0,0,0,1,0"
+	bb := "4,0,1,0,0,0,0,1,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,2,0"
+	bt := "4,0,1,0,0,1,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,2,0"
+	expectedRed := "0.500000,9,0,4,5,16,20,4,4,3,4,1.148722,1.804669,4,7,4,9," + rr + "," + bb
+	expectedBlue := "-0.500000,9,1,5,4,20,16,4,3,4,4,1.804669,1.148722,7,4,9,4," + bt + "," + rt
 
 	test(t, 8, actions, expectedRed, expectedBlue)
 }
@@ -133,12 +135,12 @@ func TestGenSample4(t *testing.T) {
 		NewAction(3, 4, Red),
 		NewAction(0, 5, Blue),
 	}
-	rr := "5,0,0,0,0,0,0,2,0,0,0,0,1,1,0,0,0,0,0,0,0,0,0,0"
-	rt := "5,0,0,0,0,2,0,0,0,0,0,1,0,1,0,0,0,0,0,0,0,0,0,0"
-	bb := "5,1,1,0,0,0,0,2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bt := "5,0,1,1,0,2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "-0.500000,10,1,5,8,20,19,5,4,4,5," + rr + "," + bb
-	expectedBlue := "0.500000,10,0,8,5,19,20,5,4,4,5," + bt + "," + rt
+	rr := "5,0,0,0,0,0,0,2,0,0,0,0,1,1,0,0,0,0,0,0,0,0,0,0,1,0"
+	rt := "5,0,0,0,0,2,0,0,0,0,0,1,0,1,0,0,0,0,0,0,0,0,0,0,1,0"
+	bb := "5,1,1,0,0,0,0,2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,2,0"
+	bt := "5,0,1,1,0,2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,2,0"
+	expectedRed := "-0.500000,10,1,5,8,20,19,5,4,4,5,1.305874,1.591818,5,6,5,9," + rr + "," + bb
+	expectedBlue := "0.500000,10,0,8,5,19,20,5,4,4,5,1.591818,1.305874,6,5,9,5," + bt + "," + rt
 
 	test(t, 8, actions, expectedRed, expectedBlue)
 }
@@ -158,12 +160,12 @@ func TestGenSample5(t *testing.T) {
 		NewAction(2, 1, Blue),
 		NewAction(2, 2, Red),
 	}
-	rr := "3,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	rt := "3,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bb := "2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bt := "2,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "0.500000,5,0,4,3,12,8,4,3,2,3," + rr + "," + bb
-	expectedBlue := "-0.500000,5,1,3,4,8,12,3,2,3,4," + bt + "," + rt
+	rr := "3,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	rt := "3,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	bb := "2,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	bt := "2,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	expectedRed := "0.500000,5,0,4,3,12,8,4,3,2,3,1.21558,1.835645,2,3,2,3," + rr + "," + bb
+	expectedBlue := "-0.500000,5,1,3,4,8,12,3,2,3,4,1.835645,1.21558,3,2,3,2," + bt + "," + rt
 
 	test(t, 5, actions, expectedRed, expectedBlue)
 }
@@ -184,12 +186,12 @@ func TestGenSample6(t *testing.T) {
 		NewAction(2, 2, Red),
 		NewAction(4, 4, Blue),
 	}
-	rr := "3,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	rt := "3,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bb := "3,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bt := "3,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "-0.500000,6,1,4,7,12,11,4,3,3,4," + rr + "," + bb
-	expectedBlue := "0.500000,6,0,7,4,11,12,4,3,3,4," + bt + "," + rt
+	rr := "3,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	rt := "3,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	bb := "3,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1"
+	bt := "3,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1"
+	expectedRed := "-0.500000,6,1,4,7,12,11,4,3,3,4,1.235543,1.796837,2,3,2,3," + rr + "," + bb
+	expectedBlue := "0.500000,6,0,7,4,11,12,4,3,3,4,1.796837,1.235543,3,2,3,2," + bt + "," + rt
 
 	test(t, 5, actions, expectedRed, expectedBlue)
 }
@@ -217,12 +219,12 @@ func TestGenSample7(t *testing.T) {
 		NewAction(4, 4, Blue),
 		NewAction(0, 10, Red),
 	}
-	rr := "4,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	rt := "4,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bb := "3,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	bt := "3,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
-	expectedRed := "0.500000,7,0,23,17,21,20,5,4,3,4," + rr + "," + bb
-	expectedBlue := "-0.500000,7,1,17,23,20,21,4,3,4,5," + bt + "," + rt
+	rr := "4,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1"
+	rt := "4,0,0,1,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1"
+	bb := "3,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	bt := "3,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"
+	expectedRed := "0.500000,7,0,23,17,21,20,5,4,3,4,1.22841,1.387045,7,9,11,12," + rr + "," + bb
+	expectedBlue := "-0.500000,7,1,17,23,20,21,4,3,4,5,1.387045,1.22841,9,7,12,11," + bt + "," + rt
 
 	test(t, 11, actions, expectedRed, expectedBlue)
 }
@@ -273,12 +275,47 @@ func TestGenSample8(t *testing.T) {
 		NewAction(6, 5, Red),
 		NewAction(9, 6, Blue),
 	}
-	rr := "15,1,0,1,0,1,0,0,0,0,0,6,6,6,0,0,0,2,2,2,7,1,1,1"
-	rt := "15,1,0,1,0,0,0,1,0,0,0,6,6,6,0,0,0,2,2,2,7,1,1,1"
-	bb := "15,0,0,0,0,0,0,0,0,0,0,10,11,9,6,7,4,16,11,12,16,7,6,5"
-	bt := "15,0,0,0,0,0,0,0,0,0,0,11,10,9,7,6,4,16,12,11,16,7,5,6"
-	expectedRed := "-0.500000,30,1,45,55,44,16,6,7,5,4," + rr + "," + bb
-	expectedBlue := "0.500000,30,0,55,45,16,44,4,5,7,6," + bt + "," + rt
+	rr := "15,1,0,1,0,1,0,0,0,0,0,6,6,6,0,0,0,2,2,2,7,1,1,1,3,0"
+	rt := "15,1,0,1,0,0,0,1,0,0,0,6,6,6,0,0,0,2,2,2,7,1,1,1,3,0"
+	bb := "15,0,0,0,0,0,0,0,0,0,0,10,11,9,6,7,4,16,11,12,16,7,6,5,1,0"
+	bt := "15,0,0,0,0,0,0,0,0,0,0,11,10,9,7,6,4,16,12,11,16,7,5,6,1,0"
+	expectedRed := "-0.500000,30,1,45,55,44,16,6,7,5,4,1.265304,1.476553,6,7,7,9," + rr + "," + bb
+	expectedBlue := "0.500000,30,0,55,45,16,44,4,5,7,6,1.476553,1.265304,7,6,9,7," + bt + "," + rt
 
 	test(t, 11, actions, expectedRed, expectedBlue)
 }
+
+/*
+r b .
+ . . .
+  . . .
+*/
+func TestGenSampleCellPlanes(t *testing.T) {
+	state := NewState(3, Red)
+	for _, a := range []*Action{NewAction(0, 0, Red), NewAction(1, 0, Blue)} {
+		s := state.GetSuccessorState(a).(State)
+		state = &s
+	}
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ppm := pm.WithCellPlanes(3)
+	if ppm.GetAttributes().Len() != pm.GetAttributes().Len()+18 {
+		t.Fatalf("Expected 18 more attributes, got %d", ppm.GetAttributes().Len()-pm.GetAttributes().Len())
+	}
+	if !strings.HasSuffix(strings.TrimSpace(GetHeaderCSV(ppm)), ",plane_b_7,plane_b_8") {
+		t.Fatalf("Planes are missing in the header %s", GetHeaderCSV(ppm))
+	}
+
+	// The red stone is in cell 0 and the blue one in cell 1. In the mirrored
+	// sample, the grid is transposed and the blue stone becomes a red stone in
+	// cell 3.
+	lines := strings.Split(strings.Trim(state.GenSample(0.5, ppm), "\n"), "\n")
+	expected := []string{",1,0,0,0,0,0,0,0,0,0,1,0,0,0,0,0,0,0", ",0,0,0,1,0,0,0,0,0,1,0,0,0,0,0,0,0,0"}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, expected[i]) {
+			t.Fatalf("Sample %d\n%s\nshould end with planes %s", i, line, expected[i])
+		}
+	}
+}
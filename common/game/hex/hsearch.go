@@ -0,0 +1,444 @@
+package hex
+
+import "math/bits"
+
+// This file implements H-search (V. Anshelevich, A hierarchical approach to
+// computer Hex). It finds virtual connections (VCs) of one player between
+// groups of stones, empty cells and the player's edges. A VC is a connection
+// that cannot be prevented even if the opponent moves first. A semi virtual
+// connection (semi-VC) is a connection that is guaranteed if the player moves
+// first, by playing on its key cell. The carrier of a connection is the set of
+// empty cells needed to hold it.
+//
+// Connections are deduced with two rules:
+//	AND rule: if x-z and z-y are VCs with disjoint carriers that do not
+//		contain x or y, then x-y is a VC if z is a group of stones (or an
+//		edge) and a semi-VC with key z if z is an empty cell
+//	OR rule: if the carriers of a set of semi-VCs between x and y have no
+//		common cell, then x-y is a VC with the union of the carriers
+
+// Maximal number of connections that are kept for each pair of nodes
+const (
+	maxVCs   = 4
+	maxSemis = 8
+)
+
+// -------------------
+// |     carrier     |
+// -------------------
+
+// carrier is a set of cells, stored as a bitset. Cell (x, y) is stored in bit
+// y*size+x.
+type carrier []uint64
+
+// newCarrier returns an empty carrier for a grid of a given size
+func newCarrier(size byte) carrier {
+	return make(carrier, (int(size)*int(size)+63)/64)
+}
+
+func (c carrier) add(i int) {
+	c[i/64] |= 1 << uint(i%64)
+}
+
+func (c carrier) contains(i int) bool {
+	return c[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (c carrier) intersects(d carrier) bool {
+	for i := range c {
+		if c[i]&d[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c carrier) isEmpty() bool {
+	for _, w := range c {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubsetOf returns true if all cells of c are also in d
+func (c carrier) isSubsetOf(d carrier) bool {
+	for i := range c {
+		if c[i]&^d[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c carrier) union(d carrier) carrier {
+	u := make(carrier, len(c))
+	for i := range c {
+		u[i] = c[i] | d[i]
+	}
+	return u
+}
+
+func (c carrier) intersection(d carrier) carrier {
+	u := make(carrier, len(c))
+	for i := range c {
+		u[i] = c[i] & d[i]
+	}
+	return u
+}
+
+func (c carrier) count() int {
+	n := 0
+	for _, w := range c {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// getCells returns coordinates of all cells in a carrier
+func (c carrier) getCells(size byte) [][2]int {
+	cells := make([][2]int, 0, c.count())
+	for i := 0; i < int(size)*int(size); i++ {
+		if c.contains(i) {
+			cells = append(cells, [2]int{i % int(size), i / int(size)})
+		}
+	}
+	return cells
+}
+
+// -------------------
+// |     HSearch     |
+// -------------------
+
+// HSearch holds virtual connections of one player in a state
+//	state is the state in which connections are searched for
+//	c is the player whose connections are searched for
+//	nodeOf is the node of each cell (index y*size+x), -1 for the opponent's
+//		stones. Each empty cell is a node of its own, while all stones in a
+//		group are the same node. Groups that touch an edge of the player are
+//		the same node as that edge.
+//	cellOf is the cell of each node if the node is an empty cell, -1 otherwise
+//	edges are the nodes of the player's two edges
+//	vcs are VCs between each pair of nodes (index i*numNodes+j, i < j)
+//	semis are semi-VCs between each pair of nodes, the key cell is included in
+//		the carrier
+//	queue is a list of VCs that have not been combined with other VCs yet
+type HSearch struct {
+	state  *State
+	c      Color
+	nodeOf []int
+	cellOf []int
+	edges  [2]int
+	vcs    [][]carrier
+	semis  [][]carrier
+	queue  [][3]int
+}
+
+// NewHSearch finds virtual connections of player c in State s
+func NewHSearch(s *State, c Color) *HSearch {
+	h := &HSearch{state: s, c: c}
+	h.initNodes()
+	numNodes := len(h.cellOf)
+	h.vcs = make([][]carrier, numNodes*numNodes)
+	h.semis = make([][]carrier, numNodes*numNodes)
+	h.addBaseVCs()
+	h.run()
+	return h
+}
+
+// initNodes splits the cells of the grid and the player's edges into nodes
+func (h *HSearch) initNodes() {
+	size := h.state.size
+	u := newUnionFind(size)
+	u.addStones(h.state, h.c)
+
+	numCells := int(size) * int(size)
+	h.nodeOf = make([]int, numCells)
+	h.cellOf = make([]int, 0)
+	rootNode := make(map[int32]int)
+	getRootNode := func(root int32) int {
+		if n, ok := rootNode[root]; ok {
+			return n
+		}
+		rootNode[root] = len(h.cellOf)
+		h.cellOf = append(h.cellOf, -1)
+		return rootNode[root]
+	}
+
+	first, second := u.getEdgeNodes(h.c)
+	h.edges = [2]int{getRootNode(u.find(first)), getRootNode(u.find(second))}
+
+	for y := byte(0); y < size; y++ {
+		for x := byte(0); x < size; x++ {
+			i := int(y)*int(size) + int(x)
+			switch h.state.getColorOn(x, y) {
+			case None:
+				h.nodeOf[i] = len(h.cellOf)
+				h.cellOf = append(h.cellOf, i)
+			case h.c:
+				h.nodeOf[i] = getRootNode(u.find(u.getNode(x, y)))
+			default:
+				h.nodeOf[i] = -1
+			}
+		}
+	}
+}
+
+// addBaseVCs adds VCs with empty carriers between adjacent nodes and VCs of
+// edge templates (see EdgeTemplates) between groups and edges
+func (h *HSearch) addBaseVCs() {
+	size := int(h.state.size)
+	empty := newCarrier(h.state.size)
+	for i, node := range h.nodeOf {
+		if node < 0 || h.cellOf[node] >= 0 {
+			continue // Not a stone of the player
+		}
+		for e, edge := range h.edges {
+			if node == edge {
+				continue
+			}
+			if _, cells, ok := h.state.GetEdgeTemplate(i%size, i/size, e); ok {
+				c := newCarrier(h.state.size)
+				for _, cell := range cells {
+					c.add(cell[1]*size + cell[0])
+				}
+				h.addVC(node, edge, c)
+			}
+		}
+	}
+	for _, i := range h.cellOf {
+		if i < 0 {
+			continue
+		}
+		x, y := i%size, i/size
+		for _, n := range neighbours {
+			nx, ny := x+n[0], y+n[1]
+			if h.state.IsCellValid(nx, ny) {
+				if node := h.nodeOf[ny*size+nx]; node >= 0 {
+					h.addVC(h.nodeOf[i], node, empty)
+				}
+			}
+		}
+		pos := y
+		if h.c == Blue {
+			pos = x
+		}
+		if pos == 0 {
+			h.addVC(h.nodeOf[i], h.edges[0], empty)
+		}
+		if pos == size-1 {
+			h.addVC(h.nodeOf[i], h.edges[1], empty)
+		}
+	}
+}
+
+// run combines VCs from the queue until no new VCs are found
+func (h *HSearch) run() {
+	numNodes := len(h.cellOf)
+	for len(h.queue) > 0 {
+		vc := h.queue[0]
+		h.queue = h.queue[1:]
+		a, b := vc[0], vc[1]
+		cA := h.vcs[h.getPairIndex(a, b)][vc[2]]
+		for _, p := range [2][2]int{{a, b}, {b, a}} {
+			z, x := p[0], p[1]
+			for y := 0; y < numNodes; y++ {
+				if y == x || y == z {
+					continue
+				}
+				for _, cB := range h.vcs[h.getPairIndex(z, y)] {
+					h.and(x, y, z, cA, cB)
+				}
+			}
+		}
+	}
+}
+
+// and applies the AND rule on VCs x-z (with carrier cA) and z-y (with carrier
+// cB)
+func (h *HSearch) and(x, y, z int, cA, cB carrier) {
+	if cA.intersects(cB) || h.inCarrier(x, cB) || h.inCarrier(y, cA) {
+		return
+	}
+	cU := cA.union(cB)
+	if cell := h.cellOf[z]; cell >= 0 {
+		cU.add(cell)
+		h.addSemi(x, y, cU)
+	} else {
+		h.addVC(x, y, cU)
+	}
+}
+
+// or applies the OR rule on semi-VCs between x and y, starting with the new
+// semi-VC with carrier cN
+func (h *HSearch) or(x, y int, cN carrier) {
+	cU, cI := cN, cN
+	for _, s := range h.semis[h.getPairIndex(x, y)] {
+		i := cI.intersection(s)
+		if i.count() == cI.count() {
+			continue // s does not reduce the intersection
+		}
+		cU, cI = cU.union(s), i
+		if cI.isEmpty() {
+			h.addVC(x, y, cU)
+			return
+		}
+	}
+}
+
+// addVC adds a VC between x and y with carrier cN, unless a VC with a subset
+// of cN is already known
+func (h *HSearch) addVC(x, y int, cN carrier) {
+	p := h.getPairIndex(x, y)
+	i := addConnection(h.vcs[p], cN, maxVCs)
+	if i < 0 {
+		return
+	}
+	if i == len(h.vcs[p]) {
+		h.vcs[p] = append(h.vcs[p], cN)
+	} else {
+		h.vcs[p][i] = cN
+	}
+	if x > y {
+		x, y = y, x
+	}
+	h.queue = append(h.queue, [3]int{x, y, i})
+}
+
+// addSemi adds a semi-VC between x and y with carrier cN, unless a VC or a
+// semi-VC with a subset of cN is already known
+func (h *HSearch) addSemi(x, y int, cN carrier) {
+	p := h.getPairIndex(x, y)
+	for _, c := range h.vcs[p] {
+		if c.isSubsetOf(cN) {
+			return
+		}
+	}
+	i := addConnection(h.semis[p], cN, maxSemis)
+	if i < 0 {
+		return
+	}
+	if i == len(h.semis[p]) {
+		h.semis[p] = append(h.semis[p], cN)
+	} else {
+		h.semis[p][i] = cN
+	}
+	h.or(x, y, cN)
+}
+
+// addConnection returns the index in list at which carrier cN is to be
+// stored, or -1 if list already contains a subset of cN. cN replaces a superset
+// of it or, if list is full, the largest carrier in list that is larger than
+// cN. Carriers are never removed, so the indices in the queue remain valid.
+func addConnection(list []carrier, cN carrier, max int) int {
+	for _, c := range list {
+		if c.isSubsetOf(cN) {
+			return -1
+		}
+	}
+	for i, c := range list {
+		if cN.isSubsetOf(c) {
+			return i
+		}
+	}
+	if len(list) < max {
+		return len(list)
+	}
+	largest, n := -1, cN.count()
+	for i, c := range list {
+		if cc := c.count(); cc > n {
+			largest, n = i, cc
+		}
+	}
+	return largest
+}
+
+// inCarrier returns true if node n is an empty cell in carrier c
+func (h *HSearch) inCarrier(n int, c carrier) bool {
+	cell := h.cellOf[n]
+	return cell >= 0 && c.contains(cell)
+}
+
+// getPairIndex returns the index of the pair of nodes x and y in h.vcs and
+// h.semis
+func (h *HSearch) getPairIndex(x, y int) int {
+	if x > y {
+		x, y = y, x
+	}
+	return x*len(h.cellOf) + y
+}
+
+// getNode returns the node of cell c. Cells beyond the grid represent edges:
+// a cell above (left of) the grid is the first edge of the red (blue) player,
+// a cell below (right of) the grid is the second edge. It returns -1 if there
+// is no such node.
+func (h *HSearch) getNode(c [2]int) int {
+	size := int(h.state.size)
+	x, y := c[0], c[1]
+	pos := y
+	if h.c == Blue {
+		pos = x
+	}
+	switch {
+	case pos < 0:
+		return h.edges[0]
+	case pos >= size:
+		return h.edges[1]
+	case h.state.IsCellValid(x, y):
+		return h.nodeOf[y*size+x]
+	}
+	return -1
+}
+
+// getSmallest returns the carrier with the fewest cells
+func getSmallest(list []carrier) carrier {
+	var smallest carrier
+	for _, c := range list {
+		if smallest == nil || c.count() < smallest.count() {
+			smallest = c
+		}
+	}
+	return smallest
+}
+
+// GetCarrier returns the smallest known carrier of a VC between cells c1 and
+// c2 and true, or nil and false if no VC is known. A carrier is a list of
+// empty cells. Edges are given as cells beyond the grid, e.g. (0, -1) is the
+// top edge for the red player and (-1, 0) the left edge for the blue player.
+func (h *HSearch) GetCarrier(c1, c2 [2]int) ([][2]int, bool) {
+	n1, n2 := h.getNode(c1), h.getNode(c2)
+	if n1 < 0 || n2 < 0 {
+		return nil, false
+	}
+	if n1 == n2 {
+		return [][2]int{}, true
+	}
+	c := getSmallest(h.vcs[h.getPairIndex(n1, n2)])
+	if c == nil {
+		return nil, false
+	}
+	return c.getCells(h.state.size), true
+}
+
+// GetSemiCarrier returns the smallest known carrier of a semi-VC between
+// cells c1 and c2 and true, or nil and false if no semi-VC is known. The key
+// cell of the semi-VC is included in the carrier. Edges are given as in
+// GetCarrier.
+func (h *HSearch) GetSemiCarrier(c1, c2 [2]int) ([][2]int, bool) {
+	n1, n2 := h.getNode(c1), h.getNode(c2)
+	if n1 < 0 || n2 < 0 || n1 == n2 {
+		return nil, false
+	}
+	c := getSmallest(h.semis[h.getPairIndex(n1, n2)])
+	if c == nil {
+		return nil, false
+	}
+	return c.getCells(h.state.size), true
+}
+
+// GetWinningCarrier returns the carrier of a VC between the player's edges
+// and true if the player has won the game, or nil and false otherwise
+func (h *HSearch) GetWinningCarrier() ([][2]int, bool) {
+	return h.GetCarrier([2]int{-1, -1}, [2]int{int(h.state.size), int(h.state.size)})
+}
@@ -0,0 +1,161 @@
+package hex
+
+import (
+	"sort"
+	"testing"
+)
+
+// getStateWithStones returns a state of a given size with red and blue stones
+// on given cells
+func getStateWithStones(size byte, red, blue [][2]int) *State {
+	state := NewState(size, Red)
+	for _, c := range red {
+		state.setCell(byte(c[0]), byte(c[1]), Red)
+	}
+	for _, c := range blue {
+		state.setCell(byte(c[0]), byte(c[1]), Blue)
+	}
+	return state
+}
+
+// sameCells returns true if lists a and b contain the same cells
+func sameCells(a, b [][2]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	less := func(l [][2]int) func(i, j int) bool {
+		return func(i, j int) bool {
+			return l[i][1] < l[j][1] || l[i][1] == l[j][1] && l[i][0] < l[j][0]
+		}
+	}
+	sort.Slice(a, less(a))
+	sort.Slice(b, less(b))
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+. . . . . . . . .
+ . . . . . . . . .
+  . . . . . . . . .
+   . . . . . . . . .
+    . . . r . . . . .
+     . . . . r . . . .
+      . . . . . . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestHSearchBridge(t *testing.T) {
+	state := getStateWithStones(9, [][2]int{{3, 4}, {4, 5}}, nil)
+	carrier, ok := NewHSearch(state, Red).GetCarrier([2]int{3, 4}, [2]int{4, 5})
+	if exp := [][2]int{{4, 4}, {3, 5}}; !ok || !sameCells(carrier, exp) {
+		t.Fatalf("Expected a VC with carrier %v, got %v (%t)", exp, carrier, ok)
+	}
+
+	// Blue stones around the bridge, so that it cannot be replaced by a longer
+	// connection
+	blue := [][2]int{{4, 4}, {2, 4}, {4, 3}, {5, 4}, {5, 5}, {3, 6}, {4, 6}, {2, 5}}
+	state = getStateWithStones(9, [][2]int{{3, 4}, {4, 5}}, blue)
+	h := NewHSearch(state, Red)
+	if carrier, ok := h.GetCarrier([2]int{3, 4}, [2]int{4, 5}); ok {
+		t.Fatalf("Expected no VC in an intruded bridge, got carrier %v", carrier)
+	}
+	carrier, ok = h.GetSemiCarrier([2]int{3, 4}, [2]int{4, 5})
+	if exp := [][2]int{{3, 5}}; !ok || !sameCells(carrier, exp) {
+		t.Fatalf("Expected a semi-VC with carrier %v, got %v (%t)", exp, carrier, ok)
+	}
+}
+
+/*
+. . . . .
+ . . . . .
+  . . b r .
+   . . . . .
+    . . . . .
+*/
+func TestHSearchEdgeTemplates(t *testing.T) {
+	// Template II: a stone in the second row is connected to the edge
+	state := getStateWithStones(5, [][2]int{{2, 3}}, nil)
+	carrier, ok := NewHSearch(state, Red).GetCarrier([2]int{2, 3}, [2]int{0, 5})
+	if exp := [][2]int{{1, 4}, {2, 4}}; !ok || !sameCells(carrier, exp) {
+		t.Fatalf("Expected a VC to the edge with carrier %v, got %v (%t)", exp, carrier, ok)
+	}
+
+	// Ziggurat: a stone in the third row is connected to the edge
+	state = getStateWithStones(5, [][2]int{{3, 2}}, [][2]int{{2, 2}})
+	carrier, ok = NewHSearch(state, Red).GetCarrier([2]int{3, 2}, [2]int{0, 5})
+	if !ok || len(carrier) != 8 {
+		t.Fatalf("Expected a VC to the edge with 8 cells in the carrier, got %v (%t)", carrier, ok)
+	}
+
+	// The ziggurat is broken by a stone in its carrier
+	state.setCell(byte(carrier[0][0]), byte(carrier[0][1]), Blue)
+	if carrier, ok := NewHSearch(state, Red).GetCarrier([2]int{3, 2}, [2]int{0, 5}); ok {
+		t.Fatalf("Expected no VC to the edge, got carrier %v", carrier)
+	}
+}
+
+/*
+. . r . . .
+ . . . . . .
+  . b . . b .
+   . . b . . .
+    . . . . . .
+     . . . . . .
+*/
+func TestHSearchWinningCarrier(t *testing.T) {
+	state := getStateWithStones(6, [][2]int{{2, 0}}, [][2]int{{1, 2}, {2, 3}, {4, 2}})
+	if carrier, ok := NewHSearch(state, Red).GetWinningCarrier(); ok {
+		t.Fatalf("Red should not have a winning VC, got carrier %v", carrier)
+	}
+	carrier, ok := NewHSearch(state, Blue).GetWinningCarrier()
+	if !ok {
+		t.Fatalf("Blue should have a winning VC:\n%v", state)
+	}
+	for _, c := range [][2]int{{2, 2}, {1, 3}, {3, 2}, {3, 3}} {
+		if !containsCell(carrier, c) {
+			t.Fatalf("Carrier %v does not contain cell %v of a bridge", carrier, c)
+		}
+	}
+	for _, c := range carrier {
+		if !state.IsCellEmpty(byte(c[0]), byte(c[1])) {
+			t.Fatalf("Carrier %v contains an occupied cell %v", carrier, c)
+		}
+	}
+
+	if carrier, ok := NewHSearch(NewState(5, Red), Red).GetWinningCarrier(); ok {
+		t.Fatalf("There should be no winning VC on an empty board, got carrier %v", carrier)
+	}
+}
+
+// containsCell returns true if list contains cell c
+func containsCell(list [][2]int, c [2]int) bool {
+	for _, l := range list {
+		if l == c {
+			return true
+		}
+	}
+	return false
+}
+
+func BenchmarkHSearchEmpty(b *testing.B) {
+	state := NewState(11, Red)
+	for n := 0; n < b.N; n++ {
+		NewHSearch(state, Red)
+	}
+}
+
+func BenchmarkHSearchMidGame(b *testing.B) {
+	state := NewState(11, Red)
+	for _, a := range getRandomGame()[:40] {
+		s := state.GetSuccessorState(a).(State)
+		state = &s
+	}
+	for n := 0; n < b.N; n++ {
+		NewHSearch(state, Red)
+	}
+}
@@ -0,0 +1,215 @@
+package hex
+
+import "github.com/RdecKa/0xAI/common/game"
+
+// This file implements inferior cell analysis. Cells found here can be
+// excluded from the search without changing the outcome of the game:
+//	A dead cell is an empty cell whose color does not matter for the outcome
+//		of the game. It is found by looking at its six neighbours: for each
+//		player, any two neighbours that the player could connect through the
+//		cell must already be connected around the cell by the player's
+//		stones.
+//	A captured pair is a pair of adjacent empty cells that a player can fill
+//		with own stones: if the opponent plays on one of them, the player
+//		responds on the other and the opponent's stone is dead.
+//	A cell d is dominated by cell c for the player on turn, if the player's
+//		stone on c makes d dead. Playing on c is then at least as good as
+//		playing on d.
+// Dead and captured cells are filled (which does not change the outcome of the
+// game) until no more are found, and domination is checked on the filled grid.
+
+// wall is a cell beyond two edges of the grid, which belongs to none of the
+// players
+const wall Color = 3
+
+// InferiorCells holds the result of inferior cell analysis of a state
+type InferiorCells struct {
+	Dead      [][2]int    // Dead cells
+	Captured  [2][][2]int // Cells captured by the red (index 0) and the blue (index 1) player
+	Dominated [][2]int    // Cells dominated for the player on turn
+}
+
+// getRing returns colors of the six neighbours of cell (x, y), in the order of
+// neighbours. Cells beyond the top and bottom edge belong to the red player,
+// cells beyond the left and right edge to the blue player.
+func (s *State) getRing(x, y int) [6]Color {
+	var ring [6]Color
+	size := int(s.size)
+	for i, n := range neighbours {
+		nx, ny := x+n[0], y+n[1]
+		outX, outY := nx < 0 || nx >= size, ny < 0 || ny >= size
+		switch {
+		case outX && outY:
+			ring[i] = wall
+		case outY:
+			ring[i] = Red
+		case outX:
+			ring[i] = Blue
+		default:
+			ring[i] = s.getColorOn(byte(nx), byte(ny))
+		}
+	}
+	return ring
+}
+
+// isDeadRing returns true if an empty cell with neighbours ring is dead
+func isDeadRing(ring [6]Color) bool {
+	return isUselessForPlayer(ring, Red) && isUselessForPlayer(ring, Blue)
+}
+
+// isUselessForPlayer returns true if a cell with neighbours ring cannot help
+// player c to connect any two of its neighbours. That is true if each pair of
+// the neighbours that are empty or player c's is connected by player c's
+// stones on one of the two arcs of the ring between them.
+func isUselessForPlayer(ring [6]Color, c Color) bool {
+	for i := 0; i < 6; i++ {
+		if ring[i] != c && ring[i] != None {
+			continue
+		}
+		for j := i + 1; j < 6; j++ {
+			if ring[j] != c && ring[j] != None {
+				continue
+			}
+			if !isArcOfColor(ring, i, j, c) && !isArcOfColor(ring, j, i+6, c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isArcOfColor returns true if all neighbours strictly between indices from and
+// to (going around the ring in increasing order) are player c's stones
+func isArcOfColor(ring [6]Color, from, to int, c Color) bool {
+	for k := from + 1; k < to; k++ {
+		if ring[k%6] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// isDead returns true if the empty cell (x, y) is dead
+func (s *State) isDead(x, y int) bool {
+	return isDeadRing(s.getRing(x, y))
+}
+
+// isCapturedPair returns true if the empty cells a and b are captured by
+// player c
+func (s *State) isCapturedPair(a, b [2]int, c Color) bool {
+	return s.killsAfterReply(a, b, c) && s.killsAfterReply(b, a, c)
+}
+
+// killsAfterReply returns true if the opponent's stone on a is dead after
+// player c replies on b
+func (s *State) killsAfterReply(a, b [2]int, c Color) bool {
+	xb, yb := byte(b[0]), byte(b[1])
+	s.setCell(xb, yb, c)
+	dead := s.isDead(a[0], a[1])
+	s.clearCell(xb, yb)
+	return dead
+}
+
+// fillInferiorCells finds dead and captured cells and fills them until no
+// more are found. It changes the grid of State s.
+func (s *State) fillInferiorCells(ic *InferiorCells) {
+	for changed := true; changed; {
+		changed = false
+		for y := 0; y < int(s.size); y++ {
+			for x := 0; x < int(s.size); x++ {
+				if !s.IsCellEmpty(byte(x), byte(y)) {
+					continue
+				}
+				if s.isDead(x, y) {
+					// The color of a dead cell does not matter
+					s.setCell(byte(x), byte(y), Red)
+					ic.Dead = append(ic.Dead, [2]int{x, y})
+					changed = true
+					continue
+				}
+				for _, n := range neighbours[2:5] {
+					b := [2]int{x + n[0], y + n[1]}
+					if !s.IsCellValid(b[0], b[1]) || !s.IsCellEmpty(byte(b[0]), byte(b[1])) {
+						continue
+					}
+					for ci, c := range []Color{Red, Blue} {
+						if s.isCapturedPair([2]int{x, y}, b, c) {
+							s.setCell(byte(x), byte(y), c)
+							s.setCell(byte(b[0]), byte(b[1]), c)
+							ic.Captured[ci] = append(ic.Captured[ci], [2]int{x, y}, b)
+							changed = true
+							break
+						}
+					}
+					if !s.IsCellEmpty(byte(x), byte(y)) {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// findDominatedCells finds cells that are dominated for player c. A cell is
+// marked as dominated only if the cell that dominates it is not, so that at
+// least one cell of each chain of dominated cells remains.
+func (s *State) findDominatedCells(c Color, ic *InferiorCells) {
+	size := int(s.size)
+	dominated := make([]bool, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !s.IsCellEmpty(byte(x), byte(y)) || dominated[y*size+x] {
+				continue
+			}
+			s.setCell(byte(x), byte(y), c)
+			for _, n := range neighbours {
+				nx, ny := x+n[0], y+n[1]
+				if !s.IsCellValid(nx, ny) || !s.IsCellEmpty(byte(nx), byte(ny)) || dominated[ny*size+nx] {
+					continue
+				}
+				if s.isDead(nx, ny) {
+					dominated[ny*size+nx] = true
+					ic.Dominated = append(ic.Dominated, [2]int{nx, ny})
+				}
+			}
+			s.clearCell(byte(x), byte(y))
+		}
+	}
+}
+
+// GetInferiorCells returns dead, captured and dominated cells in State s
+func (s State) GetInferiorCells() *InferiorCells {
+	ic := &InferiorCells{}
+	filled := s.Clone().(State)
+	filled.fillInferiorCells(ic)
+	filled.findDominatedCells(s.lastAction.c.Opponent(), ic)
+	return ic
+}
+
+// GetPrunedActions returns possible actions from State s without actions on
+// inferior cells (see GetInferiorCells). If all cells are inferior, all
+// possible actions are returned.
+func (s State) GetPrunedActions() []game.Action {
+	possibleActions := s.GetPossibleActions()
+	ic := s.GetInferiorCells()
+
+	size := int(s.size)
+	inferior := make([]bool, size*size)
+	for _, list := range [][][2]int{ic.Dead, ic.Captured[0], ic.Captured[1], ic.Dominated} {
+		for _, c := range list {
+			inferior[c[1]*size+c[0]] = true
+		}
+	}
+
+	actions := make([]game.Action, 0, len(possibleActions))
+	for _, a := range possibleActions {
+		x, y := a.(*Action).GetCoordinates()
+		if a.(*Action).IsSwap() || !inferior[y*size+x] {
+			actions = append(actions, a)
+		}
+	}
+	if len(actions) == 0 || len(actions) == 1 && actions[0].(*Action).IsSwap() {
+		return possibleActions
+	}
+	return actions
+}
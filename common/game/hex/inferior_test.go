@@ -0,0 +1,161 @@
+package hex
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/RdecKa/0xAI/common/game"
+)
+
+// shift returns cells moved by (dx, dy)
+func shift(cells [][2]int, dx, dy int) [][2]int {
+	shifted := make([][2]int, len(cells))
+	for i, c := range cells {
+		shifted[i] = [2]int{c[0] + dx, c[1] + dy}
+	}
+	return shifted
+}
+
+/*
+. . . r . . . . .
+ . . r r . . . . .
+  . . . r . . . . .
+   . . r . . . . . .
+    . . . . . . . . .
+     . . . . . . . . .
+      . . . . . . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestDeadCells(t *testing.T) {
+	// (2, 2) has four red neighbours in a row, (2, 0) has two red neighbours
+	// and the top edge
+	state := getStateWithStones(9, [][2]int{{3, 0}, {2, 1}, {3, 1}, {3, 2}, {2, 3}}, nil)
+	ic := state.GetInferiorCells()
+	for _, c := range [][2]int{{2, 2}, {2, 0}} {
+		if !containsCell(ic.Dead, c) {
+			t.Fatalf("Cell %v should be dead, dead cells: %v\n%v", c, ic.Dead, state)
+		}
+	}
+	if containsCell(ic.Dead, [2]int{6, 6}) {
+		t.Fatalf("Cell (6, 6) should not be dead\n%v", state)
+	}
+
+	// On an empty board only the acute corners are inferior, they are
+	// dominated by their neighbours
+	ic = NewState(5, Red).GetInferiorCells()
+	if len(ic.Dead)+len(ic.Captured[0])+len(ic.Captured[1]) > 0 ||
+		!sameCells(ic.Dominated, [][2]int{{0, 0}, {4, 4}}) {
+		t.Fatalf("Expected only the acute corners to be inferior on an empty board, got %v", ic)
+	}
+}
+
+/*
+. . . . . . . . .
+ . . . . . . . . .
+  . . . . . . . . .
+   . . . . r r . . .
+    . . . . . r . . .
+     . . . r . r . . .
+      . . . . . . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestCapturedCells(t *testing.T) {
+	red := shift([][2]int{{2, 1}, {3, 1}, {3, 2}, {3, 3}, {1, 3}}, 2, 2)
+	state := getStateWithStones(9, red, nil)
+	ic := state.GetInferiorCells()
+	for _, c := range [][2]int{{4, 4}, {4, 5}} {
+		if !containsCell(ic.Captured[0], c) {
+			t.Fatalf("Cell %v should be captured by red, captured cells: %v\n%v", c, ic.Captured[0], state)
+		}
+	}
+	for _, a := range state.GetPrunedActions() {
+		if x, y := a.(*Action).GetCoordinates(); x == 4 && (y == 4 || y == 5) {
+			t.Fatalf("Action on a captured cell %v should be pruned", a)
+		}
+	}
+}
+
+/*
+. . . . . . . . .
+ . . . . . . . . .
+  . . . . . . . . .
+   . . . . r r . . .
+    . . . . . r . . .
+     . . . . . . . . .
+      . . . . . . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestDominatedCells(t *testing.T) {
+	// Red's stone on (4, 5) kills (4, 4)
+	state := getStateWithStones(9, shift([][2]int{{2, 1}, {3, 1}, {3, 2}}, 2, 2), nil)
+	ic := state.GetInferiorCells()
+	if !containsCell(ic.Dominated, [2]int{4, 4}) {
+		t.Fatalf("Cell (4, 4) should be dominated for red, dominated cells: %v\n%v", ic.Dominated, state)
+	}
+	if len(ic.Dead)+len(ic.Captured[0])+len(ic.Captured[1]) > 0 {
+		t.Fatalf("There should be no dead or captured cells, got %v\n%v", ic, state)
+	}
+}
+
+// solve returns true if the player on turn wins on board. If pruned is true,
+// only pruned actions are considered in the first move.
+func solve(board *Board, pruned bool, tt map[uint64]bool) bool {
+	if goal, _ := board.IsGoalState(true); goal {
+		return false
+	}
+	key := board.GetMapKey()
+	if win, ok := tt[key]; ok && !pruned {
+		return win
+	}
+	var actions []game.Action
+	if pruned {
+		actions = board.GetPrunedActions()
+	} else {
+		actions = board.GetPossibleActions()
+	}
+	win := false
+	for _, a := range actions {
+		if a.(*Action).IsSwap() {
+			continue
+		}
+		board.Play(a)
+		win = !solve(board, false, tt)
+		board.Undo()
+		if win {
+			break
+		}
+	}
+	if !pruned {
+		tt[key] = win
+	}
+	return win
+}
+
+func TestPrunedActionsKeepGameValue(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		board := NewBoard(NewState(4, Red))
+		for n := r.Intn(5) + 4; n > 0; n-- {
+			actions := board.GetPossibleActions()
+			board.Play(actions[r.Intn(len(actions))])
+			if board.lastAction.IsSwap() {
+				board.Undo()
+				n++
+				continue
+			}
+			if goal, _ := board.IsGoalState(true); goal {
+				board.Undo()
+				break
+			}
+		}
+
+		tt := make(map[uint64]bool)
+		if all, pruned := solve(board, false, tt), solve(board, true, tt); all != pruned {
+			t.Fatalf("Pruned actions change the value of the game (all: %t, pruned: %t)\n%v",
+				all, pruned, board)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package hex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file provides functions for reading and writing actions in the standard
+// Hex notation. A cell is written as a column letter followed by a row number,
+// for example "a1" is the top left cell and "k11" is the bottom right cell of an
+// 11x11 grid. Columns after "z" are "aa", "ab", ... A swap move is written as
+// "swap".
+//
+// A whole state can be written in the board notation: rows of the grid from
+// top to bottom, separated by '/', where 'r' is a red stone, 'b' a blue stone
+// and '.' an empty cell, followed by a space and the color of the player who
+// made the last move. For example, "r../.b./... b" is a 3x3 grid with two
+// stones where the red player is on turn.
+
+// swapNotation is the notation of the swap move
+const swapNotation = "swap"
+
+// ParseAction reads an action of the player c, written in the standard
+// notation, on a grid of a given size
+func ParseAction(notation string, size byte, c Color) (*Action, error) {
+	n := strings.ToLower(strings.TrimSpace(notation))
+	if n == swapNotation || n == "swap-pieces" {
+		return NewSwapAction(c), nil
+	}
+
+	i := 0
+	for i < len(n) && n[i] >= 'a' && n[i] <= 'z' {
+		i++
+	}
+	if i == 0 || i == len(n) {
+		return nil, fmt.Errorf("Invalid action '%s': expected a column letter and a row number", notation)
+	}
+
+	x := 0
+	for _, l := range n[:i] {
+		x = x*26 + int(l-'a') + 1
+	}
+	y, err := strconv.Atoi(n[i:])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid row in action '%s': %s", notation, err)
+	}
+
+	if x < 1 || x > int(size) || y < 1 || y > int(size) {
+		return nil, fmt.Errorf("Action '%s' is not on the grid of size %d", notation, size)
+	}
+	return NewAction(byte(x-1), byte(y-1), c), nil
+}
+
+// GetNotation returns the action written in the standard notation
+func (a *Action) GetNotation() string {
+	if a.swap {
+		return swapNotation
+	}
+	return getCellNotation(int(a.x), int(a.y))
+}
+
+// getCellNotation returns the standard notation of the cell (x, y)
+func getCellNotation(x, y int) string {
+	return getColumnNotation(x) + strconv.Itoa(y+1)
+}
+
+// getColumnNotation returns letters that represent the column with index x
+func getColumnNotation(x int) string {
+	s := ""
+	for x++; x > 0; x = (x - 1) / 26 {
+		s = string(rune('a'+(x-1)%26)) + s
+	}
+	return s
+}
+
+// GetBoardNotation returns the state written in the board notation
+func (s State) GetBoardNotation() string {
+	var b strings.Builder
+	for y, row := range s.grid {
+		if y > 0 {
+			b.WriteByte('/')
+		}
+		for x := byte(0); x < s.size; x++ {
+			b.WriteString(getCellInRow(row, x).String())
+		}
+	}
+	b.WriteByte(' ')
+	b.WriteString(s.lastAction.c.String())
+	return b.String()
+}
+
+// ParseBoardNotation reads a state written in the board notation. The last
+// action of the returned state is invalid, as in NewState.
+func ParseBoardNotation(notation string) (*State, error) {
+	fields := strings.Fields(notation)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("Invalid board '%s': expected rows and the last player", notation)
+	}
+	rows := strings.Split(fields[0], "/")
+	if len(rows) > 255 {
+		return nil, fmt.Errorf("Invalid board '%s': too many rows", notation)
+	}
+
+	var lastPlayer Color
+	switch fields[1] {
+	case Red.String():
+		lastPlayer = Red
+	case Blue.String():
+		lastPlayer = Blue
+	default:
+		return nil, fmt.Errorf("Invalid last player '%s' in board '%s'", fields[1], notation)
+	}
+
+	s := NewState(byte(len(rows)), lastPlayer.Opponent())
+	for y, row := range rows {
+		if len(row) != len(rows) {
+			return nil, fmt.Errorf("Invalid board '%s': row %d has %d cells, expected %d", notation, y+1, len(row), len(rows))
+		}
+		for x, ch := range row {
+			switch string(ch) {
+			case Red.String():
+				s.setCell(byte(x), byte(y), Red)
+			case Blue.String():
+				s.setCell(byte(x), byte(y), Blue)
+			case None.String():
+			default:
+				return nil, fmt.Errorf("Invalid cell '%c' in board '%s'", ch, notation)
+			}
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,82 @@
+package hex
+
+import "testing"
+
+func TestNotationRoundTrip(t *testing.T) {
+	tests := []struct {
+		notation string
+		x, y     byte
+	}{
+		{"a1", 0, 0},
+		{"k11", 10, 10},
+		{"c4", 2, 3},
+		{"s19", 18, 18},
+		{"z1", 25, 0},
+		{"aa27", 26, 26},
+		{"ad30", 29, 29},
+	}
+
+	for _, tc := range tests {
+		a, err := ParseAction(tc.notation, 30, Red)
+		if err != nil {
+			t.Fatalf("Cannot parse '%s': %s", tc.notation, err)
+		}
+		if x, y := a.GetCoordinates(); x != int(tc.x) || y != int(tc.y) {
+			t.Fatalf("'%s': expected (%d, %d), got (%d, %d)", tc.notation, tc.x, tc.y, x, y)
+		}
+		if n := NewAction(tc.x, tc.y, Red).GetNotation(); n != tc.notation {
+			t.Fatalf("(%d, %d): expected '%s', got '%s'", tc.x, tc.y, tc.notation, n)
+		}
+	}
+}
+
+func TestParseSwap(t *testing.T) {
+	for _, n := range []string{"swap", "SWAP", "swap-pieces"} {
+		a, err := ParseAction(n, 11, Blue)
+		if err != nil {
+			t.Fatalf("Cannot parse '%s': %s", n, err)
+		}
+		if !a.IsSwap() || a.GetColor() != Blue || a.GetNotation() != "swap" {
+			t.Fatalf("'%s': expected a swap of the blue player, got %v", n, a)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, n := range []string{"", "a", "11", "a0", "l1", "a12", "1a", "a1b", "a-1"} {
+		if a, err := ParseAction(n, 11, Red); err == nil {
+			t.Fatalf("'%s' should not be parsed, got %v", n, a)
+		}
+	}
+}
+
+/*
+. . r
+ . b .
+  r . .
+*/
+func TestBoardNotation(t *testing.T) {
+	state := NewState(3, Red)
+	for _, a := range []*Action{NewAction(2, 0, Red), NewAction(1, 1, Blue), NewAction(0, 2, Red)} {
+		s := state.GetSuccessorState(a).(State)
+		state = &s
+	}
+
+	n := state.GetBoardNotation()
+	if n != "..r/.b./r.. r" {
+		t.Fatalf("Expected '..r/.b./r.. r', got '%s'", n)
+	}
+	parsed, err := ParseBoardNotation(n)
+	if err != nil {
+		t.Fatalf("Cannot parse '%s': %s", n, err)
+	}
+	if !parsed.Same(state) || parsed.GetMapKey() != state.GetMapKey() || parsed.GetLastPlayer() != Red {
+		t.Fatalf("Expected\n%v, got\n%v", state, parsed)
+	}
+
+	for _, n := range []string{"", "../.. ", "../.. x", "../... r", ".x/.. b", "../../.. r"} {
+		if s, err := ParseBoardNotation(n); err == nil {
+			t.Fatalf("'%s' should not be parsed, got\n%v", n, s)
+		}
+	}
+}
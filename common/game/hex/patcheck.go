@@ -0,0 +1,349 @@
+package hex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file implements parsing and validation of pattern files. The format of
+// a pattern file is:
+//	### <number> <comment>
+//	<keywords>
+//	---
+//	<rows of the pattern>
+//	---
+//	<rows of another form of the pattern>
+//	### <number> <comment>
+//	...
+// The number is the index of the pattern. Keywords are 'exclude' and
+// 'symmetric', each in its own line. Rows are written with increasing
+// indentation and cells are separated by spaces. Each form is a canonical form
+// of the pattern, its variants are generated (see getPatternVariants). Empty
+// lines are ignored.
+
+// -----------------------------
+// |     PatternDiagnostic     |
+// -----------------------------
+
+// PatternDiagnostic is a problem found in a pattern file
+//	File is the name of the file
+//	Line and Column are the position of the problem, starting with 1. Column
+//		is 0 if the problem concerns a whole line, Line is 0 if it concerns
+//		the whole file.
+//	Message describes the problem
+//	Warning is true if the patterns can still be used, false if the problem
+//		is an error
+type PatternDiagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	Warning bool
+}
+
+func (d PatternDiagnostic) String() string {
+	kind := "error"
+	if d.Warning {
+		kind = "warning"
+	}
+	pos := d.File
+	if d.Line > 0 {
+		pos += fmt.Sprintf(":%d", d.Line)
+	}
+	if d.Column > 0 {
+		pos += fmt.Sprintf(":%d", d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, kind, d.Message)
+}
+
+// ----------------------
+// |     patternFile     |
+// ----------------------
+
+// patternForm is a form of a pattern as written in a pattern file
+//	line is the line of '---' that starts the form
+//	rows are the rows of the form
+//	rowLines are the lines of the rows
+type patternForm struct {
+	line     int
+	rows     [][]cellType
+	rowLines []int
+}
+
+// patternBlock is a pattern as written in a pattern file
+//	line is the line of the header '###'
+//	header is the text after '###'
+//	exclude and symmetric are the keywords of the pattern
+//	forms are the forms of the pattern
+type patternBlock struct {
+	line      int
+	header    string
+	exclude   bool
+	symmetric bool
+	forms     []*patternForm
+}
+
+// patternFile is a parsed pattern file
+type patternFile struct {
+	name        string
+	blocks      []*patternBlock
+	diagnostics []PatternDiagnostic
+}
+
+// addDiagnostic adds a problem on line and column of the file
+func (pf *patternFile) addDiagnostic(line, column int, warning bool, format string, a ...interface{}) {
+	pf.diagnostics = append(pf.diagnostics, PatternDiagnostic{pf.name, line, column, fmt.Sprintf(format, a...), warning})
+}
+
+// err returns an error that describes the errors in the file, or nil if there
+// are none
+func (pf *patternFile) err() error {
+	var first *PatternDiagnostic
+	numErrors := 0
+	for i, d := range pf.diagnostics {
+		if !d.Warning {
+			if first == nil {
+				first = &pf.diagnostics[i]
+			}
+			numErrors++
+		}
+	}
+	switch {
+	case numErrors == 0:
+		return nil
+	case numErrors == 1:
+		return fmt.Errorf("%v", first)
+	default:
+		return fmt.Errorf("%v (and %d more errors)", first, numErrors-1)
+	}
+}
+
+// parsePatternFile parses a pattern file read from r. Problems are recorded in
+// diagnostics of the result. The returned error is not nil only if r cannot be
+// read.
+func parsePatternFile(r io.Reader, name string) (*patternFile, error) {
+	pf := &patternFile{name: name}
+	var block *patternBlock
+	var form *patternForm
+	var firstIndent int // Indentation of the first row of the current form
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		indent := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsSpace(r) })
+
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "###"):
+			block = &patternBlock{line: lineNum, header: strings.TrimSpace(trimmed[3:])}
+			form = nil
+			pf.blocks = append(pf.blocks, block)
+			if fields := strings.Fields(block.header); len(fields) > 0 {
+				if n, err := strconv.Atoi(fields[0]); err == nil && n != len(pf.blocks)-1 {
+					pf.addDiagnostic(lineNum, indent+1, true, "Pattern number %d does not match its index %d", n, len(pf.blocks)-1)
+				}
+			}
+		case block == nil:
+			pf.addDiagnostic(lineNum, indent+1, false, "Expected '###' at the start of a pattern, got '%s'", trimmed)
+		case trimmed == "exclude" || trimmed == "symmetric":
+			if len(block.forms) > 0 {
+				pf.addDiagnostic(lineNum, indent+1, false, "Keyword '%s' must precede the first form of the pattern", trimmed)
+			}
+			if trimmed == "exclude" {
+				block.exclude = true
+			} else {
+				block.symmetric = true
+			}
+		case trimmed == "---":
+			form = &patternForm{line: lineNum}
+			block.forms = append(block.forms, form)
+		case form == nil:
+			pf.addDiagnostic(lineNum, indent+1, false, "Expected '---' before rows of a pattern, got '%s'", trimmed)
+		default:
+			if len(form.rows) == 0 {
+				firstIndent = indent
+			} else if indent != firstIndent+len(form.rows) {
+				pf.addDiagnostic(lineNum, 0, true, "Row %d of a form should be indented by %d more spaces than the first row",
+					len(form.rows)+1, len(form.rows))
+			}
+			form.rows = append(form.rows, pf.parseRow(line, lineNum))
+			form.rowLines = append(form.rowLines, lineNum)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(pf.blocks) == 0 {
+		pf.addDiagnostic(0, 0, false, "File contains no patterns")
+	}
+	return pf, nil
+}
+
+// parseRow returns cells in a row of a pattern. Unknown symbols are recorded as
+// errors and replaced with indefinite cells.
+func (pf *patternFile) parseRow(line string, lineNum int) []cellType {
+	row := make([]cellType, 0, len(line)/2)
+	for col := 0; col < len(line); col++ {
+		if line[col] == ' ' || line[col] == '\t' {
+			continue
+		}
+		end := col
+		for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+			end++
+		}
+		ct, ok := getCellTypeFromString(line[col:end])
+		if !ok {
+			pf.addDiagnostic(lineNum, col+1, false, "Invalid symbol '%s' in pattern", line[col:end])
+		}
+		row = append(row, ct)
+		col = end
+	}
+	return row
+}
+
+// buildPatterns checks forms of patterns in the file and returns all variants
+// of each pattern (see readPatternsFromFile) and their color-swapped
+// counterparts. Problems are recorded in diagnostics. Patterns with errors may
+// be missing variants and counterparts are nil if there are any errors.
+func (pf *patternFile) buildPatterns() ([][]*pattern, []int) {
+	patterns := make([][]*pattern, len(pf.blocks))
+	for pi, block := range pf.blocks {
+		if len(block.forms) == 0 {
+			pf.addDiagnostic(block.line, 0, false, "Pattern %d has no forms", pi)
+			continue
+		}
+		keys := make(map[string]bool)
+		var allSymmetries map[string]bool // Keys of all rotations and reflections of the first form
+		for fi, form := range block.forms {
+			if !pf.checkForm(form) {
+				continue
+			}
+			variants, err := getPatternVariants(form.rows, block.symmetric)
+			if err != nil {
+				pf.addDiagnostic(form.line, 0, false, "%s", err)
+				continue
+			}
+
+			if fi == 0 {
+				allSymmetries = make(map[string]bool)
+				for _, v := range getAllSymmetries(form.rows) {
+					allSymmetries[getPatternKey(v)] = true
+				}
+			} else if k := getPatternKey(form.rows); keys[k] {
+				pf.addDiagnostic(form.line, 0, true, "Form %d of pattern %d is generated from previous forms and is not needed",
+					fi+1, pi)
+			} else if allSymmetries != nil && !allSymmetries[k] {
+				pf.addDiagnostic(form.line, 0, false, "Form %d of pattern %d is not a rotation or a reflection of the first form",
+					fi+1, pi)
+			}
+
+			for _, v := range variants {
+				if k := getPatternKey(v); !keys[k] {
+					keys[k] = true
+					patterns[pi] = append(patterns[pi], newPattern(v, block.exclude))
+				}
+			}
+		}
+	}
+
+	// The same variant in two patterns is counted twice
+	owners := make(map[string]int)
+	for pi, variants := range patterns {
+		for _, v := range variants {
+			k := getPatternKey(v.pat)
+			if o, ok := owners[k]; ok && o != pi {
+				pf.addDiagnostic(pf.blocks[pi].line, 0, true, "Pattern %d has a variant of pattern %d", pi, o)
+				break
+			}
+			owners[k] = pi
+		}
+	}
+
+	if pf.err() != nil {
+		return patterns, nil
+	}
+	counterparts, err := getPatternCounterparts(patterns)
+	if err != nil {
+		pf.addDiagnostic(0, 0, false, "%s", err)
+	}
+	return patterns, counterparts
+}
+
+// checkForm checks rows of a form and returns false if the form cannot be used
+func (pf *patternFile) checkForm(form *patternForm) bool {
+	if len(form.rows) == 0 {
+		pf.addDiagnostic(form.line, 0, false, "Form has no rows")
+		return false
+	}
+	ok := true
+	for ri, row := range form.rows {
+		if len(row) != len(form.rows[0]) {
+			pf.addDiagnostic(form.rowLines[ri], 0, false, "Row has %d cells, the first row of the form has %d",
+				len(row), len(form.rows[0]))
+			ok = false
+		}
+		p := &pattern{}
+		if p.setBoundsOfLine(row) {
+			pf.addDiagnostic(form.rowLines[ri], 0, true,
+				"Row contains separated definite parts, the pattern will be matched cell by cell")
+		}
+	}
+	return ok
+}
+
+// getAllSymmetries returns all rotations and reflections of a pattern given by
+// rows
+func getAllSymmetries(rows [][]cellType) [][][]cellType {
+	symmetries := make([][][]cellType, 0, 12)
+	r := rows
+	for i := 0; i < 6; i++ {
+		symmetries = append(symmetries, r, transposePattern(r))
+		r = rotatePattern60(r)
+	}
+	return symmetries
+}
+
+// ----------------------------
+// |     CheckPatternFile     |
+// ----------------------------
+
+// CheckPatternFile parses and validates a pattern file. It returns all problems
+// found in the file, sorted by their position. If w is not nil, each pattern is
+// written to w with all its variants. The returned error is not nil only if
+// the file cannot be read.
+func CheckPatternFile(fileName string, w io.Writer) ([]PatternDiagnostic, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pf, err := parsePatternFile(f, fileName)
+	if err != nil {
+		return nil, err
+	}
+	patterns, _ := pf.buildPatterns()
+
+	if w != nil {
+		for pi, variants := range patterns {
+			fmt.Fprintf(w, "### %s (%d variants)\n", pf.blocks[pi].header, len(variants))
+			for _, v := range variants {
+				fmt.Fprintf(w, "---\n%v", v)
+			}
+		}
+	}
+
+	sort.SliceStable(pf.diagnostics, func(i, j int) bool {
+		di, dj := pf.diagnostics[i], pf.diagnostics[j]
+		return di.Line < dj.Line || di.Line == dj.Line && di.Column < dj.Column
+	})
+	return pf.diagnostics, nil
+}
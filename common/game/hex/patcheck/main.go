@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// patcheck validates a pattern file and reports all problems found in it, with
+// their lines and columns. It exits with a non-zero status if the file contains
+// errors.
+func main() {
+	// Read flags
+	pPatternsFile := flag.String("patterns", "patterns.txt", "File with hex patterns")
+	pPrint := flag.Bool("print", false, "Print all variants of each pattern")
+	pWarnings := flag.Bool("warnings", true, "Report warnings")
+	flag.Parse()
+	patternsFile, print, warnings := *pPatternsFile, *pPrint, *pWarnings
+	if flag.NArg() > 0 {
+		patternsFile = flag.Arg(0)
+	}
+
+	var diagnostics []hex.PatternDiagnostic
+	var err error
+	if print {
+		diagnostics, err = hex.CheckPatternFile(patternsFile, os.Stdout)
+	} else {
+		diagnostics, err = hex.CheckPatternFile(patternsFile, nil)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	numErrors, numWarnings := 0, 0
+	for _, d := range diagnostics {
+		if d.Warning {
+			numWarnings++
+			if !warnings {
+				continue
+			}
+		} else {
+			numErrors++
+		}
+		fmt.Fprintln(os.Stderr, d)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d errors, %d warnings\n", patternsFile, numErrors, numWarnings)
+	if numErrors > 0 {
+		os.Exit(1)
+	}
+}
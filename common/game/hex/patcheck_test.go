@@ -0,0 +1,77 @@
+package hex
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckPatternFile checks that the default pattern file has no problems
+func TestCheckPatternFile(t *testing.T) {
+	diagnostics, err := CheckPatternFile("patterns.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diagnostics {
+		t.Error(d)
+	}
+}
+
+// TestPatternDiagnostics checks problems found in invalid pattern files
+func TestPatternDiagnostics(t *testing.T) {
+	testCases := []struct {
+		file    string
+		line    int
+		column  int
+		warning bool
+		message string
+	}{
+		// Empty lines are skipped
+		{"### 0\n---\n* .\n\n . *\n", 0, 0, false, ""},
+		{"### 0\n---\n* .\n . x\n", 4, 4, false, "Invalid symbol 'x'"},
+		{"* .\n", 1, 1, false, "Expected '###'"},
+		{"### 0\n* .\n", 2, 1, false, "Expected '---'"},
+		{"### 0\n---\n* .\nexclude\n", 4, 1, false, "must precede"},
+		{"### 0\n---\n* . .\n . *\n", 4, 0, false, "Row has 2 cells"},
+		{"### 0\n---\n* .\n. *\n", 4, 0, true, "indented"},
+		{"### 1\n---\n*\n", 1, 1, true, "does not match its index"},
+		{"### 0\n", 1, 0, false, "has no forms"},
+		{"### 0\n---\n### 1\n---\n*\n", 2, 0, false, "no rows"},
+		{"### 0\n---\n? ?\n", 2, 0, false, "without definite cells"},
+		{"### 0\nsymmetric\n---\n* +\n", 3, 0, false, "cannot be symmetric"},
+		{"### 0\n---\n* ? *\n", 3, 0, true, "separated definite parts"},
+		// The second form is the first one rotated by 180 degrees
+		{"### 0\n---\n* .\n . /\n---\n/ .\n . *\n", 5, 0, true, "not needed"},
+		// The second form is the first one rotated by 60 degrees
+		{"### 0\n---\n* .\n . /\n---\n? *\n . .\n  / ?\n", 0, 0, false, ""},
+		{"### 0\n---\n* .\n . /\n---\n* /\n . .\n", 5, 0, false, "not a rotation"},
+		{"### 0\n---\n* *\n### 1\n---\n* *\n", 4, 0, true, "variant of pattern 0"},
+		{"", 0, 0, false, "no patterns"},
+		{"\n  \n", 0, 0, false, "no patterns"},
+		// The reflected pattern, a vertical pair of cells, is missing
+		{"### 0\n---\n* .\n", 0, 0, false, "counterpart"},
+	}
+
+	for i, tc := range testCases {
+		pf, err := parsePatternFile(strings.NewReader(tc.file), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pf.buildPatterns()
+		if tc.message == "" {
+			if len(pf.diagnostics) > 0 {
+				t.Errorf("Test case %d: unexpected problems %v", i, pf.diagnostics)
+			}
+			continue
+		}
+		found := false
+		for _, d := range pf.diagnostics {
+			if d.Line == tc.line && d.Column == tc.column && d.Warning == tc.warning && strings.Contains(d.Message, tc.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Test case %d: expected a problem '%s' on %d:%d (warning: %v), got %v",
+				i, tc.message, tc.line, tc.column, tc.warning, pf.diagnostics)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package hex
+
+// This file implements compiled pattern matching. Each variant of a pattern is
+// compiled into a list of its definite cells. The first cell is the anchor: a
+// cell of the player's stone, if the pattern has one. Occurrences of anchored
+// patterns are searched for only around stones on the grid, instead of on all
+// positions of the grid.
+//
+// Counts of patterns can also be updated incrementally (see PatternCounts).
+// When a cell changes, only the occurrences of patterns that cover that cell
+// are checked again.
+//
+// Results are the same as results of countPatternsInGrid, which compares
+// patterns with whole lines of the grid.
+
+// ---------------------------
+// |     compiledPattern     |
+// ---------------------------
+
+// compiledPattern is a variant of a pattern compiled for matching
+//	index is the index of the pattern
+//	w, h are the width and the height of the pattern
+//	cells are the definite cells of the pattern, the anchor is the first one
+//	anchored is true if the anchor is the player's stone
+//	excluded is true if rows and columns where the pattern is found do not
+//		count as occupied
+//	edges is true if the pattern contains edge cells
+type compiledPattern struct {
+	index    int
+	w, h     int
+	cells    []patternCell
+	anchored bool
+	excluded bool
+	edges    bool
+}
+
+// compilePatterns compiles all variants of patterns
+func compilePatterns(patterns [][]*pattern) [][]*compiledPattern {
+	compiled := make([][]*compiledPattern, len(patterns))
+	for pi, variants := range patterns {
+		compiled[pi] = make([]*compiledPattern, len(variants))
+		for vi, p := range variants {
+			compiled[pi][vi] = compilePattern(pi, p)
+		}
+	}
+	return compiled
+}
+
+// compilePattern compiles a variant p of pattern with index pi
+func compilePattern(pi int, p *pattern) *compiledPattern {
+	cp := &compiledPattern{
+		index:    pi,
+		w:        p.w,
+		h:        p.h,
+		cells:    getDefiniteCells(p.pat),
+		excluded: p.excluded,
+		edges:    p.edges,
+	}
+	for i, c := range cp.cells {
+		if c.ct == cellPlayer {
+			cp.cells[0], cp.cells[i] = cp.cells[i], cp.cells[0]
+			cp.anchored = true
+			break
+		}
+	}
+	return cp
+}
+
+// fits returns true if the pattern, placed with its upper left corner on
+// (xStart, yStart), lies on a grid of size size. Patterns with edge cells may
+// also cover the frame of cells around the grid.
+func (cp *compiledPattern) fits(xStart, yStart, size int) bool {
+	start, end := 0, size
+	if cp.edges {
+		start, end = -1, size+1
+	}
+	return xStart >= start && yStart >= start && xStart+cp.w <= end && yStart+cp.h <= end
+}
+
+// matchAt returns the player for whom the pattern, placed with its upper left
+// corner on (xStart, yStart), matches the grid. It returns None if the pattern
+// does not match. The pattern must fit on the grid.
+func (cp *compiledPattern) matchAt(grid [][]uint64, xStart, yStart int) Color {
+	if cp.anchored {
+		// The player's stone must be on the grid
+		ax, ay := xStart+cp.cells[0].x, yStart+cp.cells[0].y
+		if ax < 0 || ay < 0 || ax >= len(grid) || ay >= len(grid) {
+			return None
+		}
+		player := getCellInRow(grid[ay], byte(ax))
+		if player == None || !cp.matchesFor(grid, xStart, yStart, player) {
+			return None
+		}
+		return player
+	}
+	for _, player := range [2]Color{Red, Blue} {
+		if cp.matchesFor(grid, xStart, yStart, player) {
+			return player
+		}
+	}
+	return None
+}
+
+// matchesFor returns true if the pattern, placed with its upper left corner on
+// (xStart, yStart), matches the grid for player. Cells beyond the grid are
+// treated as in matchesCellByCell.
+func (cp *compiledPattern) matchesFor(grid [][]uint64, xStart, yStart int, player Color) bool {
+	size := len(grid)
+	for _, c := range cp.cells {
+		gx, gy := xStart+c.x, yStart+c.y
+		outX, outY := gx < 0 || gx >= size, gy < 0 || gy >= size
+		if !outX && !outY {
+			color := getCellInRow(grid[gy], byte(gx))
+			switch c.ct {
+			case cellEmpty:
+				if color != None {
+					return false
+				}
+			case cellPlayer:
+				if color != player {
+					return false
+				}
+			case cellOpponent:
+				if color != player.Opponent() {
+					return false
+				}
+			default:
+				return false
+			}
+			continue
+		}
+		edge := None
+		if outX && !outY {
+			edge = Blue
+		} else if outY && !outX {
+			edge = Red
+		}
+		if !(c.ct == cellOwnEdge && edge == player || c.ct == cellOpponentEdge && edge == player.Opponent()) {
+			return false
+		}
+	}
+	return true
+}
+
+// -------------------------
+// |     PatternCounts     |
+// -------------------------
+
+// PatternCounts holds counts of patterns in a state, which can be updated
+// incrementally when stones are added or removed. A pattern is counted when
+// its count is requested for the first time (see Get) and only such patterns
+// are updated afterwards. A PatternCounts is not safe for concurrent use.
+//	pm is the PatternMatcher whose patterns are counted
+//	state is a copy of the state in which patterns are counted
+//	tracked tells for each pattern whether it has been counted
+//	counts are the numbers of occurrences of each pattern for the red (index
+//		0) and the blue (index 1) player
+//	rows and cols are the numbers of occurrences of each pattern (that is not
+//		excluded) that cover each row and column, for each player. The count
+//		for pattern pi and row y is on index pi*size+y.
+//	stones are the stones of each player in state, which are possible anchors
+//		of patterns. They are nil if not known.
+type PatternCounts struct {
+	pm      *PatternMatcher
+	state   State
+	tracked []bool
+	counts  [2][]int
+	rows    [2][]int
+	cols    [2][]int
+	stones  *[2][][2]int
+}
+
+// NewCounts returns counts of patterns in State s, which can be updated
+// incrementally. Changes of s after the call do not affect the counts.
+func (pm *PatternMatcher) NewCounts(s State) *PatternCounts {
+	return pm.newCounts(s.Clone().(State))
+}
+
+// newCounts returns counts of patterns in State s, s is not copied
+func (pm *PatternMatcher) newCounts(s State) *PatternCounts {
+	numPat, size := len(pm.compiled), int(s.size)
+	pc := &PatternCounts{pm: pm, state: s, tracked: make([]bool, numPat)}
+	for p := 0; p < 2; p++ {
+		pc.counts[p] = make([]int, numPat)
+		pc.rows[p] = make([]int, numPat*size)
+		pc.cols[p] = make([]int, numPat*size)
+	}
+	return pc
+}
+
+// track counts all occurrences of pattern with index pi. Its counts are
+// updated from then on.
+func (pc *PatternCounts) track(pi int) {
+	grid, size := pc.state.grid, int(pc.state.size)
+	if pc.stones == nil {
+		pc.stones = &[2][][2]int{}
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				switch getCellInRow(grid[y], byte(x)) {
+				case Red:
+					pc.stones[0] = append(pc.stones[0], [2]int{x, y})
+				case Blue:
+					pc.stones[1] = append(pc.stones[1], [2]int{x, y})
+				}
+			}
+		}
+	}
+
+	pc.tracked[pi] = true
+	for _, cp := range pc.pm.compiled[pi] {
+		if !cp.anchored {
+			for yStart := -1; yStart <= size; yStart++ {
+				for xStart := -1; xStart <= size; xStart++ {
+					if cp.fits(xStart, yStart, size) {
+						pc.record(cp, xStart, yStart, cp.matchAt(grid, xStart, yStart), 1)
+					}
+				}
+			}
+			continue
+		}
+		a := cp.cells[0]
+		for p := 0; p < 2; p++ {
+			for _, st := range pc.stones[p] {
+				xStart, yStart := st[0]-a.x, st[1]-a.y
+				if cp.fits(xStart, yStart, size) {
+					pc.record(cp, xStart, yStart, cp.matchAt(grid, xStart, yStart), 1)
+				}
+			}
+		}
+	}
+}
+
+// forSubset calls f for each index of a pattern in subset, or for all patterns
+// if subset is nil. Indices out of range are skipped.
+func (pm *PatternMatcher) forSubset(subset []int, f func(pi int)) {
+	if subset == nil {
+		for pi := range pm.compiled {
+			f(pi)
+		}
+		return
+	}
+	for _, pi := range subset {
+		if pi >= 0 && pi < len(pm.compiled) {
+			f(pi)
+		}
+	}
+}
+
+// record adds delta to the count of pattern cp, placed with its upper left
+// corner on (xStart, yStart), for player. Nothing is recorded if player is
+// None.
+func (pc *PatternCounts) record(cp *compiledPattern, xStart, yStart int, player Color, delta int) {
+	p := 0
+	switch player {
+	case Red:
+	case Blue:
+		p = 1
+	default:
+		return
+	}
+	pc.counts[p][cp.index] += delta
+	if cp.excluded {
+		return
+	}
+	size := int(pc.state.size)
+	for x := xStart; x < xStart+cp.w; x++ {
+		if x >= 0 && x < size {
+			pc.cols[p][cp.index*size+x] += delta
+		}
+	}
+	for y := yStart; y < yStart+cp.h; y++ {
+		if y >= 0 && y < size {
+			pc.rows[p][cp.index*size+y] += delta
+		}
+	}
+}
+
+// AddStone adds a stone of player c on the empty cell (x, y) and updates the
+// counts
+func (pc *PatternCounts) AddStone(x, y byte, c Color) {
+	pc.setCell(x, y, c)
+}
+
+// RemoveStone removes the stone from cell (x, y) and updates the counts
+func (pc *PatternCounts) RemoveStone(x, y byte) {
+	pc.setCell(x, y, None)
+}
+
+// Update updates the counts to State s, which must be of the same size. Only
+// cells where s differs from the last counted state are checked, so updating is
+// fast if s differs in a few stones.
+func (pc *PatternCounts) Update(s State) {
+	pc.state.lastAction = s.lastAction
+	for y, row := range s.grid {
+		for w, word := range row {
+			if word == pc.state.grid[y][w] {
+				continue
+			}
+			for i := 0; i < cellsPerWord; i++ {
+				x := byte(w*cellsPerWord + i)
+				if int(x) >= len(s.grid) {
+					break
+				}
+				if c := getCellInRow(row, x); c != pc.state.getColorOn(x, byte(y)) {
+					pc.setCell(x, byte(y), c)
+				}
+			}
+		}
+	}
+}
+
+// setCell sets cell (x, y) to color c (None removes a stone) and updates the
+// counts of all patterns that cover the cell
+func (pc *PatternCounts) setCell(x, y byte, c Color) {
+	pc.stones = nil
+	pc.countAround(int(x), int(y), -1)
+	if pc.state.getColorOn(x, y) != None {
+		pc.state.clearCell(x, y)
+	}
+	if c != None {
+		pc.state.setCell(x, y, c)
+	}
+	pc.countAround(int(x), int(y), 1)
+}
+
+// countAround adds delta to the counts of all occurrences of tracked patterns
+// that have a definite cell on (x, y). Occurrences that cannot match the
+// current color of the cell are skipped.
+func (pc *PatternCounts) countAround(x, y, delta int) {
+	size := int(pc.state.size)
+	empty := pc.state.getColorOn(byte(x), byte(y)) == None
+	for pi, variants := range pc.pm.compiled {
+		if !pc.tracked[pi] {
+			continue
+		}
+		for _, cp := range variants {
+			for _, c := range cp.cells {
+				xStart, yStart := x-c.x, y-c.y
+				if c.ct.isEdge() || (c.ct == cellEmpty) != empty || !cp.fits(xStart, yStart, size) {
+					continue
+				}
+				pc.record(cp, xStart, yStart, cp.matchAt(pc.state.grid, xStart, yStart), delta)
+			}
+		}
+	}
+}
+
+// GetFeatures returns values of attributes of learning samples (see
+// PatternMatcher.GetAttributes) in the last counted state. If subset is not
+// nil, only patterns with listed indices are counted, as in Get. If mirrored
+// is true, values are given for the state with swapped roles of the players.
+// The last player is the one from the state given to NewCounts or Update.
+func (pc *PatternCounts) GetFeatures(subset []int, mirrored bool) []float64 {
+	return pc.pm.attributes.GetFeatures(&EvalContext{pc.state, pc.Get(subset)}, mirrored)
+}
+
+// Get returns the counts in the same format as PatternMatcher.Count. If subset
+// is not nil, only patterns with listed indices are included. Patterns that
+// have not been counted yet are counted.
+func (pc *PatternCounts) Get(subset []int) [2][]int {
+	numPat, size := len(pc.pm.compiled), int(pc.state.size)
+	var results [2][]int
+	for p := 0; p < 2; p++ {
+		results[p] = make([]int, numPat+2)
+		occRows, occCols := make([]bool, size), make([]bool, size)
+		pc.pm.forSubset(subset, func(pi int) {
+			if !pc.tracked[pi] {
+				pc.track(pi)
+			}
+			results[p][pi] = pc.counts[p][pi]
+			for s := 0; s < size; s++ {
+				occRows[s] = occRows[s] || pc.rows[p][pi*size+s] > 0
+				occCols[s] = occCols[s] || pc.cols[p][pi*size+s] > 0
+			}
+		})
+		// Last two numbers are the numbers of occupied rows and columns
+		for s := 0; s < size; s++ {
+			if occRows[s] {
+				results[p][numPat]++
+			}
+			if occCols[s] {
+				results[p][numPat+1]++
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,138 @@
+package hex
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// getRandomState returns a state of size size with numStones stones of
+// alternating colors on random cells
+func getRandomState(r *rand.Rand, size byte, numStones int) *State {
+	state := NewState(size, Red)
+	for n := 0; n < numStones; n++ {
+		x, y := byte(r.Intn(int(size))), byte(r.Intn(int(size)))
+		if state.IsCellEmpty(x, y) {
+			state.setCell(x, y, []Color{Red, Blue}[n%2])
+		}
+	}
+	return state
+}
+
+// TestCompiledPatterns checks that compiled patterns give the same counts as
+// patterns that are compared with whole lines of the grid
+func TestCompiledPatterns(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		size := byte(r.Intn(10) + 2)
+		state := getRandomState(r, size, r.Intn(int(size)*int(size)))
+		var subset []int
+		if i%2 == 1 {
+			subset = []int{0, 1, 4, 11, 17, 24}
+		}
+		expected := countPatternsInGrid(pm.patterns, state.GetCopyGrid(), subset)
+		if got := pm.Count(*state, subset); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Fatalf("Expected %v, got %v (subset %v)\n%v", expected, got, subset, state)
+		}
+	}
+}
+
+// TestPatternCountsIncremental checks that counts updated after each added or
+// removed stone equal counts in the resulting state
+func TestPatternCountsIncremental(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(2))
+	state := NewState(11, Red)
+	pc := pm.NewCounts(*state)
+	pc.Get([]int{1}) // Other patterns are counted later
+	for i := 0; i < 300; i++ {
+		x, y := byte(r.Intn(11)), byte(r.Intn(11))
+		if state.IsCellEmpty(x, y) {
+			c := []Color{Red, Blue}[r.Intn(2)]
+			state.setCell(x, y, c)
+			pc.AddStone(x, y, c)
+		} else {
+			state.clearCell(x, y)
+			pc.RemoveStone(x, y)
+		}
+		if expected, got := pm.Count(*state, nil), pc.Get(nil); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Fatalf("Step %d: expected %v, got %v\n%v", i, expected, got, state)
+		}
+	}
+
+	// Update finds the changed cells
+	other := getRandomState(r, 11, 40)
+	pc.Update(*other)
+	if expected, got := pm.Count(*other, nil), pc.Get(nil); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v after update, got %v\n%v", expected, got, other)
+	}
+	expected := pm.attributes.GetFeatures(&EvalContext{*other, pm.Count(*other, nil)}, false)
+	if got := pc.GetFeatures(nil, false); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Expected features %v after update, got %v\n%v", expected, got, other)
+	}
+}
+
+// getBenchmarkState returns an 11x11 state in the middle of a game
+func getBenchmarkState() *State {
+	return getRandomState(rand.New(rand.NewSource(3)), 11, 40)
+}
+
+func BenchmarkCountPatternsInGrid(b *testing.B) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	state := getBenchmarkState()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		countPatternsInGrid(pm.patterns, state.GetCopyGrid(), nil)
+	}
+}
+
+func BenchmarkPatternMatcherCount(b *testing.B) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	state := getBenchmarkState()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pm.Count(*state, nil)
+	}
+}
+
+func BenchmarkPatternCountsAddStone(b *testing.B) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	state := getBenchmarkState()
+	pc := pm.NewCounts(*state)
+	pc.Get(nil) // Count all patterns, so that they are updated
+	empty := make([][2]byte, 0)
+	for y := byte(0); y < 11; y++ {
+		for x := byte(0); x < 11; x++ {
+			if state.IsCellEmpty(x, y) {
+				empty = append(empty, [2]byte{x, y})
+			}
+		}
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		// Add a stone, get the counts and take the stone back, as at a leaf
+		// of the search
+		c := empty[n%len(empty)]
+		pc.AddStone(c[0], c[1], Red)
+		pc.Get(nil)
+		pc.RemoveStone(c[0], c[1])
+	}
+}
@@ -1,28 +1,67 @@
 package hex
 
 import (
-	"bufio"
 	"fmt"
-	"log"
 	"os"
-	"strings"
 )
 
 // This file provides functions for pattern matching in hex grids. Grids must be
-// represented as lists of integers, where one integer represents one row, and
-// each two bits in an integer represent one column. Patterns must be
+// represented as lists of rows, where one row is a slice of uint64 words, and
+// each two bits in a row represent one column (see State). Patterns must be
 // represented as 2D slices.
+//
+// Patterns may contain cells beyond the grid that belong to the player's or to
+// the opponent's edge. Such patterns are matched cell by cell on the grid and
+// the frame of cells around it (one cell wide), while other patterns are
+// compared with whole lines of the grid (unless a line of a pattern contains
+// separated definite parts).
 
 // -------------------
 // |     pattern     |
 // -------------------
 
 type pattern struct {
-	w, h     int          // width and heigth of the pattern
-	pat      [][]cellType // pattern
-	bounds   [][2]uint    // [[start of pattern in that line, length of pattern in that line], ...]
-	match    [2][]uint32  // how should a line be to match red/blue player
-	excluded bool         // true if rows and columns where this pattern is found do not count as occupied, false otherwise
+	w, h       int          // width and heigth of the pattern
+	pat        [][]cellType // pattern
+	bounds     [][2]uint    // [[start of pattern in that line, length of pattern in that line], ...]
+	match      [2][]uint64  // how should a line be to match red/blue player
+	excluded   bool         // true if rows and columns where this pattern is found do not count as occupied, false otherwise
+	edges      bool         // true if the pattern contains edge cells, false otherwise
+	cellByCell bool         // true if the pattern is matched cell by cell (see matchesCellByCell), false otherwise
+}
+
+// newPattern returns a pattern with given rows. Rows that are shorter than the
+// longest one are filled with indefinite cells.
+func newPattern(rows [][]cellType, excluded bool) *pattern {
+	p := &pattern{
+		h:        len(rows),
+		pat:      make([][]cellType, len(rows)),
+		bounds:   make([][2]uint, 0, len(rows)),
+		excluded: excluded,
+	}
+	for _, row := range rows {
+		if len(row) > p.w {
+			p.w = len(row)
+		}
+	}
+	for y, row := range rows {
+		p.pat[y] = make([]cellType, p.w)
+		for x := range p.pat[y] {
+			p.pat[y][x] = cellIndefinite
+			if x < len(row) {
+				p.pat[y][x] = row[x]
+			}
+			p.edges = p.edges || p.pat[y][x].isEdge()
+		}
+		if p.setBoundsOfLine(p.pat[y]) {
+			p.cellByCell = true
+		}
+	}
+	p.cellByCell = p.cellByCell || p.edges
+	if !p.cellByCell {
+		p.setMatches()
+	}
+	return p
 }
 
 func (p *pattern) String() string {
@@ -36,13 +75,15 @@ func (p *pattern) String() string {
 		}
 		s += "\n"
 	}
-	return fmt.Sprintf("%ssize: (%d, %d), excluded: %v\nbounds: %v\nmatch: %v\n",
-		s, p.w, p.h, p.excluded, p.bounds, p.match)
+	return fmt.Sprintf("%ssize: (%d, %d), excluded: %v, edges: %v, cell by cell: %v\nbounds: %v\nmatch: %v\n",
+		s, p.w, p.h, p.excluded, p.edges, p.cellByCell, p.bounds, p.match)
 }
 
-// setBoundsOfLineInPat sets the range of a line that contains definite cells
-// NOTE: A line must NOT contain two separated definite parts, such as [* . ? *]
-func (p *pattern) setBoundsOfLine(line []cellType) {
+// setBoundsOfLineInPat sets the range of a line that contains definite cells.
+// It returns true if the line contains two separated definite parts, such as
+// [* . ? *]. Such patterns cannot be compared with whole lines of the grid and
+// are matched cell by cell.
+func (p *pattern) setBoundsOfLine(line []cellType) bool {
 	start, length := 0, 0
 	for ; start < len(line) && line[start] == cellIndefinite; start++ {
 	}
@@ -50,13 +91,19 @@ func (p *pattern) setBoundsOfLine(line []cellType) {
 		length++
 	}
 	p.bounds = append(p.bounds, [2]uint{2 * uint(start), 2 * uint(length)})
+	for i := start + length; i < len(line); i++ {
+		if line[i] != cellIndefinite {
+			return true
+		}
+	}
+	return false
 }
 
 // setMatches sets an exact patterns that match red and blue player
 func (p *pattern) setMatches() {
 	player := Red
 	for pl := 0; pl <= 1; pl++ {
-		matches := make([]uint32, 0, 3)
+		matches := make([]uint64, 0, 3)
 		for line := 0; line < len(p.pat); line++ {
 			matches = append(matches, p.getLineForCoparison(line, player))
 		}
@@ -67,11 +114,11 @@ func (p *pattern) setMatches() {
 
 // getLineForComparison returns an exact pattern of a line that matches the
 // specified player
-func (p *pattern) getLineForCoparison(lineIndex int, c Color) uint32 {
+func (p *pattern) getLineForCoparison(lineIndex int, c Color) uint64 {
 	line := p.pat[lineIndex]
 	bounds := p.bounds[lineIndex]
 	start, length := bounds[0]/2, bounds[1]/2
-	r := uint32(0)
+	r := uint64(0)
 	for x := int(start+length) - 1; x >= int(start); x-- {
 		r = r << 2
 		if line[x] == cellPlayer {
@@ -108,10 +155,12 @@ type cellType byte
 
 // enum for player types
 const (
-	cellEmpty      cellType = 0
-	cellPlayer     cellType = 1
-	cellOpponent   cellType = 2
-	cellIndefinite cellType = 3
+	cellEmpty        cellType = 0
+	cellPlayer       cellType = 1
+	cellOpponent     cellType = 2
+	cellIndefinite   cellType = 3
+	cellOwnEdge      cellType = 4
+	cellOpponentEdge cellType = 5
 )
 
 func (ct cellType) String() string {
@@ -124,119 +173,141 @@ func (ct cellType) String() string {
 		return "/"
 	case cellIndefinite:
 		return "?"
+	case cellOwnEdge:
+		return "+"
+	case cellOpponentEdge:
+		return "="
 	default:
 		return "?"
 	}
 }
 
-func getCellTypeFromString(s string) cellType {
+// isEdge returns true if a cell type is one of the edge types
+func (ct cellType) isEdge() bool {
+	return ct == cellOwnEdge || ct == cellOpponentEdge
+}
+
+// getCellTypeFromString returns the cell type denoted by symbol s. The second
+// return value is false if s is not a valid symbol.
+func getCellTypeFromString(s string) (cellType, bool) {
 	switch s {
 	case ".": // Empty cell
-		return cellEmpty
+		return cellEmpty, true
 	case "/": // Opponent's color
-		return cellOpponent
+		return cellOpponent, true
 	case "?": // Cell state not important
-		return cellIndefinite
+		return cellIndefinite, true
 	case "*": // Player's color
-		return cellPlayer
+		return cellPlayer, true
+	case "+": // Beyond the grid, on the player's edge
+		return cellOwnEdge, true
+	case "=": // Beyond the grid, on the opponent's edge
+		return cellOpponentEdge, true
 	default:
-		fmt.Println(fmt.Errorf("Invalid character '%s' in pattern", s))
-		return cellIndefinite
+		return cellIndefinite, false
 	}
 }
 
-// ----------------------
-// |     PatChecker     |
-// ----------------------
-
-// CreatePatChecker creates a go routine that will serach for patterns in grids.
-// It returns channels for communicatin with this goroutine.
-func CreatePatChecker(fileName string) (chan []uint32, chan []int, chan struct{}, chan [2][]int) {
-	gridChan := make(chan []uint32, 1)
-	patChan := make(chan []int, 1)
-	stopChan := make(chan struct{}, 1)
-	resultChan := make(chan [2][]int, 1)
-
-	go patChecker(fileName, gridChan, patChan, stopChan, resultChan)
-
-	return gridChan, patChan, stopChan, resultChan
+// --------------------------
+// |     PatternMatcher     |
+// --------------------------
+
+// PatternMatcher counts patterns, read from a file, in hex grids. It also
+// counts in how many rows and columns each player has at least one stone or
+// virtual connection. A PatternMatcher is not changed after it is loaded, so it
+// is safe for concurrent use.
+type PatternMatcher struct {
+	patterns     [][]*pattern         // Patterns with all their variants
+	compiled     [][]*compiledPattern // Compiled variants of patterns (see compilePatterns)
+	counterparts []int                // Indices of color-swapped counterparts of patterns
+	attributes   *AttributeSet        // Attributes of learning samples (see newSampleAttributes)
 }
 
-// readPatternsFromFile reads all patterns from a specified file and returns a
-// 2D slice of patterns. The first dimension is a pattern, the second dimension
-// are all rotations of that pattern.
-func readPatternsFromFile(fileName string) ([][]*pattern, error) {
-	f, err := os.Open(fileName)
+// Load reads patterns from a file (see readPatternsFromFile) and returns a
+// PatternMatcher that counts them
+func Load(fileName string) (*PatternMatcher, error) {
+	patterns, counterparts, err := readPatternsFromFile(fileName)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	patterns := make([][]*pattern, 0, 25)
-	patC, rotC, lineC := -1, -1, 0 // Counters of patterns, rotations for each pattern, and lines in each pattern
-	exclude := false
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineSplit := strings.Fields(line)
-		if lineSplit[0] == "###" {
-			exclude = false
-			patC++
-			rotC = -1
-			patterns = append(patterns, make([]*pattern, 0, 1))
-		} else if lineSplit[0] == "---" {
-			rotC++
-			lineC = 0
-			patterns[patC] = append(patterns[patC], &pattern{
-				pat:      make([][]cellType, 0, 3),
-				bounds:   make([][2]uint, 0, 3),
-				match:    [2][]uint32{make([]uint32, 0, 3), make([]uint32, 0, 3)},
-				excluded: exclude,
-			})
-		} else if lineSplit[0] == "exclude" {
-			exclude = true
-		} else {
-			val, w := lineToNumber(lineSplit)
-			pattern := patterns[patC][rotC]
-			pattern.pat = append(pattern.pat, val)
-			pattern.w = w // Necessary only once, but easier that way
-			pattern.h++
-			pattern.setBoundsOfLine(val)
-			lineC++
-		}
+	pm := &PatternMatcher{
+		patterns:     patterns,
+		compiled:     compilePatterns(patterns),
+		counterparts: counterparts,
 	}
+	pm.attributes = newSampleAttributes(pm)
+	return pm, nil
+}
 
-	if err = scanner.Err(); err != nil {
-		return nil, err
-	}
+// GetNumPatterns returns the number of patterns (without their variants)
+func (pm *PatternMatcher) GetNumPatterns() int {
+	return len(pm.patterns)
+}
 
-	for _, p := range patterns {
-		for _, r := range p {
-			r.setMatches()
-		}
+// GetCounterpart returns the index of the pattern that matches the same
+// subgrids as pattern with index patternIndex, but with swapped roles of the
+// red and the blue player
+func (pm *PatternMatcher) GetCounterpart(patternIndex int) int {
+	return pm.counterparts[patternIndex]
+}
+
+// WithCellPlanes returns a PatternMatcher that counts the same patterns as pm,
+// but whose learning samples also include a plane of stones of each player on
+// a board of a given size (see AttrCellPlane). Samples can then be computed
+// only for states of that size.
+func (pm *PatternMatcher) WithCellPlanes(size int) *PatternMatcher {
+	npm := *pm
+	npm.attributes = newSampleAttributes(pm)
+	for _, pair := range NewAttrCellPlanes(size) {
+		npm.attributes.RegisterVector(pair[0], pair[1])
 	}
+	return &npm
+}
 
-	return patterns, nil
+// GetAttributes returns attributes of learning samples, which include counts
+// of the patterns of pm
+func (pm *PatternMatcher) GetAttributes() *AttributeSet {
+	return pm.attributes
 }
 
-// lineToNumber converts ASCII characters that represent a line in a format
-// needed.
-func lineToNumber(lineSplit []string) ([]cellType, int) {
-	num := make([]cellType, len(lineSplit))
-	width := len(num)
+// Count returns the number of occurrences of each pattern in State s for the
+// red (index 0) and the blue player (index 1), followed by the number of rows
+// and columns occupied by each player. If subset is not nil, only patterns
+// with listed indices are counted, counts of other patterns are 0.
+func (pm *PatternMatcher) Count(s State, subset []int) [2][]int {
+	return pm.newCounts(s).Get(subset)
+}
 
-	for i, ls := range lineSplit {
-		num[i] = getCellTypeFromString(ls)
+// readPatternsFromFile reads all patterns from a specified file and returns a
+// 2D slice of patterns. The first dimension is a pattern, the second dimension
+// are all variants of that pattern (see getPatternVariants). It also returns
+// the index of the color-swapped counterpart of each pattern (see
+// getPatternCounterparts).
+//
+// The format of the file is described in patcheck.go. An error is returned if
+// the file contains any errors (see CheckPatternFile), warnings are ignored.
+func readPatternsFromFile(fileName string) ([][]*pattern, []int, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer f.Close()
 
-	return num, width
+	pf, err := parsePatternFile(f, fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	patterns, counterparts := pf.buildPatterns()
+	if err = pf.err(); err != nil {
+		return nil, nil, err
+	}
+	return patterns, counterparts, nil
 }
 
 // countPatternsInGrid counts how many occurences the given pattern (with given
 // rotation) has in the grid. It also counts how many rows and columns each
 // player has occupied.
-func countPatternsInGrid(patterns [][]*pattern, grid []uint32, usedPat []int) [2][]int {
+func countPatternsInGrid(patterns [][]*pattern, grid [][]uint64, usedPat []int) [2][]int {
 	var results [2][]int
 	// Last two numbers mean number of rows and columns (respectively) occupied by a player
 	results[0] = make([]int, len(patterns)+2) // Counts for red
@@ -260,14 +331,26 @@ func countPatternsInGrid(patterns [][]*pattern, grid []uint32, usedPat []int) [2
 				continue
 			}
 		}
-		for xStart := 0; xStart <= len(grid); xStart++ {
-			for yStart := 0; yStart <= len(grid); yStart++ {
+		for xStart := -1; xStart <= len(grid); xStart++ {
+			for yStart := -1; yStart <= len(grid); yStart++ {
 				for _, r := range p {
-					if xStart+r.w > len(grid) || yStart+r.h > len(grid) {
+					// Patterns with edge cells may also cover the frame of
+					// cells around the grid
+					start, end := 0, len(grid)
+					if r.edges {
+						start, end = -1, len(grid)+1
+					}
+					if xStart < start || yStart < start || xStart+r.w > end || yStart+r.h > end {
 						continue
 					}
 					found := -1
-					matchRow, c := matches(*r, grid, xStart, yStart)
+					var matchRow int
+					var c Color
+					if r.cellByCell {
+						matchRow, c = matchesCellByCell(*r, grid, xStart, yStart)
+					} else {
+						matchRow, c = matches(*r, grid, xStart, yStart)
+					}
 					if matchRow == r.h {
 						switch c {
 						case Red:
@@ -280,10 +363,14 @@ func countPatternsInGrid(patterns [][]*pattern, grid []uint32, usedPat []int) [2
 						results[found][pi]++
 						if !r.excluded {
 							for x := xStart; x < xStart+r.w; x++ {
-								occCols[found][x] = true
+								if x >= 0 && x < len(grid) {
+									occCols[found][x] = true
+								}
 							}
 							for y := yStart; y < yStart+r.h; y++ {
-								occRows[found][y] = true
+								if y >= 0 && y < len(grid) {
+									occRows[found][y] = true
+								}
 							}
 						}
 					}
@@ -315,14 +402,13 @@ func countPatternsInGrid(patterns [][]*pattern, grid []uint32, usedPat []int) [2
 // matches checks whether a subgrid matches the given pattern.
 // The first return value tells how many rows did match the pattern.
 // The last value tells which player has a match.
-func matches(pat pattern, grid []uint32, xStart, yStart int) (int, Color) {
+func matches(pat pattern, grid [][]uint64, xStart, yStart int) (int, Color) {
 	for pl := 0; pl <= 1; pl++ {
 		match := true
 
 		for y := 0; y < pat.h; y++ {
 			patStart, patLength := pat.bounds[y][0], pat.bounds[y][1]
-			rowGrid := grid[yStart+y] >> (2*uint(xStart) + patStart)
-			rowGrid = rowGrid & ((1 << patLength) - 1)
+			rowGrid := getBitsInRow(grid[yStart+y], 2*uint(xStart)+patStart, patLength)
 			if rowGrid != pat.match[pl][y] {
 				match = false
 				break
@@ -343,29 +429,49 @@ func matches(pat pattern, grid []uint32, xStart, yStart int) (int, Color) {
 	return 0, None
 }
 
-// patChecker is a goroutine that searches for patterns in grids, sent via
-// gridChan. Results are sent via resultChan. stopChan is used to end the
-// goroutine.
-// It also checks in how many rows and columns each player has at least one
-// stoen or virtual connection
-func patChecker(filename string, gridChan chan []uint32, patChan chan []int, stopChan chan struct{}, resultChan chan [2][]int) {
-	defer close(gridChan)
-	defer close(stopChan)
-	defer close(resultChan)
-
-	patterns, err := readPatternsFromFile(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
+// matchesCellByCell checks whether a subgrid, which may include cells beyond
+// the grid, matches the given pattern. It is used for patterns with edge cells
+// and patterns with separated definite parts in a line. Cells beyond the top and the
+// bottom edge belong to the red player, cells beyond the left and the right
+// edge to the blue player. Cells beyond two edges belong to none of the
+// players. Return values are the same as in matches.
+func matchesCellByCell(pat pattern, grid [][]uint64, xStart, yStart int) (int, Color) {
+	size := len(grid)
+	for _, player := range []Color{Red, Blue} {
+		match := true
 
-	for {
-		select {
-		case grid := <-gridChan:
-			usedPats := <-patChan
-			results := countPatternsInGrid(patterns, grid, usedPats)
-			resultChan <- results
-		case <-stopChan:
-			return
+		for y := 0; y < pat.h && match; y++ {
+			for x, ct := range pat.pat[y] {
+				if ct == cellIndefinite {
+					continue
+				}
+				gx, gy := xStart+x, yStart+y
+				outX, outY := gx < 0 || gx >= size, gy < 0 || gy >= size
+				if !outX && !outY {
+					c := getCellInRow(grid[gy], byte(gx))
+					match = ct == cellEmpty && c == None ||
+						ct == cellPlayer && c == player ||
+						ct == cellOpponent && c == player.Opponent()
+				} else {
+					edge := None
+					if outX && !outY {
+						edge = Blue
+					} else if outY && !outX {
+						edge = Red
+					}
+					match = ct == cellOwnEdge && edge == player ||
+						ct == cellOpponentEdge && edge == player.Opponent()
+				}
+				if !match {
+					break
+				}
+			}
+		}
+
+		if match {
+			return pat.h, player
 		}
 	}
+
+	return 0, None
 }
@@ -0,0 +1,183 @@
+package hex
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestPatternsOnLargeBoard checks that a pattern is found regardless of where
+// it lies in a row, also when it spans two words of a row.
+func TestPatternsOnLargeBoard(t *testing.T) {
+	patterns, _, err := readPatternsFromFile("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, x := range []byte{1, 15, 31, 32, 33, 38} {
+		state := NewState(40, Red)
+		// A bridge (pattern 1) between (x, 5) and (x-1, 7)
+		for _, a := range []*Action{NewAction(x, 5, Red), NewAction(x-1, 7, Red)} {
+			state.setCell(a.x, a.y, a.c)
+		}
+		results := countPatternsInGrid(patterns, state.GetCopyGrid(), nil)
+		if results[0][0] != 2 {
+			t.Fatalf("x = %d: expected 2 red stones, got %d", x, results[0][0])
+		}
+		if results[0][1] != 1 {
+			t.Fatalf("x = %d: expected 1 red bridge, got %d", x, results[0][1])
+		}
+	}
+}
+
+/*
+. . . . . . . . .
+ . . . . . . . . .
+  . . . . . . . b .
+   r . . . . . . . .
+    . . . . . . . . .
+     . . . . r . . . .
+      . . . . . . . . .
+       . . . . r . . . .
+        . . . . . . . . .
+*/
+func TestEdgePatterns(t *testing.T) {
+	patterns, _, err := readPatternsFromFile("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const edgeBridge, oppEdge = 24, 25
+
+	state := getStateWithStones(9, [][2]int{{0, 3}, {4, 5}, {4, 7}}, [][2]int{{7, 2}})
+	results := countPatternsInGrid(patterns, state.GetCopyGrid(), nil)
+	if results[0][edgeBridge] != 1 || results[1][edgeBridge] != 1 {
+		t.Fatalf("Expected one edge bridge for each player, got %d (red) and %d (blue)",
+			results[0][edgeBridge], results[1][edgeBridge])
+	}
+	if results[0][oppEdge] != 1 || results[1][oppEdge] != 0 {
+		t.Fatalf("Expected one red stone on the opponent's edge, got %d (red) and %d (blue)",
+			results[0][oppEdge], results[1][oppEdge])
+	}
+
+	// An intrusion breaks the edge bridge of the red player
+	state.setCell(3, 8, Blue)
+	results = countPatternsInGrid(patterns, state.GetCopyGrid(), nil)
+	if results[0][edgeBridge] != 0 {
+		t.Fatalf("Expected no red edge bridges, got %d", results[0][edgeBridge])
+	}
+
+	// Patterns without edge cells are still found only inside the grid
+	if results[0][0] != 3 || results[1][0] != 2 {
+		t.Fatalf("Expected 3 red and 2 blue stones, got %d and %d", results[0][0], results[1][0])
+	}
+}
+
+// TestPatternVariants checks that variants of patterns are generated from
+// their canonical forms.
+func TestPatternVariants(t *testing.T) {
+	patterns, _, err := readPatternsFromFile("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Symmetric pattern 4 has all rotations and reflections, bridges to the
+	// edge (pattern 24) are rotated by 180 degrees and reflected
+	for pi, n := range map[int]int{0: 1, 1: 1, 2: 1, 4: 6, 17: 2, 24: 4, 25: 4} {
+		if len(patterns[pi]) != n {
+			t.Fatalf("Expected %d variants of pattern %d, got %d", n, pi, len(patterns[pi]))
+		}
+	}
+	bridge := [][]cellType{
+		{cellIndefinite, cellPlayer},
+		{cellEmpty, cellEmpty},
+		{cellPlayer, cellIndefinite},
+	}
+	if getPatternKey(patterns[1][0].pat) != getPatternKey(bridge) ||
+		getPatternKey(rotatePattern180(bridge)) != getPatternKey(bridge) {
+		t.Fatalf("Expected a single variant of the bridge, got\n%v", patterns[1][0])
+	}
+
+	// Six rotations by 60 degrees give the same pattern
+	r := patterns[4][0].pat
+	for i := 0; i < 6; i++ {
+		r = rotatePattern60(r)
+	}
+	if getPatternKey(r) != getPatternKey(patterns[4][0].pat) {
+		t.Fatalf("Expected the same pattern after six rotations, got\n%v", newPattern(r, false))
+	}
+
+	// Line with separated definite parts is matched cell by cell
+	if p := newPattern([][]cellType{{cellPlayer, cellIndefinite, cellPlayer}}, false); !p.cellByCell {
+		t.Fatalf("Pattern with separated definite parts should be matched cell by cell\n%v", p)
+	}
+	state := getStateWithStones(5, [][2]int{{0, 2}, {2, 2}, {4, 2}}, nil)
+	gapped := [][]*pattern{{newPattern([][]cellType{{cellPlayer, cellIndefinite, cellPlayer}}, false)}}
+	if results := countPatternsInGrid(gapped, state.GetCopyGrid(), nil); results[0][0] != 2 {
+		t.Fatalf("Expected 2 red matches of '* ? *', got %d", results[0][0])
+	}
+}
+
+// TestPatternCounterparts checks that color-swapped counterparts of patterns
+// are found when patterns are read.
+func TestPatternCounterparts(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Patterns that are not listed are their own counterparts
+	pairs := map[int]int{1: 3, 5: 7, 8: 10, 11: 12, 14: 15, 18: 19, 22: 23}
+	for i, j := range pairs {
+		pairs[j] = i
+	}
+	for i := 0; i < pm.GetNumPatterns(); i++ {
+		expected, ok := pairs[i]
+		if !ok {
+			expected = i
+		}
+		if c := pm.GetCounterpart(i); c != expected {
+			t.Fatalf("Expected pattern %d to be the counterpart of pattern %d, got %d", expected, i, c)
+		}
+	}
+}
+
+// TestPatternMatcherConcurrent checks that a PatternMatcher gives the same
+// results when it is used by several goroutines at once.
+func TestPatternMatcherConcurrent(t *testing.T) {
+	if _, err := Load("no_such_file.txt"); err == nil {
+		t.Fatal("Expected an error when loading a missing file")
+	}
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := []*State{
+		getStateWithStones(7, [][2]int{{2, 2}, {3, 3}, {1, 4}}, [][2]int{{3, 2}, {4, 4}}),
+		getStateWithStones(9, [][2]int{{4, 1}, {4, 2}, {3, 5}}, [][2]int{{0, 0}, {8, 8}, {5, 5}}),
+	}
+	expected := make([][2][]int, len(states))
+	for i, s := range states {
+		expected[i] = pm.Count(*s, nil)
+	}
+
+	errs := make(chan string, 10)
+	for g := 0; g < 10; g++ {
+		go func(g int) {
+			i := g % len(states)
+			if r := pm.Count(*states[i], nil); fmt.Sprint(r) != fmt.Sprint(expected[i]) {
+				errs <- fmt.Sprintf("Goroutine %d: expected %v, got %v", g, expected[i], r)
+				return
+			}
+			errs <- ""
+		}(g)
+	}
+	for g := 0; g < 10; g++ {
+		if e := <-errs; e != "" {
+			t.Fatal(e)
+		}
+	}
+
+	// Patterns that are not in the subset are not counted
+	if r := pm.Count(*states[0], []int{1}); r[0][0] != 0 || r[0][1] != expected[0][0][1] {
+		t.Fatalf("Expected only bridges (%d) to be counted, got %v", expected[0][0][1], r)
+	}
+}
@@ -0,0 +1,334 @@
+package hex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements mining of new patterns from learning samples. Around
+// each stone of a state, all shapes that consist of the stone and up to
+// maxCells other cells within a given radius are enumerated. The cells of a
+// shape are taken as they are in the state (the player's stone, the
+// opponent's stone or an empty cell). Shapes that differ only by a rotation or
+// a reflection are the same shape, so mined shapes are written as symmetric
+// patterns.
+//
+// For each shape, the number of its occurrences for the red player minus the
+// number of occurrences for the blue player is compared with the Q value of
+// the sample (which is always given for the red player). Shapes are ranked by
+// the absolute value of the correlation coefficient.
+
+// ----------------------
+// |     MinedShape     |
+// ----------------------
+
+// MinedShape is a shape found by a PatternMiner
+//	rows are the rows of the shape as it was first found
+//	Support is the number of samples in which the shape was found
+//	Correlation is the correlation coefficient between the difference of
+//		occurrences of the shape for both players and Q values of samples
+//	sumF, sumF2, sumFQ are sums of differences of occurrences, their squares
+//		and their products with Q values over all samples
+type MinedShape struct {
+	rows        [][]cellType
+	Support     int
+	Correlation float64
+	sumF        float64
+	sumF2       float64
+	sumFQ       float64
+}
+
+// WritePattern writes the shape to w in the format of a pattern file (see
+// patcheck.go), as a symmetric pattern with index index
+func (ms *MinedShape) WritePattern(w io.Writer, index int) {
+	fmt.Fprintf(w, "### %d mined, correlation %.4f, support %d\nsymmetric\n---\n", index, ms.Correlation, ms.Support)
+	for y, row := range ms.rows {
+		cells := make([]string, len(row))
+		for x, ct := range row {
+			cells[x] = ct.String()
+		}
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", y), strings.Join(cells, " "))
+	}
+}
+
+// ------------------------
+// |     PatternMiner     |
+// ------------------------
+
+// PatternMiner collects statistics of shapes in learning samples
+//	maxCells is the maximal number of cells in a shape besides the stone
+//	neighborhood are the cells within the radius around a stone (excluding
+//		the stone), relative to the stone
+//	shapes are the shapes found so far, by their canonical keys
+//	canonical caches canonical keys of shapes by their keys as found around a
+//		stone (see getShapeKey)
+//	known are canonical keys of shapes that are already among known patterns
+//	numSamples, sumQ, sumQ2 are the number of samples and the sums of their Q
+//		values and squared Q values
+type PatternMiner struct {
+	maxCells     int
+	neighborhood [][2]int
+	shapes       map[string]*MinedShape
+	canonical    map[string]string
+	known        map[string]bool
+	numSamples   int
+	sumQ         float64
+	sumQ2        float64
+}
+
+// NewPatternMiner returns a PatternMiner that enumerates shapes of up to
+// maxCells cells (besides the stone) within radius around each stone. Shapes
+// that are variants of patterns in known (which may be nil) are skipped.
+func NewPatternMiner(radius, maxCells int, known *PatternMatcher) *PatternMiner {
+	pm := &PatternMiner{
+		maxCells:     maxCells,
+		neighborhood: make([][2]int, 0),
+		shapes:       make(map[string]*MinedShape),
+		canonical:    make(map[string]string),
+		known:        make(map[string]bool),
+	}
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if d := (abs(dx) + abs(dy) + abs(dx+dy)) / 2; d > 0 && d <= radius {
+				pm.neighborhood = append(pm.neighborhood, [2]int{dx, dy})
+			}
+		}
+	}
+	if known != nil {
+		for _, variants := range known.patterns {
+			for _, v := range variants {
+				pm.known[getCanonicalKey(v.pat)] = true
+			}
+		}
+	}
+	return pm
+}
+
+// getCanonicalKey returns the smallest key (see getPatternKey) of all
+// rotations and reflections of a pattern given by rows
+func getCanonicalKey(rows [][]cellType) string {
+	key := ""
+	for _, v := range getAllSymmetries(rows) {
+		if k := getPatternKey(v); key == "" || k < key {
+			key = k
+		}
+	}
+	return key
+}
+
+// AddSample adds occurrences of shapes in State s to the statistics. q is the
+// Q value of s for the red player.
+func (pm *PatternMiner) AddSample(s State, q float64) {
+	diff := make(map[string]int)
+	size := int(s.size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := s.getColorOn(byte(x), byte(y))
+			if c == None {
+				continue
+			}
+			delta := 1
+			if c == Blue {
+				delta = -1
+			}
+			pm.forEachShape(s, x, y, c, func(key string) {
+				diff[key] += delta
+			})
+		}
+	}
+
+	pm.numSamples++
+	pm.sumQ += q
+	pm.sumQ2 += q * q
+	for key, d := range diff {
+		ms := pm.shapes[key]
+		ms.Support++
+		f := float64(d)
+		ms.sumF += f
+		ms.sumF2 += f * f
+		ms.sumFQ += f * q
+	}
+}
+
+// forEachShape calls f with the canonical key of each shape around the stone
+// of player c on (x, y). Shapes that do not lie on the grid and known shapes
+// are skipped.
+func (pm *PatternMiner) forEachShape(s State, x, y int, c Color, f func(key string)) {
+	size := int(s.size)
+	types := make([]cellType, len(pm.neighborhood)) // Types of neighboring cells, for player c
+	for i, n := range pm.neighborhood {
+		nx, ny := x+n[0], y+n[1]
+		if nx < 0 || ny < 0 || nx >= size || ny >= size {
+			types[i] = cellIndefinite
+			continue
+		}
+		switch s.getColorOn(byte(nx), byte(ny)) {
+		case None:
+			types[i] = cellEmpty
+		case c:
+			types[i] = cellPlayer
+		default:
+			types[i] = cellOpponent
+		}
+	}
+
+	chosen := make([]int, 0, pm.maxCells)
+	var choose func(start int)
+	choose = func(start int) {
+		if len(chosen) > 0 {
+			if key := pm.getShapeKey(chosen, types); key != "" {
+				f(key)
+			}
+		}
+		if len(chosen) == pm.maxCells {
+			return
+		}
+		for i := start; i < len(pm.neighborhood); i++ {
+			if types[i] != cellIndefinite {
+				chosen = append(chosen, i)
+				choose(i + 1)
+				chosen = chosen[:len(chosen)-1]
+			}
+		}
+	}
+	choose(0)
+}
+
+// getShapeKey returns the canonical key of a shape that consists of the stone
+// and chosen cells of the neighborhood with given types. It returns an empty
+// string if the shape is known. New shapes are added to the miner.
+func (pm *PatternMiner) getShapeKey(chosen []int, types []cellType) string {
+	// Indices of cells take two bytes, as the neighborhood can have more than
+	// 256 cells
+	b := make([]byte, 3*len(chosen))
+	for i, ci := range chosen {
+		b[3*i], b[3*i+1], b[3*i+2] = byte(ci>>8), byte(ci), byte(types[ci])
+	}
+	if key, ok := pm.canonical[string(b)]; ok {
+		return key
+	}
+
+	cells := make([]patternCell, 1, len(chosen)+1)
+	cells[0] = patternCell{0, 0, cellPlayer}
+	for _, ci := range chosen {
+		cells = append(cells, patternCell{pm.neighborhood[ci][0], pm.neighborhood[ci][1], types[ci]})
+	}
+	rows := cellsToRows(cells)
+	key := getCanonicalKey(rows)
+	if pm.known[key] {
+		key = ""
+	} else if _, ok := pm.shapes[key]; !ok {
+		pm.shapes[key] = &MinedShape{rows: rows}
+	}
+	pm.canonical[string(b)] = key
+	return key
+}
+
+// GetNumSamples returns the number of samples added to the miner
+func (pm *PatternMiner) GetNumSamples() int {
+	return pm.numSamples
+}
+
+// GetBestShapes returns at most n shapes that were found in at least
+// minSupport samples, sorted by the absolute value of their correlation with Q
+// values
+func (pm *PatternMiner) GetBestShapes(n, minSupport int) []*MinedShape {
+	num := float64(pm.numSamples)
+	varQ := num*pm.sumQ2 - pm.sumQ*pm.sumQ
+	best := make([]*MinedShape, 0, len(pm.shapes))
+	for _, ms := range pm.shapes {
+		if ms.Support < minSupport {
+			continue
+		}
+		varF := num*ms.sumF2 - ms.sumF*ms.sumF
+		if varF <= 0 || varQ <= 0 {
+			continue
+		}
+		ms.Correlation = (num*ms.sumFQ - ms.sumF*pm.sumQ) / math.Sqrt(varF*varQ)
+		best = append(best, ms)
+	}
+	sort.Slice(best, func(i, j int) bool {
+		ci, cj := math.Abs(best[i].Correlation), math.Abs(best[j].Correlation)
+		if ci != cj {
+			return ci > cj
+		}
+		return best[i].Support > best[j].Support
+	})
+	if len(best) > n {
+		best = best[:n]
+	}
+	return best
+}
+
+// ReadSamples reads learning samples from r (see GenSample) and adds them to
+// the miner. Only samples that are preceded by a comment '# Board <board>',
+// where the state is written in the board notation, can be used. The sample
+// that follows the comment gives the Q value of the state, the next sample is
+// its color-swapped version and is skipped. Files in the sample format (see
+// SampleReader) are read as well. It returns the number of samples that were
+// added.
+func (pm *PatternMiner) ReadSamples(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(1); err == nil && b[0] == '{' {
+		return pm.readSampleFile(br)
+	}
+
+	added := 0
+	var state *State
+	scanner := bufio.NewScanner(br)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "# Board ") {
+			s, err := ParseBoardNotation(line[len("# Board "):])
+			if err != nil {
+				return added, fmt.Errorf("Line %d: %s", lineNum, err)
+			}
+			state = s
+			continue
+		}
+		if state == nil || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.SplitN(line, ",", 2)[0], 64)
+		if err != nil {
+			return added, fmt.Errorf("Line %d: invalid Q value: %s", lineNum, err)
+		}
+		pm.AddSample(*state, q)
+		added++
+		state = nil
+	}
+	return added, scanner.Err()
+}
+
+// readSampleFile reads records from r in the sample format and adds the ones
+// that are not mirrored to the miner. It returns the number of samples that
+// were added.
+func (pm *PatternMiner) readSampleFile(r io.Reader) (int, error) {
+	added := 0
+	sr, err := NewSampleReader(r)
+	if err != nil {
+		return added, err
+	}
+	for {
+		rec, err := sr.Read()
+		if err == io.EOF {
+			return added, nil
+		} else if err != nil {
+			return added, err
+		}
+		if rec.Mirrored {
+			continue
+		}
+		s, err := rec.GetState()
+		if err != nil {
+			return added, err
+		}
+		pm.AddSample(*s, rec.Q)
+		added++
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/RdecKa/0xAI/common/game/hex"
+)
+
+// patmine finds shapes whose presence correlates with Q values of learning
+// samples and writes the best ones in the format of a pattern file. Arguments
+// are files with learning samples or folders with such files (*.in).
+func main() {
+	// Read flags
+	pRadius := flag.Int("radius", 2, "Maximal distance of cells of a shape from its stone")
+	pMaxCells := flag.Int("cells", 3, "Maximal number of cells of a shape besides its stone")
+	pTop := flag.Int("top", 20, "Number of shapes to output")
+	pMinSupport := flag.Int("minsupport", 50, "Minimal number of samples in which a shape must be found")
+	pPatternsFile := flag.String("patterns", "patterns.txt", "File with known hex patterns, which are skipped (empty for none)")
+	pOutputFile := flag.String("output", "", "Output file (standard output if empty)")
+	flag.Parse()
+	radius, maxCells, top, minSupport := *pRadius, *pMaxCells, *pTop, *pMinSupport
+	patternsFile, outputFile := *pPatternsFile, *pOutputFile
+
+	if radius < 1 || radius > 9 || maxCells < 1 {
+		fmt.Fprintln(os.Stderr, "Radius must be between 1 and 9 and the number of cells at least 1")
+		os.Exit(2)
+	}
+
+	var known *hex.PatternMatcher
+	startIndex := 0
+	if patternsFile != "" {
+		var err error
+		known, err = hex.Load(patternsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		startIndex = known.GetNumPatterns()
+	}
+	miner := hex.NewPatternMiner(radius, maxCells, known)
+
+	// Read samples
+	for _, arg := range flag.Args() {
+		files := []string{arg}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			files, _ = filepath.Glob(filepath.Join(arg, "*.in"))
+		}
+		for _, fileName := range files {
+			f, err := os.Open(fileName)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			n, err := miner.ReadSamples(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", fileName, err)
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %d samples\n", fileName, n)
+		}
+	}
+	if miner.GetNumSamples() == 0 {
+		fmt.Fprintln(os.Stderr, "No samples with boards found, samples must be generated with '# Board' comments")
+		os.Exit(1)
+	}
+
+	// Write patterns
+	out := os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		out = f
+	}
+	for i, ms := range miner.GetBestShapes(top, minSupport) {
+		ms.WritePattern(out, startIndex+i)
+	}
+}
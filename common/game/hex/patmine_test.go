@@ -0,0 +1,145 @@
+package hex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+/*
+. . . . .
+ . . . . .
+  . . r . .
+   . . . . .
+    . . . . .
+*/
+func TestPatternMinerShapes(t *testing.T) {
+	state := NewState(5, Red)
+	state.setCell(2, 2, Red)
+
+	// The stone with one empty neighbor (all six are the same shape) and with
+	// two neighbors that are adjacent, at distance 2 or opposite
+	pm := NewPatternMiner(1, 2, nil)
+	pm.AddSample(*state, 0)
+	if len(pm.shapes) != 4 {
+		t.Fatalf("Expected 4 shapes, got %d", len(pm.shapes))
+	}
+}
+
+/*
+. . . . .
+ . . r . .
+  . . . . .
+   . r . . .
+    . . . . .
+*/
+func TestPatternMinerKnownShapes(t *testing.T) {
+	state := NewState(5, Red)
+	state.setCell(2, 1, Red)
+	state.setCell(1, 3, Red)
+	bridge := getCanonicalKey([][]cellType{
+		{cellIndefinite, cellPlayer},
+		{cellEmpty, cellEmpty},
+		{cellPlayer, cellIndefinite},
+	})
+
+	// The bridge between the stones is a shape of three cells within radius 2
+	pm := NewPatternMiner(2, 3, nil)
+	pm.AddSample(*state, 0)
+	if _, ok := pm.shapes[bridge]; !ok {
+		t.Fatalf("Expected the bridge among %d shapes", len(pm.shapes))
+	}
+
+	// The bridge is pattern 1, so it is skipped when patterns are known
+	known, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pmKnown := NewPatternMiner(2, 3, known)
+	pmKnown.AddSample(*state, 0)
+	if _, ok := pmKnown.shapes[bridge]; ok {
+		t.Fatalf("The bridge should be skipped as a known pattern")
+	}
+	if len(pmKnown.shapes) >= len(pm.shapes) {
+		t.Fatalf("Expected fewer than %d shapes with known patterns, got %d", len(pm.shapes), len(pmKnown.shapes))
+	}
+}
+
+// TestPatternMinerLargeRadius checks that shapes with cells beyond the 256th
+// cell of the neighborhood are not confused with other shapes
+func TestPatternMinerLargeRadius(t *testing.T) {
+	pm := NewPatternMiner(9, 1, nil)
+	if len(pm.neighborhood) <= 257 {
+		t.Fatalf("Expected more than 257 cells in the neighborhood, got %d", len(pm.neighborhood))
+	}
+	types := make([]cellType, len(pm.neighborhood))
+	for i := range types {
+		types[i] = cellEmpty
+	}
+	if pm.getShapeKey([]int{1}, types) == pm.getShapeKey([]int{257}, types) {
+		t.Fatalf("Cells 1 and 257 give the same shape")
+	}
+	if key := NewPatternMiner(9, 1, nil).getShapeKey([]int{257}, types); pm.getShapeKey([]int{257}, types) != key {
+		t.Fatalf("Cached key of cell 257 differs from a new one")
+	}
+}
+
+func TestPatternMinerCorrelation(t *testing.T) {
+	// Two connected stones of the red player are good for the red player, two
+	// connected stones of the blue player are good for the blue player
+	samples := ""
+	for i := 0; i < 10; i++ {
+		samples += "# Board ...../.rr../...b./...../..... r\n1.0,1\n-1.0,1\n"
+		samples += "# Board ...../.bb../...r./...../..... b\n-1.0,1\n1.0,1\n"
+		// Empty cells around stones do not correlate with Q
+		samples += "# Board r..../...../...b./...../..... r\n0.0,1\n0.0,1\n"
+	}
+	pm := NewPatternMiner(1, 1, nil)
+	n, err := pm.ReadSamples(strings.NewReader("value,num_stones\n# Search ID 0\n" + samples))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 30 {
+		t.Fatalf("Expected 30 samples, got %d", n)
+	}
+
+	best := pm.GetBestShapes(1, 5)
+	if len(best) != 1 || best[0].Correlation < 0.99 || best[0].Support != 20 {
+		t.Fatalf("Expected a shape with correlation 1 found in 20 samples, got %v", best)
+	}
+
+	// The shape can be read as a pattern
+	var b bytes.Buffer
+	best[0].WritePattern(&b, 0)
+	if b.String() != "### 0 mined, correlation 1.0000, support 20\nsymmetric\n---\n* *\n" {
+		t.Fatalf("Unexpected pattern:\n%s", b.String())
+	}
+	pf, err := parsePatternFile(&b, "mined")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.buildPatterns(); pf.err() != nil {
+		t.Fatal(pf.err())
+	}
+}
+
+func TestPatternMinerSampleFile(t *testing.T) {
+	samples := `{"format":"0xai-samples","version":1,"board":"notation","attributes":["num_stones"]}` + "\n"
+	for i := 0; i < 10; i++ {
+		samples += `{"search":0,"n":100,"q":1,"board":"...../.rr../...b./...../..... r","mirrored":false,"features":[3]}` + "\n"
+		samples += `{"search":0,"n":100,"q":-1,"board":"...../.rb../.r.b./...../..... b","mirrored":true,"features":[3]}` + "\n"
+		samples += `{"search":0,"n":100,"q":-1,"board":"...../.bb../...r./...../..... b","mirrored":false,"features":[3]}` + "\n"
+	}
+	pm := NewPatternMiner(1, 1, nil)
+	n, err := pm.ReadSamples(strings.NewReader(samples))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 20 {
+		t.Fatalf("Expected 20 samples, got %d", n)
+	}
+	best := pm.GetBestShapes(1, 5)
+	if len(best) != 1 || best[0].Correlation < 0.99 || best[0].Support != 20 {
+		t.Fatalf("Expected a shape with correlation 1 found in 20 samples, got %v", best)
+	}
+}
@@ -0,0 +1,191 @@
+package hex
+
+import (
+	"fmt"
+	"sort"
+)
+
+// This file generates variants of patterns that are read from a file. A pattern
+// is written only once, in its canonical form, and all its variants are
+// obtained by hex symmetries:
+//	Rotation by 180 degrees keeps the edges of both players, so it is applied
+//		to all patterns.
+//	Rotation by 60 degrees changes the direction of the edges. It is applied
+//		only to patterns marked with the keyword 'symmetric'.
+//	Reflection over the long diagonal (swapping x and y) swaps the edges of
+//		the players. It maps a pattern of the red player to a pattern of the
+//		blue player, which is the color-swapped counterpart of the pattern. It
+//		is applied to symmetric patterns and to patterns with edge cells,
+//		because edge cells keep their owner ('+' stays the player's edge).
+
+// -------------------------
+// |     Pattern cells     |
+// -------------------------
+
+// patternCell is a definite cell of a pattern
+type patternCell struct {
+	x, y int
+	ct   cellType
+}
+
+// getDefiniteCells returns all definite cells in rows of a pattern
+func getDefiniteCells(rows [][]cellType) []patternCell {
+	cells := make([]patternCell, 0)
+	for y, row := range rows {
+		for x, ct := range row {
+			if ct != cellIndefinite {
+				cells = append(cells, patternCell{x, y, ct})
+			}
+		}
+	}
+	return cells
+}
+
+// cellsToRows returns the smallest rectangle of rows that contains all cells.
+// Cells that are not given are indefinite.
+func cellsToRows(cells []patternCell) [][]cellType {
+	minX, minY, maxX, maxY := cells[0].x, cells[0].y, cells[0].x, cells[0].y
+	for _, c := range cells {
+		if c.x < minX {
+			minX = c.x
+		} else if c.x > maxX {
+			maxX = c.x
+		}
+		if c.y < minY {
+			minY = c.y
+		} else if c.y > maxY {
+			maxY = c.y
+		}
+	}
+	rows := make([][]cellType, maxY-minY+1)
+	for y := range rows {
+		rows[y] = make([]cellType, maxX-minX+1)
+		for x := range rows[y] {
+			rows[y][x] = cellIndefinite
+		}
+	}
+	for _, c := range cells {
+		rows[c.y-minY][c.x-minX] = c.ct
+	}
+	return rows
+}
+
+// getPatternKey returns a string that identifies a pattern by its definite
+// cells. Two patterns with the same key match the same subgrids.
+func getPatternKey(rows [][]cellType) string {
+	cells := getDefiniteCells(cellsToRows(getDefiniteCells(rows)))
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].y < cells[j].y || cells[i].y == cells[j].y && cells[i].x < cells[j].x
+	})
+	return fmt.Sprint(cells)
+}
+
+// ----------------------
+// |     Symmetries     |
+// ----------------------
+
+// rotatePattern180 returns rows of a pattern rotated by 180 degrees
+func rotatePattern180(rows [][]cellType) [][]cellType {
+	rotated := make([][]cellType, len(rows))
+	for y, row := range rows {
+		r := make([]cellType, len(row))
+		for x, ct := range row {
+			r[len(row)-1-x] = ct
+		}
+		rotated[len(rows)-1-y] = r
+	}
+	return rotated
+}
+
+// rotatePattern60 returns rows of a pattern rotated by 60 degrees. The result
+// is the smallest rectangle that contains all definite cells.
+func rotatePattern60(rows [][]cellType) [][]cellType {
+	cells := getDefiniteCells(rows)
+	for i, c := range cells {
+		cells[i].x, cells[i].y = -c.y, c.x+c.y
+	}
+	return cellsToRows(cells)
+}
+
+// transposePattern returns rows of a pattern reflected over the long diagonal,
+// which is the same pattern with swapped roles of the red and the blue player
+func transposePattern(rows [][]cellType) [][]cellType {
+	cells := getDefiniteCells(rows)
+	for i, c := range cells {
+		cells[i].x, cells[i].y = c.y, c.x
+	}
+	return cellsToRows(cells)
+}
+
+// getPatternVariants returns all distinct variants of a pattern given by rows.
+// The pattern itself is the first variant. If symmetric is true, all six
+// rotations of the pattern are included, otherwise only the rotation by 180
+// degrees. If symmetric is true or the pattern contains edge cells,
+// reflections over the long diagonal are included as well.
+func getPatternVariants(rows [][]cellType, symmetric bool) ([][][]cellType, error) {
+	edges := false
+	for _, row := range rows {
+		for _, ct := range row {
+			edges = edges || ct.isEdge()
+		}
+	}
+	if len(getDefiniteCells(rows)) == 0 {
+		return nil, fmt.Errorf("Pattern without definite cells")
+	}
+	if edges && symmetric {
+		return nil, fmt.Errorf("Pattern with edge cells cannot be symmetric")
+	}
+
+	variants := [][][]cellType{rows}
+	keys := map[string]bool{getPatternKey(rows): true}
+	add := func(v [][]cellType) {
+		if k := getPatternKey(v); !keys[k] {
+			keys[k] = true
+			variants = append(variants, v)
+		}
+	}
+
+	if symmetric {
+		r := rows
+		for i := 1; i < 6; i++ {
+			r = rotatePattern60(r)
+			add(r)
+		}
+	}
+	for _, v := range variants {
+		add(rotatePattern180(v))
+	}
+	if symmetric || edges {
+		for _, v := range variants {
+			add(transposePattern(v))
+		}
+	}
+	return variants, nil
+}
+
+// ------------------------
+// |     Counterparts     |
+// ------------------------
+
+// getPatternCounterparts returns for each pattern the index of the pattern that
+// matches the same subgrids with swapped roles of the red and the blue player
+// (its color-swapped counterpart). It returns an error if a pattern has no
+// counterpart among patterns.
+func getPatternCounterparts(patterns [][]*pattern) ([]int, error) {
+	index := make(map[string]int)
+	for pi, variants := range patterns {
+		for _, v := range variants {
+			index[getPatternKey(v.pat)] = pi
+		}
+	}
+
+	counterparts := make([]int, len(patterns))
+	for pi, variants := range patterns {
+		ci, ok := index[getPatternKey(transposePattern(variants[0].pat))]
+		if !ok {
+			return nil, fmt.Errorf("Pattern %d has no color-swapped counterpart:\n%v", pi, variants[0])
+		}
+		counterparts[pi] = ci
+	}
+	return counterparts, nil
+}
@@ -0,0 +1,241 @@
+package hex
+
+import (
+	"math"
+)
+
+// This file implements the evaluation of a state as an electrical circuit. For
+// each player, the board is a network of resistors between the two edges of
+// the player. A cell has resistance 1 if it is empty and 0 if it is occupied by
+// the player, cells of the opponent do not conduct. Two neighbouring cells are
+// connected by a resistor with the sum of their resistances, and so are an
+// edge and a cell on it (the resistance of an edge is 0). The lower the
+// effective resistance between the edges, the better the position of the
+// player.
+
+// GetResistance returns the effective resistance between the edges of player
+// c. It returns 0 if the player has connected the edges and +Inf if the
+// opponent has.
+func (s State) GetResistance(c Color) float64 {
+	u := newUnionFind(s.size)
+	u.addStones(&s, c)
+	first, second := u.getEdgeNodes(c)
+	source, sink := u.find(first), u.find(second)
+	if source == sink {
+		return 0
+	}
+
+	// Cells of the player that are connected have the same potential, so each
+	// set of the union-find is a node of the network. Nodes are numbered in
+	// the order of cells, so that the system of equations is banded.
+	index := make([]int, len(u.parent)) // Index of a node by its root, 0 if it has none yet
+	index[source], index[sink] = 1, 2
+	numNodes := 2
+	getIndex := func(node int32) int {
+		root := u.find(node)
+		if index[root] == 0 {
+			numNodes++
+			index[root] = numNodes
+		}
+		return index[root] - 1
+	}
+	size := int(s.size)
+	resistance := func(x, y int) float64 {
+		if s.getColorOn(byte(x), byte(y)) == c {
+			return 0
+		}
+		return 1
+	}
+
+	// Resistors between nodes, in a fixed order so that the result does not
+	// depend on the order of summation
+	resistors := make([]resistor, 0, 3*size*size)
+	connect := func(i, j int, r float64) {
+		if i != j {
+			resistors = append(resistors, resistor{i, j, 1 / r})
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if s.getColorOn(byte(x), byte(y)) == c.Opponent() {
+				continue
+			}
+			i := getIndex(u.getNode(byte(x), byte(y)))
+			r := resistance(x, y)
+			// Each pair of neighbours is connected once
+			for _, n := range neighbours[:3] {
+				nx, ny := x+n[0], y+n[1]
+				if s.IsCellValid(nx, ny) && s.getColorOn(byte(nx), byte(ny)) != c.Opponent() {
+					if rn := r + resistance(nx, ny); rn > 0 {
+						connect(i, getIndex(u.getNode(byte(nx), byte(ny))), rn)
+					}
+				}
+			}
+			pos := y
+			if c == Blue {
+				pos = x
+			}
+			if r > 0 && pos == 0 {
+				connect(i, 0, r)
+			}
+			if r > 0 && pos == size-1 {
+				connect(i, 1, r)
+			}
+		}
+	}
+
+	v, ok := solveCircuit(numNodes, resistors)
+	if !ok {
+		return math.Inf(1)
+	}
+	current := 0.0
+	for _, r := range resistors {
+		if r.i == 0 {
+			current += r.g * (1 - v[r.j])
+		} else if r.j == 0 {
+			current += r.g * (1 - v[r.i])
+		}
+	}
+	return 1 / current
+}
+
+// resistor connects nodes i and j of a network with conductance g
+type resistor struct {
+	i, j int
+	g    float64
+}
+
+// solveCircuit returns potentials of n nodes of a network of resistors, when
+// node 0 has potential 1 and node 1 has potential 0. Nodes that are not
+// connected with node 0 have potential 0. The second return value is false if
+// nodes 0 and 1 are not connected.
+func solveCircuit(n int, resistors []resistor) ([]float64, bool) {
+	// Neighbors of node i are neighbors[start[i]:start[i+1]]
+	start := make([]int, n+1)
+	for _, r := range resistors {
+		start[r.i+1]++
+		start[r.j+1]++
+	}
+	for i := 0; i < n; i++ {
+		start[i+1] += start[i]
+	}
+	neighbors := make([]int, 2*len(resistors))
+	next := make([]int, n)
+	copy(next, start)
+	for _, r := range resistors {
+		neighbors[next[r.i]], neighbors[next[r.j]] = r.j, r.i
+		next[r.i]++
+		next[r.j]++
+	}
+
+	// Only nodes that are connected with node 0 are included in the system
+	reached := make([]bool, n)
+	reached[0] = true
+	queue := make([]int, 1, n)
+	for q := 0; q < len(queue); q++ {
+		i := queue[q]
+		for _, j := range neighbors[start[i]:start[i+1]] {
+			if !reached[j] {
+				reached[j] = true
+				if j != 1 {
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+	if !reached[1] {
+		return nil, false
+	}
+	unknown := next // Index of a node in the system, -1 if not included
+	numUnknown := 0
+	for i := range unknown {
+		unknown[i] = -1
+		if i > 1 && reached[i] {
+			unknown[i] = numUnknown
+			numUnknown++
+		}
+	}
+
+	// Kirchhoff's current law for each included node. The matrix of the system
+	// is symmetric, so only its diagonal and the band above it are stored.
+	bandwidth := 0
+	for _, r := range resistors {
+		if ui, uj := unknown[r.i], unknown[r.j]; ui >= 0 && uj >= 0 && abs(ui-uj) > bandwidth {
+			bandwidth = abs(ui - uj)
+		}
+	}
+	a := make([][]float64, numUnknown)
+	data := make([]float64, numUnknown*(bandwidth+1))
+	for i := range a {
+		a[i] = data[i*(bandwidth+1) : (i+1)*(bandwidth+1)]
+	}
+	b := make([]float64, numUnknown)
+	for _, r := range resistors {
+		ui, uj := unknown[r.i], unknown[r.j]
+		if ui >= 0 {
+			a[ui][0] += r.g
+		}
+		if uj >= 0 {
+			a[uj][0] += r.g
+		}
+		switch {
+		case ui >= 0 && uj >= 0:
+			if ui > uj {
+				ui, uj = uj, ui
+			}
+			a[ui][uj-ui] -= r.g
+		case ui >= 0 && r.j == 0:
+			b[ui] += r.g
+		case uj >= 0 && r.i == 0:
+			b[uj] += r.g
+		}
+	}
+
+	x := solveBandedSystem(a, b)
+	v := make([]float64, n)
+	v[0] = 1
+	for i, u := range unknown {
+		if u >= 0 {
+			v[i] = x[u]
+		}
+	}
+	return v, true
+}
+
+// solveBandedSystem returns x such that a*x = b, where a is a symmetric
+// positive definite band matrix. Row i of a holds elements (i, i), (i, i+1),
+// ... of the matrix. It uses Gaussian elimination, which needs no pivoting for
+// such matrices, and modifies a and b.
+func solveBandedSystem(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		for d := 1; d < len(a[col]) && col+d < n; d++ {
+			f := a[col][d] / a[col][0]
+			if f == 0 {
+				continue
+			}
+			row := col + d
+			for k := d; k < len(a[col]); k++ {
+				a[row][k-d] -= f * a[col][k]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for d := 1; d < len(a[row]) && row+d < n; d++ {
+			sum -= a[row][d] * x[row+d]
+		}
+		x[row] = sum / a[row][0]
+	}
+	return x
+}
+
+// getMaxResistance returns the highest possible finite resistance between the
+// edges of a player on a board of a given size, which is the resistance of a
+// path through all the cells
+func getMaxResistance(size int) float64 {
+	return float64(2 * size * size)
+}
@@ -0,0 +1,67 @@
+package hex
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResistanceEmpty(t *testing.T) {
+	// One cell between the edges
+	if r := NewState(1, Red).GetResistance(Red); r != 2 {
+		t.Fatalf("Expected resistance 2 on a board of size 1, got %f", r)
+	}
+
+	// Potentials are 3/4 and 2/3 in the top row, 1/4 and 1/3 in the bottom row
+	for _, c := range []Color{Red, Blue} {
+		if r := NewState(2, Red).GetResistance(c); math.Abs(r-12.0/7) > 1e-9 {
+			t.Fatalf("Expected resistance 12/7 for %v, got %f", c, r)
+		}
+	}
+
+	// An empty board is the same for both players
+	s := NewState(7, Red)
+	if r, b := s.GetResistance(Red), s.GetResistance(Blue); math.Abs(r-b) > 1e-9 {
+		t.Fatalf("Expected equal resistances, got %f and %f", r, b)
+	}
+}
+
+/*
+. r .
+ . r .
+  . r .
+*/
+func TestResistanceConnected(t *testing.T) {
+	s := NewState(3, Red)
+	for y := byte(0); y < 3; y++ {
+		s.setCell(1, y, Red)
+	}
+	if r := s.GetResistance(Red); r != 0 {
+		t.Fatalf("Expected resistance 0 for a connected player, got %f", r)
+	}
+	if r := s.GetResistance(Blue); !math.IsInf(r, 1) {
+		t.Fatalf("Expected infinite resistance for a blocked player, got %f", r)
+	}
+	ctx := &EvalContext{*s, [2][]int{}}
+	if v := AttrResistanceBlue.GetAttributeValue(ctx); v != getMaxResistance(3) {
+		t.Fatalf("Expected attribute value %f for a blocked player, got %f", getMaxResistance(3), v)
+	}
+}
+
+/*
+. . . . .
+ . . . . .
+  . . r . .
+   . . . . .
+    . . . . .
+*/
+func TestResistanceStone(t *testing.T) {
+	empty := NewState(5, Red)
+	s := NewState(5, Red)
+	s.setCell(2, 2, Red)
+	r0, r1 := empty.GetResistance(Red), s.GetResistance(Red)
+	b0, b1 := empty.GetResistance(Blue), s.GetResistance(Blue)
+	if r1 >= r0 || b1 <= b0 {
+		t.Fatalf("Expected a stone to lower the resistance of its player (%f -> %f) and raise it for the opponent (%f -> %f)",
+			r0, r1, b0, b1)
+	}
+}
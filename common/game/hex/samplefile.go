@@ -0,0 +1,207 @@
+package hex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// This file implements a self-describing format of learning samples. A sample
+// file is a JSON Lines file: each line holds one JSON object. The first line is
+// a SampleHeader that describes the format, the rest are SampleRecords. Each
+// state is written as two records, the second is the same state with reversed
+// roles of the red and the blue player (see GenSample).
+
+// SampleFormatName identifies sample files in the header
+const SampleFormatName = "0xai-samples"
+
+// SampleFormatVersion is the version of the sample format that is written.
+// It is increased whenever the format changes in an incompatible way.
+const SampleFormatVersion = 1
+
+// BoardEncodingNotation means that boards in records are written in the board
+// notation (see GetBoardNotation), which includes the last player
+const BoardEncodingNotation = "notation"
+
+// ------------------------
+// |     SampleHeader     |
+// ------------------------
+
+// SampleHeader is the first line of a sample file
+//	Format is always SampleFormatName
+//	Version is the version of the format (see SampleFormatVersion)
+//	Board is the encoding of boards in records (see BoardEncodingNotation)
+//	Attributes are names of features in records (see AttributeSet.GetNames)
+type SampleHeader struct {
+	Format     string   `json:"format"`
+	Version    int      `json:"version"`
+	Board      string   `json:"board"`
+	Attributes []string `json:"attributes"`
+}
+
+// newSampleHeader returns the header of a file with the current version of the
+// format and given attributes
+func newSampleHeader(attributes []string) SampleHeader {
+	return SampleHeader{
+		Format:     SampleFormatName,
+		Version:    SampleFormatVersion,
+		Board:      BoardEncodingNotation,
+		Attributes: attributes,
+	}
+}
+
+// ------------------------
+// |     SampleRecord     |
+// ------------------------
+
+// SampleRecord is a learning sample of one state
+//	Search is the ID of the search that produced the sample
+//	N is the number of visits of the node with the state
+//	Q is the estimated value of the state for the red player
+//	Board is the state, encoded as given in the header
+//	Mirrored is true if the record is the color-swapped version of the
+//		previous record
+//	Features are values of attributes, in the order given in the header
+type SampleRecord struct {
+	Search   int       `json:"search"`
+	N        uint      `json:"n"`
+	Q        float64   `json:"q"`
+	Board    string    `json:"board"`
+	Mirrored bool      `json:"mirrored"`
+	Features []float64 `json:"features"`
+}
+
+// GetState returns the state of the record
+func (r *SampleRecord) GetState() (*State, error) {
+	return ParseBoardNotation(r.Board)
+}
+
+// ------------------------
+// |     SampleWriter     |
+// ------------------------
+
+// SampleWriter writes learning samples in the sample format
+//	enc encodes lines of the file
+//	pm counts patterns and gives attributes of samples
+//	searchID is the ID of the search that is written in records
+type SampleWriter struct {
+	enc      *json.Encoder
+	pm       *PatternMatcher
+	searchID int
+}
+
+// NewSampleWriter returns a SampleWriter that writes to w. Samples are computed
+// with PatternMatcher pm. The header is written immediately.
+func NewSampleWriter(w io.Writer, pm *PatternMatcher) (*SampleWriter, error) {
+	sw := &SampleWriter{json.NewEncoder(w), pm, 0}
+	if err := sw.enc.Encode(newSampleHeader(pm.attributes.GetNames())); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// SetSearchID sets the ID of the search that is written in the following
+// records
+func (sw *SampleWriter) SetSearchID(id int) {
+	sw.searchID = id
+}
+
+// WriteSample writes two records of State s, which has been visited n times
+// and has value q from the perspective of the player who made the last move.
+// The records contain the same samples as GenSample (see genSampleRows).
+func (sw *SampleWriter) WriteSample(s State, q float64, n uint) error {
+	for _, row := range s.genSampleRows(q, sw.pm) {
+		board := s
+		if row.mirrored {
+			board = s.Transform(SwapTranspose)
+		}
+		r := SampleRecord{sw.searchID, n, row.q, board.GetBoardNotation(), row.mirrored, row.features}
+		if err := sw.enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ------------------------
+// |     SampleReader     |
+// ------------------------
+
+// SampleReader reads learning samples in the sample format
+//	scanner reads lines of the file
+//	header is the header of the file
+//	line is the number of the last line that was read
+type SampleReader struct {
+	scanner *bufio.Scanner
+	header  SampleHeader
+	line    int
+}
+
+// maxSampleLineLength is the maximal length of a line in a sample file
+const maxSampleLineLength = 1 << 20
+
+// NewSampleReader returns a SampleReader that reads from r. The header is read
+// immediately and an error is returned if the format is not supported.
+func NewSampleReader(r io.Reader) (*SampleReader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSampleLineLength)
+	sr := &SampleReader{scanner: scanner}
+	if !sr.nextLine() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Missing header of the sample file")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &sr.header); err != nil {
+		return nil, fmt.Errorf("Line %d: invalid header: %s", sr.line, err)
+	}
+	h := sr.header
+	if h.Format != SampleFormatName {
+		return nil, fmt.Errorf("Line %d: unknown format '%s', expected '%s'", sr.line, h.Format, SampleFormatName)
+	}
+	if h.Version != SampleFormatVersion {
+		return nil, fmt.Errorf("Line %d: unsupported version %d of the sample format, expected %d", sr.line, h.Version, SampleFormatVersion)
+	}
+	if h.Board != BoardEncodingNotation {
+		return nil, fmt.Errorf("Line %d: unknown board encoding '%s'", sr.line, h.Board)
+	}
+	return sr, nil
+}
+
+// nextLine moves the scanner to the next line that is not empty. It returns
+// false at the end of the input.
+func (sr *SampleReader) nextLine() bool {
+	for sr.scanner.Scan() {
+		sr.line++
+		if len(bytes.TrimSpace(sr.scanner.Bytes())) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHeader returns the header of the sample file
+func (sr *SampleReader) GetHeader() SampleHeader {
+	return sr.header
+}
+
+// Read returns the next record of the sample file. It returns io.EOF when there
+// are no more records.
+func (sr *SampleReader) Read() (*SampleRecord, error) {
+	if !sr.nextLine() {
+		if err := sr.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r := &SampleRecord{}
+	if err := json.Unmarshal(sr.scanner.Bytes(), r); err != nil {
+		return nil, fmt.Errorf("Line %d: invalid record: %s", sr.line, err)
+	}
+	if len(r.Features) != len(sr.header.Attributes) {
+		return nil, fmt.Errorf("Line %d: record has %d features, expected %d", sr.line, len(r.Features), len(sr.header.Attributes))
+	}
+	return r, nil
+}
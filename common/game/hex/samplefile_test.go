@@ -0,0 +1,104 @@
+package hex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+/*
+. . . . . .
+ . . . . b .
+  . r . . . .
+   . . . . . .
+    . . . . . .
+     . . . . . .
+*/
+func TestSampleFileRoundTrip(t *testing.T) {
+	pm, err := Load("patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := getStateAfterMoves(NewState(6, Red), []*Action{
+		NewAction(1, 2, Red),
+		NewAction(4, 1, Blue),
+	})
+
+	var b bytes.Buffer
+	sw, err := NewSampleWriter(&b, pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.SetSearchID(3)
+	if err := sw.WriteSample(*s, 0.5, 120); err != nil {
+		t.Fatal(err)
+	}
+
+	sr, err := NewSampleReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sr.GetHeader()
+	if h.Version != SampleFormatVersion || len(h.Attributes) != pm.GetAttributes().Len() {
+		t.Fatalf("Wrong header %v", h)
+	}
+
+	// The records hold the same values as the samples in the CSV format
+	csv := strings.Split(strings.Trim(s.GenSample(0.5, pm), "\n"), "\n")
+	for i, mirrored := range []bool{false, true} {
+		r, err := sr.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Search != 3 || r.N != 120 || r.Mirrored != mirrored {
+			t.Fatalf("Wrong record %v", r)
+		}
+		if !strings.HasPrefix(csv[i], fmt.Sprintf("%f,", r.Q)) {
+			t.Fatalf("Wrong Q %f of record %d", r.Q, i)
+		}
+		if f := featuresToCSV(r.Features); !strings.HasSuffix(csv[i], f) {
+			t.Fatalf("Features of record %d do not match the CSV sample\n%s\n%s", i, f, csv[i])
+		}
+		rs, err := r.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := *s
+		if mirrored {
+			expected = s.Transform(SwapTranspose)
+		}
+		if rs.GetMapKey() != expected.GetMapKey() {
+			t.Fatalf("Wrong state of record %d:\n%v", i, rs)
+		}
+	}
+	if _, err := sr.Read(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestSampleFileInvalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"a,b,c\n",
+		`{"format":"other","version":1,"board":"notation","attributes":[]}`,
+		`{"format":"0xai-samples","version":99,"board":"notation","attributes":[]}`,
+		`{"format":"0xai-samples","version":1,"board":"grid","attributes":[]}`,
+	}
+	for _, in := range inputs {
+		if _, err := NewSampleReader(strings.NewReader(in)); err == nil {
+			t.Fatalf("Expected an error for header '%s'", in)
+		}
+	}
+
+	in := `{"format":"0xai-samples","version":1,"board":"notation","attributes":["a","b"]}
+{"search":0,"n":1,"q":0,"board":". b","mirrored":false,"features":[1]}`
+	sr, err := NewSampleReader(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sr.Read(); err == nil || !strings.HasPrefix(err.Error(), "Line 2") {
+		t.Fatalf("Expected an error in line 2, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package hex
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/RdecKa/0xAI/common/astarsearch"
 	"github.com/RdecKa/0xAI/common/game"
@@ -13,37 +14,69 @@ import (
 
 // State represents a state on a grid in a hex game
 //	size is a length of the grid (size 11 means 11x11 grid)
-//	grid is a list of rows in a grid, each row is represented as uint32. Each
-//		cell in a row is stored with two bits:
+//	grid is a list of rows in a grid, each row is represented as a slice of
+//		uint64 words. Each cell in a row is stored with two bits:
 //			00 - empty
 //			01 - red
 //			10 - blue
 //			11 - undefined
-//		Lowest two bits represent the cell with index 0. Because of using 32
-//		bits for a row, maximal size of the grid is 16x16.
+//		Lowest two bits of the first word represent the cell with index 0. One
+//		word holds 32 cells, larger grids use more words per row.
 //	lastAction is the action that led to the current position on board
+//	key is the Zobrist key of the state (see zobrist.go). It is updated
+//		whenever a stone is placed.
 //
 // A goal of the red player is to connect top-most and bottom-most row while a
 // goal of the blue player is to connect left-most and right-most column
 type State struct {
 	size       byte
-	grid       []uint32
+	grid       [][]uint64
 	lastAction *Action
+	key        uint64
 }
 
+// cellsPerWord is the number of cells that are stored in one word of a row
+const cellsPerWord = 32
+
 // NewState returns new State with a grid of given size and an invalid action as
 // lastAction
 func NewState(size byte, firstPlayer Color) *State {
-	grid := make([]uint32, size)
-	return &State{size, grid, NewAction(size, size, firstPlayer.Opponent())} // Opponent is set as last player, so firstPlayer starts
+	grid := newGrid(size)
+	lastPlayer := firstPlayer.Opponent() // Opponent is set as last player, so firstPlayer starts
+	lastAction := NewAction(size, size, lastPlayer)
+	return &State{size, grid, lastAction, getZobristActionKey(lastAction)}
+}
+
+// newGrid returns an empty grid of a given size. All rows share one underlying
+// array, so only two allocations are needed.
+func newGrid(size byte) [][]uint64 {
+	words := getNumOfWordsInRow(int(size))
+	data := make([]uint64, int(size)*words)
+	grid := make([][]uint64, size)
+	for i := range grid {
+		grid[i] = data[i*words : (i+1)*words : (i+1)*words]
+	}
+	return grid
 }
 
+// getNumOfWordsInRow returns the number of words needed to store a row of a
+// grid of a given size
+func getNumOfWordsInRow(size int) int {
+	return (size + cellsPerWord - 1) / cellsPerWord
+}
+
+// String returns the grid with column letters and row numbers of the standard
+// notation (see ParseAction)
 func (s State) String() string {
-	r := ""
+	w := len(fmt.Sprint(s.size)) // Width of row numbers
+	r := strings.Repeat(" ", w+1)
+	for col := 0; col < int(s.size); col++ {
+		r += getColumnNotation(col) + " "
+	}
+	r += "\n"
 	for rowIndex, row := range s.grid {
-		for i := 0; i < rowIndex; i++ {
-			r += " "
-		}
+		r += strings.Repeat(" ", rowIndex)
+		r += fmt.Sprintf("%*d ", w, rowIndex+1)
 		for col := byte(0); col < s.size; col++ {
 			color := getCellInRow(row, col)
 			r += fmt.Sprintf("%s ", color)
@@ -59,9 +92,11 @@ func (s *State) GetSize() int {
 }
 
 // GetCopyGrid returns a copy of the state's grid
-func (s *State) GetCopyGrid() []uint32 {
-	c := make([]uint32, len(s.grid))
-	copy(c, s.grid)
+func (s *State) GetCopyGrid() [][]uint64 {
+	c := newGrid(s.size)
+	for i, row := range s.grid {
+		copy(c[i], row)
+	}
 	return c
 }
 
@@ -84,25 +119,43 @@ func (s *State) getColorOn(x, y byte) Color {
 // setCell puts a stone of color c into cell (x, y)
 // Cell (x, y) must be empty and valid
 func (s *State) setCell(x, y byte, c Color) {
-	bits := uint32(c) << (x * 2)
-	s.grid[y] |= bits
+	bits := uint64(c) << ((x % cellsPerWord) * 2)
+	s.grid[y][x/cellsPerWord] |= bits
+	s.key ^= getZobristKey(x, y, c)
+}
+
+// clearCell removes a stone from cell (x, y)
+func (s *State) clearCell(x, y byte) {
+	s.key ^= getZobristKey(x, y, s.getColorOn(x, y))
+	s.grid[y][x/cellsPerWord] &^= 3 << ((x % cellsPerWord) * 2)
 }
 
 // getCellInRow returns color of a stone on index index in row row
-func getCellInRow(row uint32, index byte) Color {
+func getCellInRow(row []uint64, index byte) Color {
 	// Find the two bits that represent column with index index
-	bits := ((3 << (index * 2)) & row) >> (index * 2)
+	shift := (index % cellsPerWord) * 2
+	bits := (row[index/cellsPerWord] >> shift) & 3
 	return GetColorFromBits(bits)
 }
 
+// getBitsInRow returns length bits of a row, starting with the bit on index
+// start. length must not be greater than 64.
+func getBitsInRow(row []uint64, start, length uint) uint64 {
+	w, offset := start/64, start%64
+	r := row[w] >> offset
+	if offset+length > 64 && int(w)+1 < len(row) {
+		r |= row[w+1] << (64 - offset)
+	}
+	if length < 64 {
+		r &= (1 << length) - 1
+	}
+	return r
+}
+
 // cloneNoAction returns a new State with same data as original state but with
 // nil as State.lastAction
 func (s *State) cloneNoAction() game.State {
-	newGrid := make([]uint32, len(s.grid))
-	for i, v := range s.grid {
-		newGrid[i] = v
-	}
-	return State{s.size, newGrid, nil}
+	return State{s.size, s.GetCopyGrid(), nil, s.key}
 }
 
 // Clone returns a duplicate of a state
@@ -112,6 +165,11 @@ func (s *State) Clone() game.State {
 	return ns
 }
 
+// GetBoard returns a mutable copy of a state
+func (s State) GetBoard() game.Board {
+	return NewBoard(&s)
+}
+
 // IsCellValid returns true if a cell (x, y) is on the grid, and false otherwise
 func (s *State) IsCellValid(x, y int) bool {
 	return x >= 0 && x < int(s.size) && y >= 0 && y < int(s.size)
@@ -137,35 +195,76 @@ func (s State) GetSuccessorState(action game.Action) game.State {
 	if a.c == s.lastAction.c {
 		panic(fmt.Sprintf("Player cannot do two moves in a row! (last player: %s, current action: %s)", s.lastAction.c, a))
 	}
+	if a.swap {
+		return s.getSwappedState(a)
+	}
 	if x, y := a.GetCoordinates(); s.getColorOn(byte(x), byte(y)) != None {
 		panic(fmt.Sprintf("Cell (%d, %d) already occupied (player %v's turn)!", x, y, a.c))
 	}
 	newState := s.cloneNoAction().(State)
 	newState.lastAction = action.(*Action)
 	newState.setCell(a.x, a.y, a.c)
+	newState.key ^= getZobristActionKey(s.lastAction) ^ getZobristActionKey(a)
+	return newState
+}
+
+// getSwappedState returns a state after the swap action a is performed. The
+// opponent's only stone is removed and a stone of player a.c is placed on the
+// cell that is its reflection over the long diagonal.
+func (s State) getSwappedState(a *Action) State {
+	if !s.IsSwapPossible() {
+		panic(fmt.Sprintf("Swap is not possible (player %v's turn)!", a.c))
+	}
+	x, y := s.lastAction.x, s.lastAction.y
+	newState := State{s.size, newGrid(s.size), &Action{y, x, a.c, true}, 0}
+	newState.key = getZobristActionKey(newState.lastAction)
+	newState.setCell(y, x, a.c)
 	return newState
 }
 
-// GetPossibleActions returns a list of all possible actions from State s
+// IsSwapPossible returns true if the player who is on turn can swap the
+// opponent's stone. This is possible only on the second move of the game.
+func (s State) IsSwapPossible() bool {
+	if s.lastAction.swap || !s.IsCellValid(int(s.lastAction.x), int(s.lastAction.y)) {
+		return false
+	}
+	r, b, _ := s.GetNumOfStones()
+	return r+b == 1
+}
+
+// GetPossibleActions returns a list of all possible actions from State s. On
+// the second move of the game, the swap action is added at the end of the list.
 func (s State) GetPossibleActions() []game.Action {
-	actions := make([]game.Action, 0, s.size*s.size)
+	actions := make([]game.Action, 0, s.size*s.size+1)
 	playerColor := s.lastAction.c.Opponent()
 	for rowIndex := byte(0); rowIndex < s.size; rowIndex++ {
 		row := s.grid[rowIndex]
 		for colIndex := byte(0); colIndex < s.size; colIndex++ {
-			bits := row & 3 // Get last two bits of a row
-			if GetColorFromBits(bits) == None {
-				actions = append(actions, &Action{colIndex, rowIndex, playerColor})
+			if getCellInRow(row, colIndex) == None {
+				actions = append(actions, &Action{colIndex, rowIndex, playerColor, false})
 			}
-			row = row >> 2
 		}
 	}
+	if len(actions) == int(s.size)*int(s.size)-1 && s.IsSwapPossible() {
+		swap := s.getSwappedState(NewSwapAction(playerColor))
+		actions = append(actions, swap.lastAction)
+	}
 	return actions
 }
 
 // IsGoalState returns true if the game is decided (the player who has just made
-// a move has a (virtual) connection) and false otherwise
+// a move has a (virtual) connection) and false otherwise.
+// If veryEnd is true, only actual connections count and they are found with
+// union-find. The returned solution is then the group of stones that connects
+// the edges. Otherwise, virtual connections (bridges and edge templates, see
+// EdgeTemplates) are found with A* search and the solution is the path of the
+// connection.
 func (s State) IsGoalState(veryEnd bool) (bool, interface{}) {
+	if veryEnd {
+		u := newUnionFind(s.size)
+		u.addStones(&s, s.lastAction.c)
+		return getConnection(u, s.lastAction.c)
+	}
 	initialState := GetInitialState(&s)
 	aStarSearch := astarsearch.InitSearch(&initialState)
 	solutionExists, solution := aStarSearch.Search(veryEnd)
@@ -187,15 +286,17 @@ func (s State) EvaluateGoalState(gameLengthImportant bool) float64 {
 // Same returns true if states s and s2 represent the same state on the board.
 func (s State) Same(sg game.State) bool {
 	s2 := sg.(*State)
-	if s.size != s2.size {
+	if s.size != s2.size || s.key != s2.key {
 		return false
 	}
-	if s.lastAction.c != s2.lastAction.c {
+	if s.lastAction.c != s2.lastAction.c || s.lastAction.swap != s2.lastAction.swap {
 		return false
 	}
 	for i := byte(0); i < s.size; i++ {
-		if s.grid[i] != s2.grid[i] {
-			return false
+		for w := range s.grid[i] {
+			if s.grid[i][w] != s2.grid[i][w] {
+				return false
+			}
 		}
 	}
 	return true
@@ -206,10 +307,8 @@ func (s State) Same(sg game.State) bool {
 func (s State) GetNumOfStones() (int, int, int) {
 	red, blue, empty := 0, 0, 0
 	for _, row := range s.grid {
-		r := row
 		for colIndex := byte(0); colIndex < s.size; colIndex++ {
-			c := GetColorFromBits(r & 3)
-			switch c {
+			switch getCellInRow(row, colIndex) {
 			case Red:
 				red++
 			case Blue:
@@ -217,7 +316,6 @@ func (s State) GetNumOfStones() (int, int, int) {
 			default:
 				empty++
 			}
-			r = r >> 2
 		}
 	}
 	return red, blue, empty
@@ -230,13 +328,10 @@ func (s *State) GetNumberOfReachableEmptyCellsForPlayer(color Color) int {
 	dirtyState := s.cloneNoAction().(State)
 	sum := 0
 	for rowIndex, row := range s.grid {
-		r := row
 		for colIndex := 0; colIndex < int(s.size); colIndex++ {
-			c := GetColorFromBits(r & 3)
-			if c == color {
+			if getCellInRow(row, byte(colIndex)) == color {
 				sum += s.getNumberOfReachableCellsFromOneCell(colIndex, rowIndex, color, &dirtyState)
 			}
-			r = r >> 2
 		}
 	}
 	return sum
@@ -278,26 +373,19 @@ func (s *State) getNumberOfReachableCellsFromOneCell(x, y int, color Color, dirt
 // Deprecated: Use hex.State.GetLastAction if possible
 func (s State) GetTransitionAction(sg game.State) game.Action {
 	s2 := sg.(State)
-	for r := 0; r < s.GetSize(); r++ {
-		if s.grid[r] != s2.grid[r] {
-			diff := s2.grid[r] - s.grid[r]
-			c := 0
-			for diff > 3 {
-				c++
-				diff = diff >> 2
+	for r := byte(0); r < s.size; r++ {
+		for c := byte(0); c < s.size; c++ {
+			if s.getColorOn(c, r) != s2.getColorOn(c, r) {
+				return NewAction(c, r, s2.getColorOn(c, r))
 			}
-			return NewAction(byte(c), byte(r), GetColorFromBits(diff))
 		}
 	}
 	return nil
 }
 
-// GetMapKey generates a key to be used in a hash map
+// GetMapKey returns the Zobrist key of the state (see zobrist.go), to be used
+// in a hash map. The key depends on the stones on the grid and on the player
+// who has made the last move.
 func (s State) GetMapKey() uint64 {
-	h := uint64(14695981039346656037)
-	for i, st := range s.grid {
-		h *= 1099511628211
-		h ^= (uint64(st) << uint((3*i)%32))
-	}
-	return h
+	return s.key
 }
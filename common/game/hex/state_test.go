@@ -0,0 +1,125 @@
+package hex
+
+import "testing"
+
+/*
+. . . .
+ . . r .
+  . . . .
+   . . . .
+*/
+func TestSwap(t *testing.T) {
+	state := NewState(4, Red)
+	if state.IsSwapPossible() {
+		t.Fatalf("Swap should not be possible on the first move")
+	}
+
+	s := state.GetSuccessorState(NewAction(2, 1, Red)).(State)
+	state = &s
+	if !state.IsSwapPossible() {
+		t.Fatalf("Swap should be possible on the second move")
+	}
+
+	actions := state.GetPossibleActions()
+	if len(actions) != 16 {
+		t.Fatalf("Expected 16 possible actions, got %d", len(actions))
+	}
+	swap := actions[len(actions)-1].(*Action)
+	if !swap.IsSwap() || swap.GetColor() != Blue {
+		t.Fatalf("Expected the last action to be a swap of the blue player, got %v", swap)
+	}
+	if x, y := swap.GetCoordinates(); x != 1 || y != 2 {
+		t.Fatalf("Expected the swapped stone on (1, 2), got (%d, %d)", x, y)
+	}
+
+	s = state.GetSuccessorState(NewSwapAction(Blue)).(State)
+	state = &s
+	if c := state.getColorOn(2, 1); c != None {
+		t.Fatalf("Cell (2, 1) should be empty after swap, got %v", c)
+	}
+	if c := state.getColorOn(1, 2); c != Blue {
+		t.Fatalf("Cell (1, 2) should be blue after swap, got %v", c)
+	}
+	if lp := state.GetLastPlayer(); lp != Blue {
+		t.Fatalf("Blue should be the last player, got %v", lp)
+	}
+	if state.IsSwapPossible() {
+		t.Fatalf("Swap should not be possible after swap")
+	}
+	if n := len(state.GetPossibleActions()); n != 15 {
+		t.Fatalf("Expected 15 possible actions after swap, got %d", n)
+	}
+}
+
+func TestLargeBoard(t *testing.T) {
+	for _, size := range []byte{19, 32, 33, 40} {
+		state := NewState(size, Red)
+		actions := []*Action{
+			NewAction(0, 0, Red),
+			NewAction(size-1, 0, Blue),
+			NewAction(size-2, 1, Red),
+			NewAction(size-1, size-1, Blue),
+		}
+		for _, a := range actions {
+			s := state.GetSuccessorState(a).(State)
+			state = &s
+		}
+		for _, a := range actions {
+			x, y := a.GetCoordinates()
+			if c := state.getColorOn(byte(x), byte(y)); c != a.c {
+				t.Fatalf("Size %d: expected %v on (%d, %d), got %v", size, a.c, x, y, c)
+			}
+		}
+		r, b, e := state.GetNumOfStones()
+		if r != 2 || b != 2 || r+b+e != int(size)*int(size) {
+			t.Fatalf("Size %d: wrong number of stones (%d, %d, %d)", size, r, b, e)
+		}
+		if n := len(state.GetPossibleActions()); n != e {
+			t.Fatalf("Size %d: expected %d possible actions, got %d", size, e, n)
+		}
+	}
+}
+
+func TestMapKey(t *testing.T) {
+	play := func(actions ...*Action) *State {
+		state := NewState(7, Red)
+		for _, a := range actions {
+			s := state.GetSuccessorState(a).(State)
+			state = &s
+		}
+		return state
+	}
+
+	s1 := play(NewAction(1, 1, Red), NewAction(2, 2, Blue), NewAction(3, 3, Red))
+	s2 := play(NewAction(3, 3, Red), NewAction(2, 2, Blue), NewAction(1, 1, Red))
+	if s1.GetMapKey() != s2.GetMapKey() {
+		t.Fatalf("Same positions reached by different move orders should have the same key")
+	}
+
+	s3 := play(NewAction(1, 1, Red), NewAction(2, 2, Blue))
+	s4 := NewState(7, Blue)
+	for _, a := range []*Action{NewAction(2, 2, Blue), NewAction(1, 1, Red)} {
+		s := s4.GetSuccessorState(a).(State)
+		s4 = &s
+	}
+	if s3.GetMapKey() == s4.GetMapKey() {
+		t.Fatalf("Same grids with different players on turn should have different keys")
+	}
+
+	if s1.GetMapKey() == s3.GetMapKey() {
+		t.Fatalf("Different positions should have different keys")
+	}
+
+	swapped := play(NewAction(2, 1, Red), NewSwapAction(Blue))
+	expected := NewState(7, Red) // Blue is set as the last player
+	expected.setCell(1, 2, Blue)
+	if swapped.GetMapKey() != expected.GetMapKey()^zobristSwapped {
+		t.Fatalf("Key of a swapped state should be computed from its grid and the swap")
+	}
+	// The same grid without the swap is a different state, because swapping is
+	// possible only in the second one
+	notSwapped := NewState(7, Blue).GetSuccessorState(NewAction(1, 2, Blue)).(State)
+	if swapped.GetMapKey() == notSwapped.GetMapKey() || swapped.Same(&notSwapped) {
+		t.Fatalf("States that differ only in the swap should have different keys")
+	}
+}
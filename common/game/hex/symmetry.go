@@ -0,0 +1,115 @@
+package hex
+
+// --------------------
+// |     Symmetry     |
+// --------------------
+
+// Symmetry is a transformation of a state that preserves its value. A board of
+// hex is symmetric under the rotation by 180 degrees. Reflecting the board over
+// one of its diagonals exchanges the edges of the players, so colors of the
+// stones must be swapped as well. The value of a transformed state is the same
+// from the perspective of the player who made the last move.
+type Symmetry byte
+
+// enum for symmetries
+const (
+	Identity          Symmetry = iota // (x, y) -> (x, y)
+	Rotation180                       // (x, y) -> (size-1-x, size-1-y)
+	SwapTranspose                     // (x, y) -> (y, x), colors swapped
+	SwapAntiTranspose                 // (x, y) -> (size-1-y, size-1-x), colors swapped
+)
+
+// Symmetries is a list of all symmetries of a hex board
+var Symmetries = []Symmetry{Identity, Rotation180, SwapTranspose, SwapAntiTranspose}
+
+func (sym Symmetry) String() string {
+	switch sym {
+	case Identity:
+		return "identity"
+	case Rotation180:
+		return "rotation180"
+	case SwapTranspose:
+		return "swaptranspose"
+	case SwapAntiTranspose:
+		return "swapantitranspose"
+	default:
+		return "unknown"
+	}
+}
+
+// SwapsColors returns true if the symmetry swaps colors of the stones, which
+// changes the sign of the value of a state for the red player
+func (sym Symmetry) SwapsColors() bool {
+	return sym == SwapTranspose || sym == SwapAntiTranspose
+}
+
+// transformCell returns the coordinates and the color of cell (x, y) with color
+// c on a board of a given size after the symmetry is applied
+func (sym Symmetry) transformCell(size, x, y byte, c Color) (byte, byte, Color) {
+	switch sym {
+	case Rotation180:
+		return size - 1 - x, size - 1 - y, c
+	case SwapTranspose:
+		return y, x, c.Opponent()
+	case SwapAntiTranspose:
+		return size - 1 - y, size - 1 - x, c.Opponent()
+	default:
+		return x, y, c
+	}
+}
+
+// Transform returns a new state that is the result of applying the symmetry
+// sym to State s. The last action is transformed as well.
+func (s State) Transform(sym Symmetry) State {
+	a := s.lastAction
+	x, y, c := a.x, a.y, a.c
+	if s.IsCellValid(int(x), int(y)) {
+		x, y, c = sym.transformCell(s.size, x, y, c)
+	} else {
+		_, _, c = sym.transformCell(s.size, 0, 0, c)
+	}
+	ns := State{s.size, newGrid(s.size), &Action{x, y, c, a.swap}, 0}
+	ns.key = getZobristActionKey(ns.lastAction)
+	for row := byte(0); row < s.size; row++ {
+		for col := byte(0); col < s.size; col++ {
+			if color := s.getColorOn(col, row); color != None {
+				ns.setCell(sym.transformCell(s.size, col, row, color))
+			}
+		}
+	}
+	return ns
+}
+
+// Canonical returns the canonical form of State s and the symmetry that
+// transforms s into it. All symmetric states have the same canonical form,
+// which is the smallest of them when comparing rows of grids and then the last
+// player.
+func (s State) Canonical() (State, Symmetry) {
+	best, bestSym := s, Identity
+	for _, sym := range Symmetries[1:] {
+		if t := s.Transform(sym); t.compare(&best) < 0 {
+			best, bestSym = t, sym
+		}
+	}
+	return best, bestSym
+}
+
+// compare returns -1, 0 or 1 if State s is smaller than, equal to or larger
+// than State s2 of the same size, comparing rows of grids and then last players
+func (s *State) compare(s2 *State) int {
+	for i, row := range s.grid {
+		for w, word := range row {
+			if word < s2.grid[i][w] {
+				return -1
+			} else if word > s2.grid[i][w] {
+				return 1
+			}
+		}
+	}
+	if s.lastAction.c < s2.lastAction.c {
+		return -1
+	} else if s.lastAction.c > s2.lastAction.c {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package hex
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+r . . .
+ . b . .
+  . . r .
+   . . . .
+*/
+func getSymmetryTestState() *State {
+	s := NewState(4, Red)
+	s = getStateAfterMoves(s, []*Action{
+		NewAction(0, 0, Red),
+		NewAction(1, 1, Blue),
+		NewAction(2, 2, Red),
+	})
+	return s
+}
+
+func getStateAfterMoves(s *State, actions []*Action) *State {
+	for _, a := range actions {
+		ns := s.GetSuccessorState(a).(State)
+		s = &ns
+	}
+	return s
+}
+
+func TestTransform(t *testing.T) {
+	s := getSymmetryTestState()
+	tests := []struct {
+		sym        Symmetry
+		x, y       byte
+		c          Color
+		lastPlayer Color
+	}{
+		{Identity, 2, 2, Red, Red},
+		{Rotation180, 1, 1, Red, Red},
+		{SwapTranspose, 2, 2, Blue, Blue},
+		{SwapAntiTranspose, 1, 1, Blue, Blue},
+	}
+	for _, test := range tests {
+		ts := s.Transform(test.sym)
+		if c := ts.getColorOn(test.x, test.y); c != test.c {
+			t.Fatalf("%v: expected %v in (%d, %d), got %v\n%v", test.sym, test.c, test.x, test.y, c, ts)
+		}
+		if p := ts.GetLastPlayer(); p != test.lastPlayer {
+			t.Fatalf("%v: expected last player %v, got %v", test.sym, test.lastPlayer, p)
+		}
+		if x, y := ts.GetLastAction().GetCoordinates(); byte(x) != test.x || byte(y) != test.y {
+			t.Fatalf("%v: expected last action in (%d, %d), got (%d, %d)", test.sym, test.x, test.y, x, y)
+		}
+		r, b, _ := ts.GetNumOfStones()
+		if test.c == Red && (r != 2 || b != 1) || test.c == Blue && (r != 1 || b != 2) {
+			t.Fatalf("%v: wrong number of stones (%d red, %d blue)\n%v", test.sym, r, b, ts)
+		}
+		// Each symmetry is its own inverse
+		if tts := ts.Transform(test.sym); !s.Same(&tts) {
+			t.Fatalf("%v: transforming twice does not return the original state\n%v", test.sym, ts)
+		}
+		// The key must match the key of the transformed stones
+		key := getZobristActionKey(NewAction(4, 4, test.lastPlayer))
+		for _, a := range []*Action{NewAction(0, 0, Red), NewAction(1, 1, Blue), NewAction(2, 2, Red)} {
+			key ^= getZobristKey(test.sym.transformCell(4, a.x, a.y, a.c))
+		}
+		if key != ts.GetMapKey() {
+			t.Fatalf("%v: wrong key of the transformed state", test.sym)
+		}
+	}
+}
+
+func TestTransformInitialState(t *testing.T) {
+	s := NewState(5, Red)
+	for _, sym := range Symmetries {
+		ts := s.Transform(sym)
+		expected := Red
+		if sym.SwapsColors() {
+			expected = Blue
+		}
+		// Player expected.Opponent() is on turn
+		if ts.GetLastPlayer() == expected {
+			t.Fatalf("%v: expected %v to be on turn", sym, expected)
+		}
+		if ts.IsSwapPossible() {
+			t.Fatalf("%v: swap should not be possible in the initial state", sym)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	s := getSymmetryTestState()
+	cs, sym := s.Canonical()
+	if ts := s.Transform(sym); !cs.Same(&ts) {
+		t.Fatalf("Canonical state does not match the returned symmetry %v", sym)
+	}
+	for _, sym := range Symmetries {
+		ts := s.Transform(sym)
+		c, _ := ts.Canonical()
+		if !cs.Same(&c) {
+			t.Fatalf("%v: expected the same canonical state\n%v\ngot\n%v", sym, cs, c)
+		}
+		if c.GetMapKey() != cs.GetMapKey() {
+			t.Fatalf("%v: expected the same key of the canonical state", sym)
+		}
+	}
+
+	// A different state has a different canonical form
+	s2 := getStateAfterMoves(s, []*Action{NewAction(3, 0, Blue)})
+	if c2, _ := s2.Canonical(); cs.Same(&c2) {
+		t.Fatalf("Different states have the same canonical form")
+	}
+}
+
+/*
+. . . . .
+ . r b . .
+  . . r . .
+   . b . . .
+    . . . . .
+*/
+func TestSymmetryPreservesEvaluation(t *testing.T) {
+	s := getStateAfterMoves(NewState(5, Red), []*Action{
+		NewAction(1, 1, Red),
+		NewAction(2, 1, Blue),
+		NewAction(2, 2, Red),
+		NewAction(1, 3, Blue),
+	})
+	for _, sym := range Symmetries {
+		ts := s.Transform(sym)
+		for _, c := range []Color{Red, Blue} {
+			tc := c
+			if sym.SwapsColors() {
+				tc = c.Opponent()
+			}
+			if d, td := s.GetTwoDistance(c), ts.GetTwoDistance(tc); d != td {
+				t.Fatalf("%v: two-distance of %v changed from %d to %d", sym, c, d, td)
+			}
+			if r, tr := s.GetResistance(c), ts.GetResistance(tc); math.Abs(r-tr) > 1e-9 {
+				t.Fatalf("%v: resistance of %v changed from %f to %f", sym, c, r, tr)
+			}
+		}
+	}
+}
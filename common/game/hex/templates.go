@@ -0,0 +1,186 @@
+package hex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// This file implements a library of edge templates. An edge template is a
+// pattern of empty cells (its carrier) that connects a stone to an edge, even
+// if the opponent moves first. Templates are named as in hex literature: the
+// roman numeral is the row of the stone (counting from the edge) and the
+// letter distinguishes templates of the same row. Each carrier in the library
+// was checked by solving the area of the template exhaustively.
+
+// -------------------------
+// |     Edge template     |
+// -------------------------
+
+// EdgeTemplate is an edge template from the library (see EdgeTemplates)
+//	Name is the name of the template
+//	Row is the row of the stone, counting from the edge. A stone on the edge
+//		is in row 1.
+//	carrier are the empty cells of the template, relative to the stone. They
+//		are given for a red stone and the bottom edge.
+type EdgeTemplate struct {
+	Name    string
+	Row     int
+	carrier [][2]int
+}
+
+// Diagrams of the templates in the library, for a red stone and the bottom
+// edge, which is below the last row. Rows are drawn as in State.String: 'r' is
+// the stone and '.' are the cells of the carrier. Mirror images of the
+// templates are added when the library is built.
+var edgeTemplateDiagrams = []struct {
+	name    string
+	diagram string
+}{
+	{"II", `
+ r
+. .`},
+	{"IIIa", `
+  r .
+ . . .
+. . . .`},
+	{"IVa", `
+     . r
+  . . . . .
+ . . . . . .
+. . . . . . .`},
+}
+
+// EdgeTemplates is the library of edge templates, including mirror images,
+// sorted by the number of cells in the carrier
+var EdgeTemplates = getEdgeTemplates()
+
+// getEdgeTemplates builds the library of edge templates from
+// edgeTemplateDiagrams
+func getEdgeTemplates() []*EdgeTemplate {
+	templates := make([]*EdgeTemplate, 0, 2*len(edgeTemplateDiagrams))
+	for _, d := range edgeTemplateDiagrams {
+		t := parseEdgeTemplate(d.name, d.diagram)
+		templates = append(templates, t)
+		if m := t.mirror(); !sameCarrier(t.carrier, m.carrier) {
+			templates = append(templates, m)
+		}
+	}
+	sort.SliceStable(templates, func(i, j int) bool {
+		return len(templates[i].carrier) < len(templates[j].carrier)
+	})
+	return templates
+}
+
+// parseEdgeTemplate returns a template described by a diagram (see
+// edgeTemplateDiagrams). It panics if the diagram is invalid.
+func parseEdgeTemplate(name, diagram string) *EdgeTemplate {
+	lines := strings.Split(strings.Trim(diagram, "\n"), "\n")
+	stoneCol := strings.IndexByte(lines[0], 'r')
+	if stoneCol < 0 {
+		panic(fmt.Sprintf("Template %s: no stone in the first row", name))
+	}
+	t := &EdgeTemplate{name, len(lines), make([][2]int, 0)}
+	for y, line := range lines {
+		for col, ch := range line {
+			if ch == ' ' || y == 0 && col == stoneCol {
+				continue
+			}
+			if ch != '.' || (col-y-stoneCol)%2 != 0 {
+				panic(fmt.Sprintf("Template %s: invalid cell '%c' in row %d, column %d", name, ch, y, col))
+			}
+			t.carrier = append(t.carrier, [2]int{(col - y - stoneCol) / 2, y})
+		}
+	}
+	return t
+}
+
+// mirror returns the mirror image of template t. The axis of reflection is
+// perpendicular to the edge, so rows of the template are kept.
+func (t *EdgeTemplate) mirror() *EdgeTemplate {
+	m := &EdgeTemplate{t.Name, t.Row, make([][2]int, len(t.carrier))}
+	for i, c := range t.carrier {
+		m.carrier[i] = [2]int{-c[0] - c[1], c[1]}
+	}
+	return m
+}
+
+// sameCarrier returns true if carriers a and b contain the same cells
+func sameCarrier(a, b [][2]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, ca := range a {
+		found := false
+		for _, cb := range b {
+			if ca == cb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// placeTemplateCell returns the cell at position c relative to the stone of a
+// template (as in EdgeTemplate.carrier), when the template is placed on a
+// stone (x, y) of player color and one of the player's edges. edge is 0 for
+// the first edge (top for the red player, left for the blue player) and 1 for
+// the second edge (bottom or right).
+func placeTemplateCell(c [2]int, x, y int, color Color, edge int) [2]int {
+	dx, dy := c[0], c[1]
+	if color == Blue {
+		dx, dy = dy, dx
+	}
+	if edge == 0 {
+		dx, dy = -dx, -dy
+	}
+	return [2]int{x + dx, y + dy}
+}
+
+// GetEdgeTemplate returns the smallest template from EdgeTemplates that
+// connects the stone on cell (x, y) to one of the edges of its player, and the
+// carrier of the template in State s. edge is 0 for the first edge (top for the
+// red player, left for the blue player) and 1 for the second edge (bottom or
+// right). The last return value is false if there is no such template.
+func (s *State) GetEdgeTemplate(x, y int, edge int) (*EdgeTemplate, [][2]int, bool) {
+	if !s.IsCellValid(x, y) {
+		return nil, nil, false
+	}
+	color := s.getColorOn(byte(x), byte(y))
+	if color != Red && color != Blue {
+		return nil, nil, false
+	}
+	row := x + 1
+	if color == Red {
+		row = y + 1
+	}
+	if edge == 1 {
+		row = int(s.size) + 1 - row
+	}
+
+	for _, t := range EdgeTemplates {
+		if t.Row != row {
+			continue
+		}
+		carrier := make([][2]int, len(t.carrier))
+		for i, c := range t.carrier {
+			carrier[i] = placeTemplateCell(c, x, y, color, edge)
+			if cx, cy := carrier[i][0], carrier[i][1]; !s.IsCellValid(cx, cy) || !s.IsCellEmpty(byte(cx), byte(cy)) {
+				carrier = nil
+				break
+			}
+		}
+		if carrier != nil {
+			return t, carrier, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (t *EdgeTemplate) String() string {
+	return fmt.Sprintf("%s (row %d, %d cells)", t.Name, t.Row, len(t.carrier))
+}
@@ -0,0 +1,131 @@
+package hex
+
+import "testing"
+
+func TestEdgeTemplateLibrary(t *testing.T) {
+	numCells := map[string]int{"II": 2, "IIIa": 8, "IVa": 19}
+	found := make(map[string]int)
+	for _, tmpl := range EdgeTemplates {
+		if n, ok := numCells[tmpl.Name]; ok && len(tmpl.carrier) != n {
+			t.Fatalf("Template %v should have %d cells in the carrier", tmpl, n)
+		}
+		for _, c := range tmpl.carrier {
+			if c == [2]int{0, 0} || c[1] < 0 || c[1] >= tmpl.Row {
+				t.Fatalf("Template %v has an invalid cell %v", tmpl, c)
+			}
+		}
+		found[tmpl.Name]++
+	}
+	// The bridge to the edge is symmetric, other templates are not
+	if found["II"] != 1 || found["IIIa"] != 2 || found["IVa"] != 2 {
+		t.Fatalf("Expected one template II and two templates IIIa and IVa, got %v", found)
+	}
+}
+
+/*
+. . . . . . . . .
+ . . . . r . . . .
+  . . . . . . . . .
+   . . . . . . . . .
+    . . . . . . . . .
+     . . . . . . . . .
+      . . . . r . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestGetEdgeTemplate(t *testing.T) {
+	state := getStateWithStones(9, [][2]int{{4, 1}, {4, 6}}, nil)
+	if tmpl, carrier, ok := state.GetEdgeTemplate(4, 1, 0); !ok || tmpl.Name != "II" ||
+		!sameCells(carrier, [][2]int{{4, 0}, {5, 0}}) {
+		t.Fatalf("Expected template II with carrier (4, 0), (5, 0), got %v, %v", tmpl, carrier)
+	}
+	tmpl, carrier, ok := state.GetEdgeTemplate(4, 6, 1)
+	if !ok || tmpl.Name != "IIIa" {
+		t.Fatalf("Expected template IIIa to the bottom edge, got %v", tmpl)
+	}
+	for _, c := range carrier {
+		if c[1] < 6 || c[1] > 8 {
+			t.Fatalf("Carrier %v of template %v is not between the stone and the edge", carrier, tmpl)
+		}
+	}
+	if tmpl, _, ok := state.GetEdgeTemplate(4, 1, 1); ok {
+		t.Fatalf("Stone in the eighth row should not be connected to the edge, got %v", tmpl)
+	}
+
+	// A stone in the carrier of one ziggurat leaves the mirrored one
+	state.setCell(5, 6, Blue)
+	if tmpl, carrier, ok := state.GetEdgeTemplate(4, 6, 1); !ok || tmpl.Name != "IIIa" ||
+		containsCell(carrier, [2]int{5, 6}) || !containsCell(carrier, [2]int{3, 6}) {
+		t.Fatalf("Expected the mirrored template IIIa, got %v with carrier %v", tmpl, carrier)
+	}
+	state.setCell(3, 6, Blue)
+	if tmpl, _, ok := state.GetEdgeTemplate(4, 6, 1); ok {
+		t.Fatalf("Both ziggurats are broken, got %v", tmpl)
+	}
+}
+
+/*
+. . . . . . .
+ . . . . . . .
+  . . b . . . b
+   . . . . . . .
+    . . . . . . .
+     . . . . . . .
+      . . . . . . .
+*/
+func TestGetEdgeTemplateBlue(t *testing.T) {
+	state := getStateWithStones(7, nil, [][2]int{{2, 2}, {6, 2}})
+	tmpl, carrier, ok := state.GetEdgeTemplate(2, 2, 0)
+	if !ok || tmpl.Name != "IIIa" {
+		t.Fatalf("Expected template IIIa to the left edge, got %v", tmpl)
+	}
+	for _, c := range carrier {
+		if c[0] < 0 || c[0] > 2 {
+			t.Fatalf("Carrier %v of template %v is not between the stone and the edge", carrier, tmpl)
+		}
+	}
+	if tmpl, _, ok := state.GetEdgeTemplate(6, 2, 1); ok {
+		t.Fatalf("Stone on the edge does not need a template, got %v", tmpl)
+	}
+	if tmpl, carrier, ok := state.GetEdgeTemplate(2, 2, 1); ok {
+		t.Fatalf("Stone in the fifth column should not be connected to the right edge, got %v, %v", tmpl, carrier)
+	}
+}
+
+/*
+. . . . . . . . .
+ . . . . r . . . .
+  . . . . r . . . .
+   . . . . r . . . .
+    . . . . r . . . .
+     . . . . r . . . .
+      . . . . . . . . .
+       . . . . . . . . .
+        . . . . . . . . .
+*/
+func TestIsGoalStateWithEdgeTemplates(t *testing.T) {
+	// The chain is connected to the top edge by template II and to the bottom
+	// edge by template IVa
+	state := getStateWithStones(9, [][2]int{{4, 1}, {4, 2}, {4, 3}, {4, 4}, {4, 5}}, nil)
+	state.lastAction = NewAction(4, 5, Red)
+	if tmpl, _, ok := state.GetEdgeTemplate(4, 5, 1); !ok || tmpl.Name != "IVa" {
+		t.Fatalf("Expected template IVa to the bottom edge, got %v", tmpl)
+	}
+	if goal, _ := state.IsGoalState(false); !goal {
+		t.Fatalf("Red should have a virtual connection\n%v", state)
+	}
+	if goal, _ := state.IsGoalState(true); goal {
+		t.Fatalf("Red should not have an actual connection\n%v", state)
+	}
+
+	// H-search finds the connection as well
+	if _, ok := NewHSearch(state, Red).GetWinningCarrier(); !ok {
+		t.Fatalf("Expected a winning VC through template IVa\n%v", state)
+	}
+
+	// Blue's stone in the carrier of both templates IVa breaks them
+	state.setCell(3, 7, Blue)
+	if tmpl, carrier, ok := state.GetEdgeTemplate(4, 5, 1); ok {
+		t.Fatalf("Expected no template to the bottom edge, got %v with carrier %v", tmpl, carrier)
+	}
+}
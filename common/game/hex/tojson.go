@@ -7,10 +7,17 @@ import (
 )
 
 // MarshalJSON implements Marshaler interface
-// It returns State in JSON format
+// It returns State in JSON format. The grid is written as a list of rows, each
+// row is a string with one character per cell (see Color.String)
 func (state State) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
-	jsonValue, err := json.Marshal(state.grid)
+	rows := make([]string, state.size)
+	for y := range rows {
+		for x := byte(0); x < state.size; x++ {
+			rows[y] += state.getColorOn(x, byte(y)).String()
+		}
+	}
+	jsonValue, err := json.Marshal(rows)
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +30,7 @@ func (state State) MarshalJSON() ([]byte, error) {
 // It returns Action in JSON format
 func (action Action) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
-	buffer.WriteString(fmt.Sprintf("\"x\":%d,\"y\":%d,\"c\":\"%v\"", action.x, action.y, action.c))
+	buffer.WriteString(fmt.Sprintf("\"x\":%d,\"y\":%d,\"c\":\"%v\",\"swap\":%t", action.x, action.y, action.c, action.swap))
 	buffer.WriteString("}")
 	return buffer.Bytes(), nil
 }
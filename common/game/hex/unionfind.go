@@ -0,0 +1,168 @@
+package hex
+
+// ----------------------
+// |     Union-find     |
+// ----------------------
+
+// unionFind is a disjoint-set structure over the cells of a grid and four
+// virtual nodes, one for each edge of the grid. Neighbouring stones of the same
+// color are in the same set, and so are stones on an edge of their own color
+// and the node of that edge. A player has won when both edge nodes of the
+// player's color are in the same set.
+//	size is a length of the grid
+//	parent is a parent of each node, a root is its own parent
+//	setSize is the number of nodes in a set, valid only for roots
+//	unions is a list of roots that were attached to another root, in the
+//		order of unions. Sets are merged by size and paths are never
+//		compressed, so unions can be undone in reverse order.
+type unionFind struct {
+	size    byte
+	parent  []int32
+	setSize []int32
+	unions  []int32
+}
+
+// Indices of the edge nodes, relative to the number of cells in a grid
+const (
+	edgeTop = iota
+	edgeBottom
+	edgeLeft
+	edgeRight
+	numEdges
+)
+
+// newUnionFind returns a unionFind for an empty grid of a given size
+func newUnionFind(size byte) *unionFind {
+	n := int(size)*int(size) + numEdges
+	u := &unionFind{size, make([]int32, n), make([]int32, n), nil}
+	for i := range u.parent {
+		u.parent[i] = int32(i)
+		u.setSize[i] = 1
+	}
+	return u
+}
+
+// newUnionFindFromState returns a unionFind with all stones of State s
+func newUnionFindFromState(s *State) *unionFind {
+	u := newUnionFind(s.size)
+	u.addStones(s, Red)
+	u.addStones(s, Blue)
+	return u
+}
+
+// getNode returns the index of the node that represents cell (x, y)
+func (u *unionFind) getNode(x, y byte) int32 {
+	return int32(y)*int32(u.size) + int32(x)
+}
+
+// getEdgeNode returns the index of the node that represents edge edge
+func (u *unionFind) getEdgeNode(edge int) int32 {
+	return int32(u.size)*int32(u.size) + int32(edge)
+}
+
+// getEdgeNodes returns both edge nodes of player c
+func (u *unionFind) getEdgeNodes(c Color) (int32, int32) {
+	if c == Red {
+		return u.getEdgeNode(edgeTop), u.getEdgeNode(edgeBottom)
+	}
+	return u.getEdgeNode(edgeLeft), u.getEdgeNode(edgeRight)
+}
+
+// find returns the root of the set that contains node i
+func (u *unionFind) find(i int32) int32 {
+	for u.parent[i] != i {
+		i = u.parent[i]
+	}
+	return i
+}
+
+// union merges sets that contain nodes i and j
+func (u *unionFind) union(i, j int32) {
+	i, j = u.find(i), u.find(j)
+	if i == j {
+		return
+	}
+	if u.setSize[i] > u.setSize[j] {
+		i, j = j, i
+	}
+	u.parent[i] = j
+	u.setSize[j] += u.setSize[i]
+	u.unions = append(u.unions, i)
+}
+
+// undoUnions undoes the latest unions until only n of them remain
+func (u *unionFind) undoUnions(n int) {
+	for len(u.unions) > n {
+		i := u.unions[len(u.unions)-1]
+		u.unions = u.unions[:len(u.unions)-1]
+		u.setSize[u.parent[i]] -= u.setSize[i]
+		u.parent[i] = i
+	}
+}
+
+// addStone connects a stone of color c in cell (x, y) with its neighbours of
+// the same color and with the edges of its color it touches. The stone must
+// already be placed in State s.
+func (u *unionFind) addStone(s *State, x, y byte, c Color) {
+	node := u.getNode(x, y)
+	for _, n := range neighbours {
+		nx, ny := int(x)+n[0], int(y)+n[1]
+		if s.IsCellValid(nx, ny) && s.getColorOn(byte(nx), byte(ny)) == c {
+			u.union(node, u.getNode(byte(nx), byte(ny)))
+		}
+	}
+
+	first, second := u.getEdgeNodes(c)
+	pos := y
+	if c == Blue {
+		pos = x
+	}
+	if pos == 0 {
+		u.union(node, first)
+	}
+	if pos == u.size-1 {
+		u.union(node, second)
+	}
+}
+
+// addStones adds all stones of color c in State s
+func (u *unionFind) addStones(s *State, c Color) {
+	for y := byte(0); y < s.size; y++ {
+		for x := byte(0); x < s.size; x++ {
+			if getCellInRow(s.grid[y], x) == c {
+				u.addStone(s, x, y, c)
+			}
+		}
+	}
+}
+
+// isConnected returns true if player c has connected both edges of its color
+func (u *unionFind) isConnected(c Color) bool {
+	first, second := u.getEdgeNodes(c)
+	return u.find(first) == u.find(second)
+}
+
+// getGroup returns coordinates of all stones in the set that contains the
+// first edge of player c
+func (u *unionFind) getGroup(c Color) [][2]int {
+	first, _ := u.getEdgeNodes(c)
+	root := u.find(first)
+	group := make([][2]int, 0, u.setSize[root])
+	for y := byte(0); y < u.size; y++ {
+		for x := byte(0); x < u.size; x++ {
+			if u.find(u.getNode(x, y)) == root {
+				group = append(group, [2]int{int(x), int(y)})
+			}
+		}
+	}
+	return group
+}
+
+// getConnection returns true and the group of stones that connects the edges of
+// player c, if there is one, and false otherwise
+func getConnection(u *unionFind, c Color) (bool, interface{}) {
+	if !u.isConnected(c) {
+		return false, nil
+	}
+	return true, u.getGroup(c)
+}
@@ -0,0 +1,133 @@
+package hex
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/RdecKa/0xAI/common/astarsearch"
+)
+
+// isGoalStateAStar returns the result of the A* search for an actual
+// connection, which was used for detecting the end of the game before
+func isGoalStateAStar(s *State) bool {
+	initialState := GetInitialState(s)
+	goal, _ := astarsearch.InitSearch(&initialState).Search(true)
+	return goal
+}
+
+/*
+. r . .
+ . r b .
+  r b . .
+   r b . .
+*/
+func TestUnionFindGoalState(t *testing.T) {
+	state := NewState(4, Red)
+	actions := []*Action{
+		NewAction(1, 0, Red),
+		NewAction(2, 1, Blue),
+		NewAction(1, 1, Red),
+		NewAction(1, 2, Blue),
+		NewAction(0, 2, Red),
+		NewAction(1, 3, Blue),
+	}
+	for _, a := range actions {
+		s := state.GetSuccessorState(a).(State)
+		state = &s
+		if goal, _ := state.IsGoalState(true); goal {
+			t.Fatalf("Game should not be over after %v:\n%v", a, state)
+		}
+	}
+
+	s := state.GetSuccessorState(NewAction(0, 3, Red)).(State)
+	goal, solution := s.IsGoalState(true)
+	if !goal {
+		t.Fatalf("Red should have won:\n%v", s)
+	}
+	if group := solution.([][2]int); len(group) != 4 {
+		t.Fatalf("Expected 4 stones in the winning group, got %v", group)
+	}
+}
+
+func TestUnionFindRandomGames(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for game := 0; game < 50; game++ {
+		board := NewBoard(NewState(byte(2+game%9), Red))
+		numActions := 0
+		for {
+			goal, _ := board.IsGoalState(true)
+			if g, _ := board.State.IsGoalState(true); g != goal {
+				t.Fatalf("Board and State disagree (board: %t, state: %t):\n%v", goal, g, board)
+			}
+			if g := isGoalStateAStar(&board.State); g != goal {
+				t.Fatalf("Union-find and A* disagree (union-find: %t, A*: %t):\n%v", goal, g, board)
+			}
+			if goal {
+				break
+			}
+			actions := board.GetPossibleActions()
+			board.Play(actions[r.Intn(len(actions))])
+			numActions++
+		}
+
+		// Undo the whole game, the game must not be over at any point
+		for ; numActions > 0; numActions-- {
+			board.Undo()
+			if goal, _ := board.IsGoalState(true); goal {
+				t.Fatalf("Game should not be over after undo:\n%v", board)
+			}
+		}
+	}
+}
+
+// getRandomGame returns actions of a random game on an 11x11 board
+func getRandomGame() []*Action {
+	r := rand.New(rand.NewSource(7))
+	board := NewBoard(NewState(11, Red))
+	actions := make([]*Action, 0)
+	for goal := false; !goal; goal, _ = board.IsGoalState(true) {
+		possibleActions := board.GetPossibleActions()
+		a := possibleActions[r.Intn(len(possibleActions))].(*Action)
+		board.Play(a)
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// benchmarkGameEndState replays a game with successor states and checks after
+// each action whether the game has ended
+func benchmarkGameEndState(b *testing.B, isGoalState func(s *State) bool) {
+	actions := getRandomGame()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		state := NewState(11, Red)
+		for _, a := range actions {
+			s := state.GetSuccessorState(a).(State)
+			state = &s
+			isGoalState(state)
+		}
+	}
+}
+
+func BenchmarkGameEndAStar(b *testing.B) {
+	benchmarkGameEndState(b, isGoalStateAStar)
+}
+
+func BenchmarkGameEndUnionFind(b *testing.B) {
+	benchmarkGameEndState(b, func(s *State) bool {
+		goal, _ := s.IsGoalState(true)
+		return goal
+	})
+}
+
+func BenchmarkGameEndBoard(b *testing.B) {
+	actions := getRandomGame()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		board := NewBoard(NewState(11, Red))
+		for _, a := range actions {
+			board.Play(a)
+			board.IsGoalState(true)
+		}
+	}
+}
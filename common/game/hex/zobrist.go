@@ -0,0 +1,47 @@
+package hex
+
+// This file provides Zobrist hashing of hex states. Each (cell, color) pair has
+// its own random key and the key of a state is XOR of the keys of all stones on
+// the grid. Another key is added when the blue player has made the last move,
+// so the same grid with a different player on turn gets a different key, and
+// another one when the last move was a swap, because swapping is then no longer
+// possible (see State.IsSwapPossible).
+// Because XOR is its own inverse, the key can be updated incrementally when a
+// stone is placed.
+//
+// Keys are not stored in a table. They are computed from the coordinates and
+// the color with splitmix64, which gives well distributed, deterministic keys
+// for grids of any size.
+
+// zobristLastPlayerBlue is added to the key of a state if the blue player has
+// made the last move
+var zobristLastPlayerBlue = splitmix64(1 << 24)
+
+// zobristSwapped is added to the key of a state if the last move was a swap
+var zobristSwapped = splitmix64(1 << 25)
+
+// getZobristKey returns the key of a stone of color c in cell (x, y)
+func getZobristKey(x, y byte, c Color) uint64 {
+	return splitmix64(uint64(c)<<16 | uint64(y)<<8 | uint64(x))
+}
+
+// getZobristActionKey returns the part of the key that depends on the last
+// action a: the player who has made it and whether it was a swap
+func getZobristActionKey(a *Action) uint64 {
+	var key uint64
+	if a.c == Blue {
+		key ^= zobristLastPlayerBlue
+	}
+	if a.swap {
+		key ^= zobristSwapped
+	}
+	return key
+}
+
+// splitmix64 returns a pseudorandom number that is determined by the seed x
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/RdecKa/0xAI/3-ab"
 	"github.com/RdecKa/0xAI/common/game/hex"
 	"github.com/RdecKa/0xAI/server/hexgame"
 	"github.com/RdecKa/0xAI/server/hexplayer"
@@ -22,6 +23,8 @@ type MatchSetup struct {
 	patternFile string
 	extraInfo1  interface{}
 	extraInfo2  interface{}
+	modelFile1  string
+	modelFile2  string
 }
 
 // CreateMatch sets up the comparison of two players
@@ -46,6 +49,14 @@ func CreateMatch(bs, ng int, p1, p2 hexplayer.PlayerType, t1, t2 int, patternFil
 	}
 }
 
+// WithModels returns the match in which players evaluate states with models
+// from files mf1 and mf2 (see hexplayer.LoadEstimator) instead of the
+// generated code. An empty name keeps the generated code for that player.
+func (ms MatchSetup) WithModels(mf1, mf2 string) MatchSetup {
+	ms.modelFile1, ms.modelFile2 = mf1, mf2
+	return ms
+}
+
 type result struct {
 	results [2][2]int
 	lengths [2][2][2]float64
@@ -71,13 +82,21 @@ func (ms MatchSetup) Run(outDir string, players [2]hexplayer.HexPlayer) ([2][2]i
 }
 
 func (ms MatchSetup) String() string {
-	s := fmt.Sprintf("Player 1: %v (%ds)\nPlayer 2: %v (%ds)\n",
-		ms.player1type.String(), ms.time1,
-		ms.player2type.String(), ms.time2)
+	s := fmt.Sprintf("Player 1: %v (%ds)%s\nPlayer 2: %v (%ds)%s\n",
+		ms.player1type.String(), ms.time1, modelString(ms.modelFile1),
+		ms.player2type.String(), ms.time2, modelString(ms.modelFile2))
 	s += fmt.Sprintf("Board size: %d\nNumber of games: %d (x2)\n", ms.boardSize, ms.numGames)
 	return s
 }
 
+// modelString returns the description of a model file of a player
+func modelString(modelFile string) string {
+	if modelFile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [model %s]", modelFile)
+}
+
 // RunAll runs all sets of matches given as argument
 func RunAll(matches []MatchSetup, outDir string) {
 	f, err := os.Create(outDir + "test_results.txt")
@@ -94,16 +113,35 @@ func RunAll(matches []MatchSetup, outDir string) {
 		f.WriteString(ms.String())
 		f.WriteString("--------------------\n")
 
+		// Players of the match share the patterns
+		pm, err := hex.Load(ms.patternFile)
+		if err != nil {
+			f.WriteString(fmt.Sprintf("Cannot load patterns: %s\n\n", err))
+			continue
+		}
+
+		// Both instances of a player share its model
+		est1, err := hexplayer.LoadEstimator(ms.player1type, ms.modelFile1, pm)
+		if err != nil {
+			f.WriteString(fmt.Sprintf("Cannot load the model of player 1: %s\n\n", err))
+			continue
+		}
+		est2, err := hexplayer.LoadEstimator(ms.player2type, ms.modelFile2, pm)
+		if err != nil {
+			f.WriteString(fmt.Sprintf("Cannot load the model of player 2: %s\n\n", err))
+			continue
+		}
+
 		var players [2][2]hexplayer.HexPlayer
 		// player1 = Red, player2 = Blue
 		players[0] = [2]hexplayer.HexPlayer{
-			createPlayer(ms.player1type, hex.Red, ms.time1, ms.patternFile, ms.extraInfo1),
-			createPlayer(ms.player2type, hex.Blue, ms.time2, ms.patternFile, ms.extraInfo2),
+			createPlayer(ms.player1type, hex.Red, ms.time1, pm, est1, ms.extraInfo1),
+			createPlayer(ms.player2type, hex.Blue, ms.time2, pm, est2, ms.extraInfo2),
 		}
 		// player1 = Blue, player2 = Red
 		players[1] = [2]hexplayer.HexPlayer{
-			createPlayer(ms.player2type, hex.Red, ms.time2, ms.patternFile, ms.extraInfo2),
-			createPlayer(ms.player1type, hex.Blue, ms.time1, ms.patternFile, ms.extraInfo1),
+			createPlayer(ms.player2type, hex.Red, ms.time2, pm, est2, ms.extraInfo2),
+			createPlayer(ms.player1type, hex.Blue, ms.time1, pm, est1, ms.extraInfo1),
 		}
 
 		go runParallel(ms, outDir, players[0], ch0)
@@ -151,7 +189,7 @@ func RunAll(matches []MatchSetup, outDir string) {
 	f.WriteString(fmt.Sprintf("\nTesting finished at %s.\n", time.Now().Format("15.04.05 (2006/01/02)")))
 }
 
-func createPlayer(t hexplayer.PlayerType, c hex.Color, tl int, patternFile string, ei interface{}) hexplayer.HexPlayer {
+func createPlayer(t hexplayer.PlayerType, c hex.Color, tl int, pm *hex.PatternMatcher, est ab.Estimator, ei interface{}) hexplayer.HexPlayer {
 	switch t {
 	case hexplayer.RandType:
 		return hexplayer.CreateRandPlayer(c)
@@ -159,13 +197,16 @@ func createPlayer(t hexplayer.PlayerType, c hex.Color, tl int, patternFile strin
 		return hexplayer.CreateMCTSplayer(c, math.Sqrt(2), time.Duration(tl)*time.Second, 10, true)
 	case hexplayer.AbDtType:
 		return hexplayer.CreateAbPlayer(c, nil, time.Duration(tl)*time.Second,
-			true, patternFile, false, hexplayer.AbDtType)
+			true, pm, false, hexplayer.AbDtType, est)
 	case hexplayer.AbLrType:
 		return hexplayer.CreateAbPlayer(c, nil, time.Duration(tl)*time.Second,
-			true, patternFile, false, hexplayer.AbLrType)
+			true, pm, false, hexplayer.AbLrType, est)
+	case hexplayer.AbErType:
+		return hexplayer.CreateAbPlayer(c, nil, time.Duration(tl)*time.Second,
+			true, pm, false, hexplayer.AbErType, est)
 	case hexplayer.HybridType:
 		return hexplayer.CreateHybridPlayer(c, time.Duration(tl)*time.Second,
-			true, patternFile, hexplayer.AbLrType, ei.(int))
+			true, pm, hexplayer.AbLrType, est, ei.(int))
 	default:
 		fmt.Println(fmt.Errorf("Invalid type '%s'", t.String()))
 		return nil
@@ -32,7 +32,7 @@ func playOneGame(boardSize int, players [2]hexplayer.HexPlayer, passiveClient he
 			return -1, -1, err
 		}
 	}
-	state := hex.NewState(byte(boardSize), players[startingPlayer].GetColor())
+	state := hex.NewBoard(hex.NewState(byte(boardSize), players[startingPlayer].GetColor()))
 	turn := startingPlayer
 	var prevAction *hex.Action
 	gameLength := 0
@@ -49,15 +49,22 @@ func playOneGame(boardSize int, players [2]hexplayer.HexPlayer, passiveClient he
 			outFile.WriteString(fmt.Sprintf("Player %v resigned!\n", players[turn].GetColor()))
 			break
 		}
+		if nextAction.IsSwap() && !state.IsSwapPossible() {
+			return -1, -1, fmt.Errorf("Player %v cannot swap now", players[turn].GetColor())
+		}
+		state.Play(nextAction)
+		// Use the action stored in the state, because a swap action gets its
+		// coordinates only when it is applied
+		prevAction = state.GetLastAction()
+		if prevAction.IsSwap() {
+			outFile.WriteString(fmt.Sprintf("Player %v swapped!\n", players[turn].GetColor()))
+		}
 		if passiveClient != nil {
-			passiveClient.PrevAction(nextAction)
+			passiveClient.PrevAction(prevAction)
 		}
-		s := state.GetSuccessorState(nextAction).(hex.State)
-		state = &s
-		prevAction = nextAction
 		turn = 1 - turn
 		gameLength++
-		outFile.WriteString(fmt.Sprintf("%v", state))
+		outFile.WriteString(fmt.Sprintf("%d. %v\n%v", gameLength, prevAction, state))
 
 		// Call garbage collector
 		runtime.GC()
@@ -13,47 +13,61 @@ import (
 // AbPlayer represents a computer player that uses alpha-beta pruning for
 // selecting moves
 type AbPlayer struct {
-	Color              hex.Color                  // Player's color
-	subtype            PlayerType                 // Player's subtype (DT/LR)
-	Webso              *websocket.Conn            // Websocket connecting server and client
-	timeToRun          time.Duration              // Time given to select an action
-	numWin             int                        // Number of wins
-	state              *hex.State                 // Current state in a game
-	safeWinCells       [][2]cell                  // List of cells under the bridges on a winning path
-	lastOpponentAction *hex.Action                // Opponent's last action
-	allowResignation   bool                       // Allow the player to resign if the game is lost
-	createTree         bool                       // If true, create a search tree for debugging purposes
-	gridChan           chan []uint32              // Used for pattern checking
-	stopChan           chan struct{}              // -||-
-	patChan            chan []int                 // -||-
-	resultChan         chan [2][]int              // -||-
-	getEstimatedValue  func(s *ab.Sample) float64 // Function used for evaluating states
+	Color              hex.Color           // Player's color
+	subtype            PlayerType          // Player's subtype (DT/LR)
+	Webso              *websocket.Conn     // Websocket connecting server and client
+	timeToRun          time.Duration       // Time given to select an action
+	numWin             int                 // Number of wins
+	state              *hex.State          // Current state in a game
+	winCarrier         [][2]int            // Carrier of the virtual connection between the player's edges
+	lastOpponentAction *hex.Action         // Opponent's last action
+	allowResignation   bool                // Allow the player to resign if the game is lost
+	createTree         bool                // If true, create a search tree for debugging purposes
+	patMatcher         *hex.PatternMatcher // Used for pattern checking
+	estimator          ab.Estimator        // Used for evaluating states
 }
 
-// CreateAbPlayer creates a new player
+// CreateAbPlayer creates a new player. Patterns are counted by PatternMatcher
+// pm and states are evaluated by Estimator est (see LoadEstimator), both can
+// be shared by several players.
 func CreateAbPlayer(c hex.Color, webso *websocket.Conn, t time.Duration,
-	allowResignation bool, patFileName string, createTree bool, subtype PlayerType) *AbPlayer {
+	allowResignation bool, pm *hex.PatternMatcher, createTree bool, subtype PlayerType,
+	est ab.Estimator) *AbPlayer {
 
-	gridChan, patChan, stopChan, resultChan := hex.CreatePatChecker(patFileName)
 	ap := AbPlayer{
-		Color:             c,
-		subtype:           subtype,
-		Webso:             webso,
-		timeToRun:         t,
-		allowResignation:  allowResignation,
-		createTree:        createTree,
-		gridChan:          gridChan,
-		patChan:           patChan,
-		stopChan:          stopChan,
-		resultChan:        resultChan,
-		getEstimatedValue: ab.GetEstimateFunction(subtype.String())}
+		Color:            c,
+		subtype:          subtype,
+		Webso:            webso,
+		timeToRun:        t,
+		allowResignation: allowResignation,
+		createTree:       createTree,
+		patMatcher:       pm,
+		estimator:        est}
 	return &ap
 }
 
+// LoadEstimator returns the estimator of states for players of type t that
+// use AB search. Hybrid players use the linear model. The model is loaded from
+// modelFile, or the generated code is used if it is empty (see
+// ab.GetEstimateFunction). For other types of players it returns nil.
+func LoadEstimator(t PlayerType, modelFile string, pm *hex.PatternMatcher) (ab.Estimator, error) {
+	switch t {
+	case AbDtType, AbLrType, AbErType:
+		return ab.GetEstimateFunction(t.String(), modelFile, pm.GetAttributes())
+	case HybridType:
+		return ab.GetEstimateFunction(AbLrType.String(), modelFile, pm.GetAttributes())
+	default:
+		if modelFile != "" {
+			return nil, fmt.Errorf("Players of type %s cannot use a model", t.String())
+		}
+		return nil, nil
+	}
+}
+
 // InitGame initializes the game
 func (ap *AbPlayer) InitGame(boardSize int, firstPlayer hex.Color) error {
 	ap.state = hex.NewState(byte(boardSize), firstPlayer)
-	ap.safeWinCells = nil
+	ap.winCarrier = nil
 	ap.lastOpponentAction = nil
 	return nil
 }
@@ -70,15 +84,15 @@ func (ap *AbPlayer) PrevAction(prevAction *hex.Action) {
 // decides to resign.
 func (ap *AbPlayer) NextAction() (*hex.Action, error) {
 	// Check if the player has already won (has a virtual connection)
-	if a, swc, ok := getActionIfWinningPathExists(ap.lastOpponentAction, ap.safeWinCells, ap.Color); ok {
+	if a, wc, ok := getActionIfWinningPathExists(ap.state, ap.lastOpponentAction, ap.winCarrier, ap.Color); ok {
 		ap.updatePlayerState(a)
-		ap.safeWinCells = swc
+		ap.winCarrier = wc
 		return a, nil
 	}
 
 	// Run Minimax with alpha-beta pruning
 	chosenAction, searchedTree := ab.AlphaBeta(ap.state, ap.timeToRun, ap.createTree,
-		ap.gridChan, ap.patChan, ap.resultChan, ap.getEstimatedValue, ap.subtype.String())
+		ap.patMatcher, ap.estimator)
 
 	if chosenAction == nil {
 		if !ap.allowResignation {
@@ -110,10 +124,9 @@ func (ap *AbPlayer) NextAction() (*hex.Action, error) {
 	ap.updatePlayerState(chosenAction)
 
 	// Check if player has a virtual connection
-	if exists, solution := ap.state.IsGoalState(false); exists {
+	if carrier := getWinningCarrier(ap.state, ap.Color); carrier != nil {
 		fmt.Println(ap.subtype.String() + " player has a virtual connection!")
-		winPath := solution.([][2]int)
-		ap.safeWinCells = findSafeCells(winPath, ap.state.GetSize(), ap.Color)
+		ap.winCarrier = carrier
 	}
 
 	return chosenAction, nil
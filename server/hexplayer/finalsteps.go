@@ -9,111 +9,78 @@ import (
 	"github.com/RdecKa/0xAI/common/game/hex"
 )
 
-type cell struct {
-	x, y int
-}
-
-// findSafeCells returns all the bridges on the winning path.
-func findSafeCells(winPath [][2]int, boardSize int, playerColor hex.Color) [][2]cell {
-	safeCells := make([][2]cell, 0)
-	if playerColor == hex.Red {
-		if winPath[0][1] < boardSize-1 {
-			// Bridge to the bottom (bottom row does not have a stone yet)
-			x := winPath[0][0]
-			y := winPath[0][1]
-			safeCells = append(safeCells, returnTwoSafeCellsBetween(x, y, x-1, y+2))
-		}
-		if winPath[len(winPath)-2][1] > 0 {
-			// Bridge to the top
-			x := winPath[len(winPath)-2][0]
-			y := winPath[len(winPath)-2][1]
-			safeCells = append(safeCells, returnTwoSafeCellsBetween(x, y, x+1, y-2))
-		}
-	} else if playerColor == hex.Blue {
-		if winPath[0][0] < boardSize-1 {
-			// Bridge to the right
-			x := winPath[0][0]
-			y := winPath[0][1]
-			safeCells = append(safeCells, returnTwoSafeCellsBetween(x, y, x+2, y-1))
-		}
-		if winPath[len(winPath)-2][0] > 0 {
-			// Bridge to the left
-			x := winPath[len(winPath)-2][0]
-			y := winPath[len(winPath)-2][1]
-			safeCells = append(safeCells, returnTwoSafeCellsBetween(x, y, x-2, y+1))
-		}
-	}
-	for i := range winPath[:len(winPath)-2] {
-		if !(hex.DirectlyConnected(winPath[i], winPath[i+1])) {
-			safeCells = append(safeCells, returnTwoSafeCellsBetween(winPath[i][0], winPath[i][1], winPath[i+1][0], winPath[i+1][1]))
-		}
+// getWinningCarrier returns the carrier of a virtual connection between the
+// edges of player playerColor (see hex.HSearch), or nil if there is no such
+// connection.
+func getWinningCarrier(state *hex.State, playerColor hex.Color) [][2]int {
+	carrier, ok := hex.NewHSearch(state, playerColor).GetWinningCarrier()
+	if !ok {
+		return nil
 	}
-	return safeCells
+	return carrier
 }
 
-// returnTwoSafeCellsBetween returns two cells between (x1, y1) and (x2, y2).
-// (x1, y1) -- (x2, y2) must be a bridge, this function does not check that.
-func returnTwoSafeCellsBetween(x1, y1, x2, y2 int) [2]cell {
-	r := hex.GetTwoCellsBewteen([2]int{x1, y1}, [2]int{x2, y2})
-	return [2]cell{cell{r[0][0], r[0][1]}, cell{r[1][0], r[1][1]}}
-}
-
-// getAttackedBridge returns two integers:
-//	- index of the bridge that was attacked with Action prevAction (-1 if none of the bridges is attacked)
-//	- index of the cell in the attacked bridge that is still empty (-1 if none of the bridges is attacked)
-func getAttackedBridge(prevAction *hex.Action, safeWinCells [][2]cell) (int, int) {
-	for i, c := range safeWinCells {
-		x, y := prevAction.GetCoordinates()
-		if x == c[0].x && y == c[0].y {
-			return i, 1
-		}
-		if x == c[1].x && y == c[1].y {
-			return i, 0
+// indexOfCell returns the index of cell (x, y) in carrier or -1 if carrier
+// does not contain it
+func indexOfCell(carrier [][2]int, x, y int) int {
+	for i, c := range carrier {
+		if c[0] == x && c[1] == y {
+			return i
 		}
 	}
-	return -1, -1
+	return -1
 }
 
 // doNotLoseHope is called when the game is lost but player still want to attack
 // the opponent and wait for his/her mistake.
 func doNotLoseHope(state *hex.State, playerColor hex.Color) (*hex.Action, error) {
 	fmt.Println("A player doesn't want to give up!")
-	exists, solution := state.IsGoalState(false)
-	if !exists {
+	carrier := getWinningCarrier(state, playerColor.Opponent())
+	if len(carrier) == 0 {
 		return nil, errors.New("Game lost but solution does not exist")
 	}
-	winPath := solution.([][2]int)
 
-	// Find opponent's safe cells, attack one
-	safeCells := findSafeCells(winPath, state.GetSize(), playerColor.Opponent())
-	a := hex.NewAction(byte(safeCells[0][0].x), byte(safeCells[0][0].y), playerColor)
+	// Attack one of the cells that the opponent needs for the connection
+	a := hex.NewAction(byte(carrier[0][0]), byte(carrier[0][1]), playerColor)
 
 	return a, nil
 }
 
-// getActionIfWinningPathExists checks whether the winning path already exists.
-// If it does (indicated by bool return value), it returns an action that either
-// defends the attacked bridge (if there is an attacked bridge) or connects one
-// of the remaining open bridges. In the latter case, the updated safeWinCells
-// is also returned.
-func getActionIfWinningPathExists(lastOpponentAction *hex.Action, safeWinCells [][2]cell, playerColor hex.Color) (*hex.Action, [][2]cell, bool) {
-	if len(safeWinCells) <= 0 {
-		// Winning path does not exist (yet)
+// getActionIfWinningPathExists checks whether the winning connection already
+// exists. If it does (indicated by bool return value), it returns an action
+// that either restores the connection (if the opponent has played in its
+// carrier) or fills one of the cells in the carrier. The updated carrier is
+// also returned.
+func getActionIfWinningPathExists(state *hex.State, lastOpponentAction *hex.Action, winCarrier [][2]int, playerColor hex.Color) (*hex.Action, [][2]int, bool) {
+	if len(winCarrier) <= 0 {
+		// Winning connection does not exist (yet)
 		return nil, nil, false
 	}
 
-	var ec cell
-	if bridge, emptyCellIndex := getAttackedBridge(lastOpponentAction, safeWinCells); bridge > -1 {
-		// Opponent has attacked one of the bridges
-		ec = safeWinCells[bridge][emptyCellIndex]
-		safeWinCells = append(safeWinCells[:bridge], safeWinCells[bridge+1:]...)
-	} else {
-		// Select the first cell in the first bridge (doesn't really matter, which one)
-		ec = safeWinCells[0][0]
-		safeWinCells = safeWinCells[1:]
+	x, y := -1, -1
+	if lastOpponentAction != nil {
+		x, y = lastOpponentAction.GetCoordinates()
+	}
+	if i := indexOfCell(winCarrier, x, y); i < 0 {
+		// The connection is untouched, its carrier without the filled cell
+		// still holds it
+		c := winCarrier[0]
+		action := hex.NewAction(byte(c[0]), byte(c[1]), playerColor)
+		return action, winCarrier[1:], true
 	}
 
-	action := hex.NewAction(byte(ec.x), byte(ec.y), playerColor)
+	// Opponent has attacked the connection, find a cell in the carrier that
+	// restores it
+	for _, c := range winCarrier {
+		if c[0] == x && c[1] == y {
+			continue
+		}
+		action := hex.NewAction(byte(c[0]), byte(c[1]), playerColor)
+		s := state.GetSuccessorState(action).(hex.State)
+		if carrier := getWinningCarrier(&s, playerColor); carrier != nil {
+			return action, carrier, true
+		}
+	}
 
-	return action, safeWinCells, true
+	return nil, nil, false
 }
@@ -21,6 +21,7 @@ const (
 	AbDtType   PlayerType = 4
 	AbLrType   PlayerType = 5
 	HybridType PlayerType = 6
+	AbErType   PlayerType = 7
 )
 
 // HexPlayer represents a player of hex that can be either human or computer.
@@ -48,6 +49,8 @@ func GetPlayerTypeFromString(t string) PlayerType {
 		return AbLrType
 	case "hybrid":
 		return HybridType
+	case "abER":
+		return AbErType
 	default:
 		fmt.Println(fmt.Errorf("Invalid type '%s'", t))
 		return Unknown
@@ -68,6 +71,8 @@ func (t PlayerType) String() string {
 		return "abLR"
 	case HybridType:
 		return "hybrid"
+	case AbErType:
+		return "abER"
 	default:
 		fmt.Println(fmt.Errorf("Invalid type '%s'", string(t)))
 		return ""
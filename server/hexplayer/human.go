@@ -4,8 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"github.com/RdecKa/0xAI/common/game/hex"
 	"github.com/gorilla/websocket"
@@ -14,9 +12,10 @@ import (
 // HumanPlayer accepts client's (human's) moves. It uses a websocket to connect
 // to the client.
 type HumanPlayer struct {
-	Color  hex.Color       // Player's color
-	Webso  *websocket.Conn // Websocket connecting server and client
-	numWin int             // Number of wins
+	Color     hex.Color       // Player's color
+	Webso     *websocket.Conn // Websocket connecting server and client
+	numWin    int             // Number of wins
+	boardSize int             // Size of the grid in the current game
 }
 
 // OpenConn opens a new websocket.
@@ -31,13 +30,14 @@ func OpenConn(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 // CreateHumanPlayer creates a human player with given websocket and color of
 // the player.
 func CreateHumanPlayer(conn *websocket.Conn, color hex.Color) *HumanPlayer {
-	hp := HumanPlayer{color, conn, 0}
+	hp := HumanPlayer{color, conn, 0, 0}
 	return &hp
 }
 
 // InitGame initializes the game. It sends board size and player's color to the
 // client and waits for replay.
-func (hp HumanPlayer) InitGame(boardSize int, firstPlayer hex.Color) error {
+func (hp *HumanPlayer) InitGame(boardSize int, firstPlayer hex.Color) error {
+	hp.boardSize = boardSize
 	m := []byte(fmt.Sprintf("INIT SIZE:%v COLOR:%v", boardSize, hp.Color))
 	hp.Webso.WriteMessage(websocket.TextMessage, m)
 
@@ -57,7 +57,8 @@ func (hp HumanPlayer) PrevAction(prevAction *hex.Action) {
 	hp.Webso.WriteMessage(websocket.TextMessage, m)
 }
 
-// NextAction returns an action to be performed.
+// NextAction returns an action to be performed. The client sends the action in
+// the standard notation (for example "c4" or "swap").
 func (hp HumanPlayer) NextAction() (*hex.Action, error) {
 	_, m, err := hp.Webso.ReadMessage()
 	if err != nil {
@@ -65,25 +66,12 @@ func (hp HumanPlayer) NextAction() (*hex.Action, error) {
 		return nil, err
 	}
 
-	c := strings.Split(string(m), ",")
-	if len(c) != 2 {
-		e := "Exactly two coordinates are expected."
-		hp.Webso.WriteMessage(websocket.TextMessage, []byte("ERROR "+e))
-		return nil, errors.New(e)
-	}
-
-	coords := [2]byte{}
-	for i := 0; i < 2; i++ {
-		co, err := strconv.Atoi(c[i])
-		if err != nil {
-			hp.Webso.WriteMessage(websocket.TextMessage, []byte("ERROR "+err.Error()))
-			return nil, err
-		}
-		coords[i] = byte(co)
+	a, err := hex.ParseAction(string(m), byte(hp.boardSize), hp.Color)
+	if err != nil {
+		hp.Webso.WriteMessage(websocket.TextMessage, []byte("ERROR "+err.Error()))
+		return nil, err
 	}
 
-	a := hex.NewAction(coords[0], coords[1], hp.Color)
-
 	return a, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/RdecKa/0xAI/3-ab"
 	"github.com/RdecKa/0xAI/common/game/hex"
 )
 
@@ -19,10 +20,11 @@ type HybridPlayer struct {
 	numStonesplaced    int
 }
 
-// CreateHybridPlayer creates a new player
+// CreateHybridPlayer creates a new player. Its AB subplayer evaluates states
+// with Estimator est.
 func CreateHybridPlayer(c hex.Color, t time.Duration, allowResignation bool,
-	patFileName string, ABsubtype PlayerType, changeTypeAt int) *HybridPlayer {
-	ABsubPlayer := CreateAbPlayer(c, nil, t, allowResignation, patFileName, false, ABsubtype)
+	pm *hex.PatternMatcher, ABsubtype PlayerType, est ab.Estimator, changeTypeAt int) *HybridPlayer {
+	ABsubPlayer := CreateAbPlayer(c, nil, t, allowResignation, pm, false, ABsubtype, est)
 	MCTSsubPlayer := CreateMCTSplayer(c, math.Sqrt(2), t, 10, allowResignation)
 	hp := HybridPlayer{c, nil, 0, nil, [2]HexPlayer{ABsubPlayer, MCTSsubPlayer}, 0, changeTypeAt, 0}
 	return &hp
@@ -72,7 +74,7 @@ func (hp *HybridPlayer) updatePlayerState(a *hex.Action) {
 		s := hp.state.Clone().(hex.State)
 		(hp.subPlayers[1]).(*MCTSplayer).initGameFromState(&s,
 			hp.lastOpponentAction,
-			hp.subPlayers[0].(*AbPlayer).safeWinCells)
+			hp.subPlayers[0].(*AbPlayer).winCarrier)
 	}
 }
 
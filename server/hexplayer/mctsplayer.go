@@ -18,7 +18,7 @@ type MCTSplayer struct {
 	minBeforeExpand    uint
 	mc                 *mcts.MCTS
 	state              *hex.State
-	safeWinCells       [][2]cell
+	winCarrier         [][2]int
 	numWin             int
 	lastOpponentAction *hex.Action
 	allowResignation   bool
@@ -35,16 +35,16 @@ func (mp *MCTSplayer) InitGame(boardSize int, firstPlayer hex.Color) error {
 	initState := hex.NewState(byte(boardSize), firstPlayer)
 	mp.mc = mcts.InitMCTS(*initState, mp.explorationFactor, mp.minBeforeExpand)
 	mp.state = initState
-	mp.safeWinCells = nil
+	mp.winCarrier = nil
 	mp.lastOpponentAction = nil
 	return nil
 }
 
-func (mp *MCTSplayer) initGameFromState(initState *hex.State, lastOpponentAction *hex.Action, safeWinCells [][2]cell) error {
+func (mp *MCTSplayer) initGameFromState(initState *hex.State, lastOpponentAction *hex.Action, winCarrier [][2]int) error {
 	mp.mc = mcts.InitMCTS(initState, mp.explorationFactor, mp.minBeforeExpand)
 	mp.state = initState
 	mp.lastOpponentAction = lastOpponentAction
-	mp.safeWinCells = safeWinCells
+	mp.winCarrier = winCarrier
 
 	return nil
 }
@@ -62,9 +62,9 @@ func (mp *MCTSplayer) PrevAction(prevAction *hex.Action) {
 // decides to resign.
 func (mp *MCTSplayer) NextAction() (*hex.Action, error) {
 	// Check if the player has already won (has a virtual connection)
-	if a, swc, ok := getActionIfWinningPathExists(mp.lastOpponentAction, mp.safeWinCells, mp.Color); ok {
+	if a, wc, ok := getActionIfWinningPathExists(mp.state, mp.lastOpponentAction, mp.winCarrier, mp.Color); ok {
 		mp.updatePlayerState(a)
-		mp.safeWinCells = swc
+		mp.winCarrier = wc
 		return a, nil
 	}
 
@@ -111,10 +111,9 @@ func (mp *MCTSplayer) NextAction() (*hex.Action, error) {
 	mp.state = &s
 
 	// Check if player has a virtual connection
-	if exists, solution := mp.state.IsGoalState(false); exists {
+	if carrier := getWinningCarrier(mp.state, mp.Color); carrier != nil {
 		fmt.Println("MCTS Player has a virtual connection!")
-		winPath := solution.([][2]int)
-		mp.safeWinCells = findSafeCells(winPath, mp.state.GetSize(), mp.Color)
+		mp.winCarrier = carrier
 	}
 
 	return bestAction, nil
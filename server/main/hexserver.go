@@ -9,10 +9,12 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"time"
 
+	"github.com/RdecKa/0xAI/3-ab"
 	"github.com/RdecKa/0xAI/common/game/hex"
 	"github.com/RdecKa/0xAI/server/cmpr"
 	"github.com/RdecKa/0xAI/server/hexgame"
@@ -37,6 +39,13 @@ const defaultBoardSize = 7
 const defaultNumGames = 1
 const defaultTime = 1
 
+// patMatcher counts patterns for all players in games on the server
+var patMatcher *hex.PatternMatcher
+
+// modelDir is the folder with model files that AB players can use (see
+// learn.WriteModel)
+var modelDir string
+
 func makeHandler(fn func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		a := validPath.FindStringSubmatch(r.URL.Path)
@@ -57,7 +66,15 @@ func playHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func selectHandler(w http.ResponseWriter, r *http.Request) {
-	err := templates.ExecuteTemplate(w, "select.html", nil)
+	models, err := filepath.Glob(filepath.Join(modelDir, "*.json"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range models {
+		models[i] = filepath.Base(models[i])
+	}
+	err = templates.ExecuteTemplate(w, "select.html", struct{ Models []string }{models})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -73,6 +90,7 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 	numGamesString, okNumGames := args["numgames"]
 	redTimeString, okRedTime := args["redtime"]
 	blueTimeString, okBlueTime := args["bluetime"]
+	redModel, blueModel := args.Get("redmodel"), args.Get("bluemodel")
 
 	wa := okWatch && watch[0] == "false"
 	pair := [2]hexplayer.HexPlayer{} // 0 - red, 1 - blue
@@ -118,6 +136,20 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Load models before the connection is opened, so that errors can be
+	// reported
+	var redEst, blueEst ab.Estimator
+	if okRed && okBlue {
+		if redEst, err = loadEstimator(red[0], redModel); err == nil {
+			blueEst, err = loadEstimator(blue[0], blueModel)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			log.Println(err)
+			return
+		}
+	}
+
 	conn, err := hexplayer.OpenConn(w, r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -125,7 +157,7 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var rFunc, bFunc func(hex.Color, *websocket.Conn, int, int, bool, hexplayer.PlayerType) hexplayer.HexPlayer
+	var rFunc, bFunc func(hex.Color, *websocket.Conn, int, int, bool, hexplayer.PlayerType, ab.Estimator) hexplayer.HexPlayer
 
 	if okRed && red[0] == "human" {
 		rFunc = createHumanPlayer
@@ -154,11 +186,11 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 	if rFunc == nil || bFunc == nil {
 		log.Println("Wrong or missing arguments for players. Using default.")
 		wa = false
-		pair[0] = createHumanPlayer(hex.Red, conn, redTime, 0, wa, hexplayer.GetPlayerTypeFromString(red[0]))
-		pair[1] = createMCTSplayer(hex.Blue, conn, blueTime, 0, wa, hexplayer.GetPlayerTypeFromString(blue[0]))
+		pair[0] = createHumanPlayer(hex.Red, conn, redTime, 0, wa, hexplayer.HumanType, nil)
+		pair[1] = createMCTSplayer(hex.Blue, conn, blueTime, 0, wa, hexplayer.MctsType, nil)
 	} else {
-		pair[0] = rFunc(hex.Red, conn, redTime, 12, wa, hexplayer.GetPlayerTypeFromString(red[0]))
-		pair[1] = bFunc(hex.Blue, conn, blueTime, 12, wa, hexplayer.GetPlayerTypeFromString(blue[0]))
+		pair[0] = rFunc(hex.Red, conn, redTime, 12, wa, hexplayer.GetPlayerTypeFromString(red[0]), redEst)
+		pair[1] = bFunc(hex.Blue, conn, blueTime, 12, wa, hexplayer.GetPlayerTypeFromString(blue[0]), blueEst)
 	}
 
 	c := conn
@@ -169,24 +201,37 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 	go hexgame.Play(boardSize, pair, numGames, c, nil, nil, playDir+startTimeFormat)
 }
 
-func createHumanPlayer(color hex.Color, conn *websocket.Conn, _, _ int, _ bool, _ hexplayer.PlayerType) hexplayer.HexPlayer {
+// loadEstimator returns the estimator of states for a player of a given type,
+// with the model from a file in modelDir, or nil for players that do not need
+// one (see hexplayer.LoadEstimator)
+func loadEstimator(playerType, modelFile string) (ab.Estimator, error) {
+	if modelFile != "" {
+		if filepath.Base(modelFile) != modelFile {
+			return nil, fmt.Errorf("Invalid model file '%s'", modelFile)
+		}
+		modelFile = filepath.Join(modelDir, modelFile)
+	}
+	return hexplayer.LoadEstimator(hexplayer.GetPlayerTypeFromString(playerType), modelFile, patMatcher)
+}
+
+func createHumanPlayer(color hex.Color, conn *websocket.Conn, _, _ int, _ bool, _ hexplayer.PlayerType, _ ab.Estimator) hexplayer.HexPlayer {
 	return hexplayer.CreateHumanPlayer(conn, color)
 }
 
-func createMCTSplayer(color hex.Color, _ *websocket.Conn, secondsPerAction, _ int, allowResignation bool, _ hexplayer.PlayerType) hexplayer.HexPlayer {
+func createMCTSplayer(color hex.Color, _ *websocket.Conn, secondsPerAction, _ int, allowResignation bool, _ hexplayer.PlayerType, _ ab.Estimator) hexplayer.HexPlayer {
 	return hexplayer.CreateMCTSplayer(color, math.Sqrt(2), time.Duration(secondsPerAction)*time.Second, 10, allowResignation)
 }
 
-func createAbPlayer(color hex.Color, conn *websocket.Conn, secondsPerAction, _ int, allowResignation bool, subtype hexplayer.PlayerType) hexplayer.HexPlayer {
-	return hexplayer.CreateAbPlayer(color, conn, time.Duration(secondsPerAction)*time.Second, allowResignation, patternFile, true, subtype)
+func createAbPlayer(color hex.Color, conn *websocket.Conn, secondsPerAction, _ int, allowResignation bool, subtype hexplayer.PlayerType, est ab.Estimator) hexplayer.HexPlayer {
+	return hexplayer.CreateAbPlayer(color, conn, time.Duration(secondsPerAction)*time.Second, allowResignation, patMatcher, true, subtype, est)
 }
 
-func createRandPlayer(color hex.Color, _ *websocket.Conn, _, _ int, _ bool, _ hexplayer.PlayerType) hexplayer.HexPlayer {
+func createRandPlayer(color hex.Color, _ *websocket.Conn, _, _ int, _ bool, _ hexplayer.PlayerType, _ ab.Estimator) hexplayer.HexPlayer {
 	return hexplayer.CreateRandPlayer(color)
 }
 
-func createHybridPlayer(color hex.Color, _ *websocket.Conn, secondsPerAction, changeTypeAt int, allowResignation bool, _ hexplayer.PlayerType) hexplayer.HexPlayer {
-	return hexplayer.CreateHybridPlayer(color, time.Duration(secondsPerAction)*time.Second, allowResignation, patternFile, hexplayer.AbLrType, changeTypeAt)
+func createHybridPlayer(color hex.Color, _ *websocket.Conn, secondsPerAction, changeTypeAt int, allowResignation bool, _ hexplayer.PlayerType, est ab.Estimator) hexplayer.HexPlayer {
+	return hexplayer.CreateHybridPlayer(color, time.Duration(secondsPerAction)*time.Second, allowResignation, patMatcher, hexplayer.AbLrType, est, changeTypeAt)
 }
 
 func comparePlayers() {
@@ -274,7 +319,9 @@ func comparePlayers() {
 
 func main() {
 	pOnlyCompare := flag.Bool("cmpr", false, "Run test matches between players")
+	pModelDir := flag.String("models", "data/models/", "Folder with model files that players can select")
 	flag.Parse()
+	modelDir = *pModelDir
 
 	if *pOnlyCompare {
 		fmt.Println("Running comparisons")
@@ -293,6 +340,11 @@ func main() {
 		fmt.Println(err)
 	}
 
+	patMatcher, err = hex.Load(patternFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Register handlers
 	http.HandleFunc("/play/", makeHandler(playHandler))
 	http.HandleFunc("/select/", makeHandler(selectHandler))
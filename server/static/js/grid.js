@@ -16,6 +16,8 @@ function hexGrid(socket, abSearchTree) {
 			abSearchTree: abSearchTree,
 			topRowColor: colors.RED,
 			leftRowColor: colors.BLUE,
+			numMoves: 0,
+			swapped: false,
 		},
 		computed: {
 			boardWidth: function () {
@@ -25,6 +27,9 @@ function hexGrid(socket, abSearchTree) {
 			boardHeight: function () {
 				let height = (this.size + 1) * unitY + 2 * hexSide + 2 * margin;
 				return height + "px";
+			},
+			canSwap: function () {
+				return this.playersTurn && this.numMoves == 1 && !this.swapped;
 			}
 		},
 		methods: {
@@ -35,6 +40,9 @@ function hexGrid(socket, abSearchTree) {
 			onClickReceived: function (click) {
 				sendMove(this, {x: click.x, y: click.y, c: this.myColor});
 			},
+			onSwapClicked: function () {
+				sendSwap(this);
+			},
 			setIsMyTurn: function (isMyTurn) {
 				this.playersTurn = isMyTurn;
 			}
@@ -82,6 +90,8 @@ function respondToMessage(obj, msg) {
 			let s = ms[1].split(":");
 			obj.initGrid(parseInt(s[1]));
 			obj.setIsMyTurn(false);
+			obj.numMoves = 0;
+			obj.swapped = false;
 
 			let c = ms[2].split(":")
 			switch (c[1]) {
@@ -137,7 +147,13 @@ function respondToMessage(obj, msg) {
  * @param moveObj object representing a move
  */
 function receiveMove(obj, moveObj) {
+	if (moveObj.swap) {
+		// The only stone on the board is replaced by the swapped one
+		createHexGrid(obj, obj.size);
+		obj.swapped = true;
+	}
 	obj.grid[moveObj.y].splice(moveObj.x, 1, moveObj.c);
+	obj.numMoves++;
 }
 
 /**
@@ -151,6 +167,24 @@ function sendMove(obj, moveObj) {
 	obj.socket.send(encodeMove(moveObj));
 }
 
+/**
+ * Swaps the opponent's only stone (reflects it over the long diagonal and
+ * changes its color) and sends the swap move via obj's socket.
+ * @param obj Vue instance
+ */
+function sendSwap(obj) {
+	for (let y = 0; y < obj.size; y++) {
+		for (let x = 0; x < obj.size; x++) {
+			if (obj.grid[y][x] != colors.NONE) {
+				receiveMove(obj, {x: y, y: x, c: obj.myColor, swap: true});
+				obj.setIsMyTurn(false);
+				obj.socket.send("swap");
+				return;
+			}
+		}
+	}
+}
+
 /**
  * Creates a new 2D array to be used as a game board.
  * @param obj  Vue instance
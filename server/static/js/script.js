@@ -58,16 +58,30 @@ function getCellColorName(color) {
 }
 
 /**
- * Formats the given objects in a way that can be sent to server.
+ * Formats the given objects in a way that can be sent to server (standard Hex
+ * notation, for example 'c4').
  * @param moveObj move object to be encoded and sent
  */
 function encodeMove(moveObj) {
-	return moveObj.x.toString() + "," + moveObj.y.toString();
+	return encodeColumn(moveObj.x) + (moveObj.y + 1).toString();
+}
+
+/**
+ * Returns letters that represent the column with index x ('a', 'b', ..., 'z',
+ * 'aa', 'ab', ...).
+ * @param x index of the column
+ */
+function encodeColumn(x) {
+	let s = "";
+	for (x++; x > 0; x = Math.floor((x - 1) / 26)) {
+		s = String.fromCharCode(97 + (x - 1) % 26) + s;
+	}
+	return s;
 }
 
 /**
  * Reads coordinates and color from the string representing a move (received
- * from server). Example of a string received: 'r: (2, 3)'.
+ * from server). Examples of strings received: 'r: c4', 'b: swap d3'.
  * @param moveString
  */
 function decodeMove(moveString) {
@@ -83,8 +97,14 @@ function decodeMove(moveString) {
 			console.log("INVALID COLOR '" + moveString.charAt(0) + "'");
 			color = colors.NONE;
 	}
-	let coords = moveString.substring(4, moveString.length - 1).split(", ");
-	return {x: parseInt(coords[0]), y: parseInt(coords[1]), c: color};
+	let swap = moveString.substring(3, 7) == "swap";
+	let cell = moveString.substring(swap ? 8 : 3);
+	let i = 0;
+	let x = 0;
+	for (; i < cell.length && cell.charAt(i) >= 'a' && cell.charAt(i) <= 'z'; i++) {
+		x = x * 26 + cell.charCodeAt(i) - 96;
+	}
+	return {x: x - 1, y: parseInt(cell.substring(i)) - 1, c: color, swap: swap};
 }
 
 /**
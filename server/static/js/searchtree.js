@@ -118,21 +118,19 @@ Vue.component('node', {
 
 				let rowN = this.node.value.state.grid[row];
 				for (let col = 0; col < this.size; col++) {
-					let c = rowN & 3;
-					switch (c) {
-						case 0:
+					switch (rowN.charAt(col)) {
+						case ".":
 							r += drawCellWithClass("cell empty");
 							break;
-						case 1:
+						case "r":
 							r += drawCellWithClass("cell red");
 							break;
-						case 2:
+						case "b":
 							r += drawCellWithClass("cell blue");
 							break;
 						default:
 							r += drawCellWithClass("cell undef");
 					}
-					rowN = rowN >> 2;
 				}
 
 				// Draw right blue column
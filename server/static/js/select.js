@@ -8,7 +8,7 @@ function selectPlayers() {
 		data: {
 			buttonActive: false,
 			message: "Select both players, please.",
-			selection: {Red: {type:null, time:0}, Blue: {type:null, time:0}},
+			selection: {Red: {type:null, time:0, model:""}, Blue: {type:null, time:0, model:""}},
 			watchInBrowser: true,
 			watchInBrowserDisabled: false,
 			boardSize: 11,
@@ -29,12 +29,19 @@ function selectPlayers() {
 				if (this.selection.Blue.type != "human" && this.selection.Blue.type != "rand") {
 					newLocation += "&bluetime=" + this.selection.Blue.time
 				}
+				if (this.selection.Red.model) {
+					newLocation += "&redmodel=" + encodeURIComponent(this.selection.Red.model)
+				}
+				if (this.selection.Blue.model) {
+					newLocation += "&bluemodel=" + encodeURIComponent(this.selection.Blue.model)
+				}
 
 				window.location.href = newLocation;
 			},
 			onSelectionChange: function (event) {
 				this.selection[event.color].type = event.type;
 				this.selection[event.color].time = event.time;
+				this.selection[event.color].model = event.model;
 				if (this.selection.Red  == null || this.selection.Red.type  == null ||
 					this.selection.Blue == null || this.selection.Blue.type == null) {
 					this.buttonActive = false;
@@ -80,28 +87,41 @@ Vue.component("select-player", {
 				<label :for="'abDT-'  + color">ABDL</label>
 				<input type="number" min="1" :id="'time-abDT-' + color" v-model="time.abDT" @change="selectionChange">
 				<label :for="'time-abDT-' + color">seconds</label>
+				<input type="text" list="models" placeholder="generated code" :id="'model-abDT-' + color" v-model="model.abDT" @change="selectionChange">
+				<label :for="'model-abDT-' + color">model</label>
 				<br>
 				<input type="radio" :id="'abLR-'  + color" :name="color" value="abLR"  v-model="player" @change="selectionChange" />
 				<label :for="'abLR-'  + color">ABLR</label>
 				<input type="number" min="1" :id="'time-abLR-' + color" v-model="time.abLR" @change="selectionChange">
 				<label :for="'time-abLR-' + color">seconds</label>
+				<input type="text" list="models" placeholder="generated code" :id="'model-abLR-' + color" v-model="model.abLR" @change="selectionChange">
+				<label :for="'model-abLR-' + color">model</label>
+				<br>
+				<input type="radio" :id="'abER-'  + color" :name="color" value="abER"  v-model="player" @change="selectionChange" />
+				<label :for="'abER-'  + color">ABER</label>
+				<input type="number" min="1" :id="'time-abER-' + color" v-model="time.abER" @change="selectionChange">
+				<label :for="'time-abER-' + color">seconds</label>
 				<br>
 				<input type="radio" :id="'hybrid-'  + color" :name="color" value="hybrid"  v-model="player" @change="selectionChange" />
 				<label :for="'hybrid-'  + color">HYBR</label>
 				<input type="number" min="1" :id="'time-hybrid-' + color" v-model="time.hybrid" @change="selectionChange">
 				<label :for="'time-hybrid-' + color">seconds</label>
+				<input type="text" list="models" placeholder="generated code" :id="'model-hybrid-' + color" v-model="model.hybrid" @change="selectionChange">
+				<label :for="'model-hybrid-' + color">model</label>
 			</div>
 		</div>`,
 	props: ["color"],
 	data: function () {
 		return {
 			player: null,
-			time: {mcts: 1, abDT: 1, abLR: 1, hybrid: 1},
+			time: {mcts: 1, abDT: 1, abLR: 1, abER: 1, hybrid: 1},
+			model: {abDT: "", abLR: "", hybrid: ""},
 		}
 	},
 	methods: {
 		selectionChange: function () {
-			this.$emit("selection-change", {color: this.color, type: this.player, time: this.time[this.player]});
+			this.$emit("selection-change", {color: this.color, type: this.player, time: this.time[this.player],
+				model: this.model[this.player] || ""});
 		}
 	}
 })
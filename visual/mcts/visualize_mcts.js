@@ -72,21 +72,19 @@ Vue.component('item', {
 
 				let rowN = this.model.value.state.grid[row];
 				for (let col = 0; col < this.size; col++) {
-					let c = rowN & 3;
-					switch (c) {
-						case 0:
+					switch (rowN.charAt(col)) {
+						case ".":
 							r += "<span class=\"cell empty\"></span>";
 							break;
-						case 1:
+						case "r":
 							r += "<span class=\"cell red\"></span>";
 							break;
-						case 2:
+						case "b":
 							r += "<span class=\"cell blue\"></span>";
 							break;
 						default:
 							r += "<span class=\"cell undef\"></span>";
 					}
-					rowN = rowN >> 2;
 				}
 
 				// Draw right blue column